@@ -0,0 +1,52 @@
+package errors
+
+import (
+	"errors"
+)
+
+// CodeValidationFailed is the code of validation error responses.
+const CodeValidationFailed = "VALIDATION_FAILED"
+
+// CodeMalformedJSON is the code of responses to request bodies that aren't valid JSON.
+const CodeMalformedJSON = "MALFORMED_JSON"
+
+// CodeInvalidParameter is the code of responses to query or path parameters of wrong type.
+const CodeInvalidParameter = "INVALID_PARAMETER"
+
+// Coder is implemented by errors with machine-readable code, which is returned to clients
+// as error.code of the response, e.g. "ORDER_NOT_FOUND".
+type Coder interface {
+	ErrorCode() string
+}
+
+type codedError struct {
+	err  error
+	code string
+}
+
+// WithCode wraps err with code. err stays available to errors.Is and errors.As,
+// so its response status is kept. WithCode returns nil if err is nil.
+func WithCode(err error, code string) error {
+	if err == nil {
+		return nil
+	}
+
+	return &codedError{err: err, code: code}
+}
+
+// NewCoded returns a new error with message and code.
+func NewCoded(code, message string) error {
+	return WithCode(errors.New(message), code)
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+func (e *codedError) ErrorCode() string {
+	return e.code
+}
@@ -0,0 +1,12 @@
+package errors
+
+// HTTPStatuser is implemented by errors dictating status code of the error response.
+type HTTPStatuser interface {
+	HTTPStatus() int
+}
+
+// PublicMessager is implemented by errors exposing message safe to return to clients,
+// it's used in the error response instead of Error().
+type PublicMessager interface {
+	PublicMessage() string
+}
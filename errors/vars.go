@@ -5,11 +5,30 @@ import (
 )
 
 var (
-	ErrNilRouter        = errors.New("router is nil")
-	ErrFHServerShutdown = errors.New("cannot complete graceful shutdown")
-	ErrNotFound         = errors.New("route not found")
-	ErrNoMethod         = errors.New("method not allowed")
-	ErrServerError      = errors.New("internal server error")
-	ErrRecordNotFound   = errors.New("record not found")
-	ErrConflict         = errors.New("conflict")
+	ErrNilRouter                  = errors.New("router is nil")
+	ErrFHServerShutdown           = errors.New("cannot complete graceful shutdown")
+	ErrServerNotRunning           = errors.New("server is not running")
+	ErrNotFound                   = errors.New("route not found")
+	ErrNoMethod                   = errors.New("method not allowed")
+	ErrServerError                = errors.New("internal server error")
+	ErrRecordNotFound             = errors.New("record not found")
+	ErrConflict                   = errors.New("conflict")
+	ErrUnauthorized               = errors.New("unauthorized")
+	ErrForbidden                  = errors.New("forbidden")
+	ErrRequestTimeout             = errors.New("request timeout")
+	ErrTooManyRequests            = errors.New("too many requests")
+	ErrBadGateway                 = errors.New("bad gateway")
+	ErrServiceUnavailable         = errors.New("service unavailable")
+	ErrUnavailableForLegalReasons = errors.New("unavailable for legal reasons")
+	ErrTLSNotConfigured           = errors.New("tls is not configured")
+	ErrBadCAPEM                   = errors.New("no valid CA certificates in PEM")
+	ErrPreforkNotAllowed          = errors.New("prefork is not allowed")
+	ErrUpgradeNotReady            = errors.New("upgraded process is not ready")
+	ErrUpgradeNotSupported        = errors.New("upgrade is not supported on this platform")
+	ErrUnknownHost                = errors.New("unknown host")
+	ErrFileTooLarge               = errors.New("file is too large")
+	ErrFilesTooLarge              = errors.New("total size of files is too large")
+	ErrUnsupportedFileType        = errors.New("unsupported file type")
+	ErrInvalidPreEncoded          = errors.New("invalid pre-encoded JSON")
+	ErrInvalidStatus              = errors.New("invalid status code")
 )
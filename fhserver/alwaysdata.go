@@ -0,0 +1,72 @@
+package fhserver
+
+import (
+	"encoding/xml"
+	"reflect"
+
+	"github.com/valyala/fasthttp"
+)
+
+// userValueAlwaysData marks requests whose successful responses always have data field,
+// see WithAlwaysData.
+const userValueAlwaysData = "fhserver.alwaysData"
+
+// alwaysData is response payload encoded with data field even if it's nil.
+type alwaysData struct {
+	v interface{}
+}
+
+// nullData is encoded as JSON null, Response.Data is omitted when it's nil.
+type nullData struct{}
+
+// WithAlwaysData makes successful responses of JSON and other response helpers always have
+// data field like JSONAlwaysData does.
+func WithAlwaysData(enabled bool) Option {
+	return func(s *Server) {
+		s.alwaysData = enabled
+	}
+}
+
+// JSONAlwaysData makes common response in json like JSON does, but successful response always
+// has data field: nil is encoded as "data":null, nil slices and maps as empty collections.
+// Error responses are the same as JSON ones.
+func JSONAlwaysData(ctx *fasthttp.RequestCtx, v interface{}) {
+	JSON(ctx, alwaysData{v: v})
+}
+
+// alwaysDataMiddleware marks requests for response helpers, see WithAlwaysData.
+func alwaysDataMiddleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.SetUserValue(userValueAlwaysData, true)
+
+		next(ctx)
+	}
+}
+
+// explicitData returns response data which isn't omitted: nullData for nil, empty collections
+// for nil slices and maps.
+func explicitData(v interface{}) interface{} {
+	if v == nil {
+		return nullData{}
+	}
+
+	rv := reflect.ValueOf(v)
+
+	switch {
+	case rv.Kind() == reflect.Slice && rv.IsNil():
+		return reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	case rv.Kind() == reflect.Map && rv.IsNil():
+		return reflect.MakeMap(rv.Type()).Interface()
+	}
+
+	return v
+}
+
+func (nullData) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// MarshalXML encodes nil data as empty element.
+func (nullData) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement("", start)
+}
@@ -0,0 +1,88 @@
+package fhserver
+
+import (
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+func TestJSONAlwaysData(t *testing.T) {
+	t.Parallel()
+
+	type order struct {
+		ID    int    `json:"id"`
+		Title string `json:"title"`
+	}
+
+	type testCase struct {
+		name     string
+		response interface{}
+		wantJSON string
+		wantBody string
+	}
+
+	tcs := []testCase{
+		{name: "nil", response: nil, wantJSON: `{}`, wantBody: `{"data":null}`},
+		{name: "nil slice", response: []order(nil), wantJSON: `{"data":null}`, wantBody: `{"data":[]}`},
+		{name: "empty slice", response: []order{}, wantJSON: `{"data":[]}`, wantBody: `{"data":[]}`},
+		{name: "nil map", response: map[string]int(nil), wantJSON: `{"data":null}`, wantBody: `{"data":{}}`},
+		{name: "zero struct", response: order{}, wantJSON: `{"data":{"id":0,"title":""}}`, wantBody: `{"data":{"id":0,"title":""}}`},
+		{
+			name:     "error",
+			response: pkgErr.ErrConflict,
+			wantJSON: `{"error":{"message":"conflict"}}`,
+			wantBody: `{"error":{"message":"conflict"}}`,
+		},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+		JSON(ctx, tc.response)
+
+		if got := string(ctx.Response.Body()); got != tc.wantJSON {
+			t.Errorf("%s: JSON body = %s, want %s", tc.name, got, tc.wantJSON)
+		}
+
+		ctx = &fasthttp.RequestCtx{}
+		JSONAlwaysData(ctx, tc.response)
+
+		if got := string(ctx.Response.Body()); got != tc.wantBody {
+			t.Errorf("%s: JSONAlwaysData body = %s, want %s", tc.name, got, tc.wantBody)
+		}
+	}
+}
+
+func TestWithAlwaysData(t *testing.T) {
+	t.Parallel()
+
+	r := router.New()
+	r.GET("/nil", func(ctx *fasthttp.RequestCtx) {
+		JSON(ctx, nil)
+	})
+	r.GET("/xml", func(ctx *fasthttp.RequestCtx) {
+		XML(ctx, nil)
+	})
+	r.GET("/page", func(ctx *fasthttp.RequestCtx) {
+		JSONPage(ctx, []int(nil), PageMeta{})
+	})
+
+	s := New(cfgstructs.WebServer{}, WithAlwaysData(true)).SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	tcs := map[string]string{
+		"/nil":  `{"data":null}`,
+		"/xml":  `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<response><data></data></response>`,
+		"/page": `{"data":[],"meta":{"total":0}}`,
+	}
+
+	for path, want := range tcs {
+		resp := doRequest(s.httpServer.Handler, newRequest("GET", path))
+
+		if got := string(resp.Body()); got != want {
+			t.Errorf("%s: body = %s, want %s", path, got, want)
+		}
+	}
+}
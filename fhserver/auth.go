@@ -2,11 +2,16 @@ package fhserver
 
 import (
 	"bytes"
+	"crypto/subtle"
 	"encoding/base64"
 
 	"github.com/valyala/fasthttp"
 )
 
+// UserValuePrincipal is a user value key holding authenticated principal of the request,
+// the username for BasicAuthMiddleware. Custom auth middlewares may set it too, see Context.
+const UserValuePrincipal = "fhserver.principal"
+
 var colonDelimiter = []byte(":")
 
 // BasicAuth returns the username and password provided in the request's
@@ -41,3 +46,28 @@ func AuthorizationHeader(ctx *fasthttp.RequestCtx) (payload []byte, exist bool)
 
 	return authHeader, true
 }
+
+// BasicAuthMiddleware returns middleware answering 401 to requests without valid basic auth credentials.
+// The username is stored as UserValuePrincipal.
+func BasicAuthMiddleware(user, pass string) Middleware {
+	return func(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			payload, _ := AuthorizationHeader(ctx)
+
+			u, p, ok := BasicAuth(payload)
+			if !ok ||
+				subtle.ConstantTimeCompare(u, []byte(user)) != 1 ||
+				subtle.ConstantTimeCompare(p, []byte(pass)) != 1 {
+				ctx.Response.Header.Set("WWW-Authenticate", `Basic realm="Restricted"`)
+				ctx.SetStatusCode(fasthttp.StatusUnauthorized)
+				JSON(ctx, fasthttp.StatusMessage(fasthttp.StatusUnauthorized))
+
+				return
+			}
+
+			ctx.SetUserValue(UserValuePrincipal, user)
+
+			h(ctx)
+		}
+	}
+}
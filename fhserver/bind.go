@@ -0,0 +1,141 @@
+package fhserver
+
+import (
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// userValueStrictContentType marks requests whose non-JSON bodies are answered with 415 by Bind,
+// see WithStrictContentType.
+const userValueStrictContentType = "fhserver.strictContentType"
+
+// WithStrictContentType makes Bind answer 415 Unsupported Media Type to request bodies without
+// JSON Content-Type, e.g. application/json or application/problem+json, instead of decoding them.
+func WithStrictContentType(enabled bool) Option {
+	return func(s *Server) {
+		s.strictContentType = enabled
+	}
+}
+
+// Bind decodes JSON request body into dst with the JSON engine (see SetJSONEngine) and validates
+// it with v, nil v skips validation. Compressed bodies are decoded after DecompressRequestHandler,
+// streamed ones are read with RequestBodyReader.
+//
+// If the body can't be bound, Bind makes the error response itself and returns false, so the
+// handler just returns: 400 for malformed JSON with decode error details, 422 for validation
+// errors (see UseJSONFieldNames), 415 for non-JSON Content-Type (see WithStrictContentType).
+func Bind(ctx *fasthttp.RequestCtx, v *validator.Validate, dst interface{}) bool {
+	if ctx.UserValue(userValueStrictContentType) != nil && !isJSONContentType(ctx.Request.Header.ContentType()) {
+		ctx.SetStatusCode(http.StatusUnsupportedMediaType)
+		JSON(ctx, fasthttp.StatusMessage(http.StatusUnsupportedMediaType))
+
+		return false
+	}
+
+	body, err := requestBody(ctx)
+	if err != nil {
+		if errors.Is(err, fasthttp.ErrBodyTooLarge) {
+			ctx.SetStatusCode(http.StatusRequestEntityTooLarge)
+			JSON(ctx, fasthttp.StatusMessage(http.StatusRequestEntityTooLarge))
+
+			return false
+		}
+
+		ctx.SetStatusCode(http.StatusBadRequest)
+		JSON(ctx, "cannot read request body")
+
+		return false
+	}
+
+	if err := jsonEngine.Unmarshal(body, dst); err != nil {
+		ctx.SetStatusCode(http.StatusBadRequest)
+		JSON(ctx, pkgErr.NewCoded(pkgErr.CodeMalformedJSON, decodeErrorMessage(err)))
+
+		return false
+	}
+
+	if v == nil {
+		return true
+	}
+
+	err = v.Struct(dst)
+	if err == nil {
+		return true
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		JSON(ctx, validationErrs)
+
+		return false
+	}
+
+	// dst isn't a struct, it's the handler bug
+	if logger := requestLogger(ctx); logger != nil {
+		logger.Error().Err(err).Bytes("path", ctx.Path()).Msg("cannot validate request body")
+	}
+
+	ctx.SetStatusCode(http.StatusInternalServerError)
+	JSON(ctx, pkgErr.ErrServerError)
+
+	return false
+}
+
+// requestBody returns request body, streamed and decompressed bodies are read at once.
+func requestBody(ctx *fasthttp.RequestCtx) ([]byte, error) {
+	if ctx.UserValue(UserValueRequestBodyReader) == nil && !ctx.Request.IsBodyStream() {
+		return ctx.PostBody(), nil
+	}
+
+	return io.ReadAll(RequestBodyReader(ctx))
+}
+
+// decodeErrorMessage returns message of JSON decode error with its offset and field if the
+// engine reports them, jsoniter includes them into the error text.
+func decodeErrorMessage(err error) string {
+	var (
+		syntaxErr *stdjson.SyntaxError
+		typeErr   *stdjson.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("malformed JSON at offset %d: %s", syntaxErr.Offset, syntaxErr.Error())
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return fmt.Sprintf("malformed JSON at offset %d: field %s must be %s, got %s",
+			typeErr.Offset, typeErr.Field, typeErr.Type, typeErr.Value)
+	case errors.As(err, &typeErr):
+		return fmt.Sprintf("malformed JSON at offset %d: value must be %s, got %s", typeErr.Offset, typeErr.Type, typeErr.Value)
+	default:
+		return "malformed JSON: " + err.Error()
+	}
+}
+
+// isJSONContentType reports whether contentType is application/json or other JSON type with
+// +json suffix.
+func isJSONContentType(contentType []byte) bool {
+	mediaType, _, err := mime.ParseMediaType(string(contentType))
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// strictContentTypeMiddleware marks requests for Bind, see WithStrictContentType.
+func strictContentTypeMiddleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.SetUserValue(userValueStrictContentType, true)
+
+		next(ctx)
+	}
+}
@@ -0,0 +1,176 @@
+package fhserver
+
+import (
+	stdjson "encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/router"
+	"github.com/go-playground/validator/v10"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+type bindAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type bindItem struct {
+	ProductID int `json:"product_id" validate:"gt=0"`
+}
+
+type bindOrder struct {
+	Name    string      `json:"name" validate:"required"`
+	Address bindAddress `json:"address"`
+	Items   []bindItem  `json:"items" validate:"required,dive"`
+}
+
+func bindRouter(v *validator.Validate) *router.Router {
+	r := router.New()
+	r.POST("/orders", func(ctx *fasthttp.RequestCtx) {
+		var order bindOrder
+		if !Bind(ctx, v, &order) {
+			return
+		}
+
+		ctx.SetStatusCode(http.StatusCreated)
+		JSON(ctx, order)
+	})
+
+	return r
+}
+
+func TestBind(t *testing.T) {
+	t.Parallel()
+
+	v := validator.New()
+	UseJSONFieldNames(v)
+
+	s := New(cfgstructs.WebServer{}).SetLogger(testLogger(t, nil))
+	s.SetRouter(bindRouter(v))
+
+	type testCase struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    string
+	}
+
+	tcs := []testCase{
+		{
+			name:       "success",
+			body:       `{"name":"order","address":{"city":"Moscow"},"items":[{"product_id":1}]}`,
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"data":{"name":"order","address":{"city":"Moscow"},"items":[{"product_id":1}]}}`,
+		},
+		{
+			name:        "not strict",
+			contentType: "text/plain",
+			body:        `{"name":"order","address":{"city":"Moscow"},"items":[{"product_id":1}]}`,
+			wantStatus:  http.StatusCreated,
+			wantBody:    `{"data":{"name":"order","address":{"city":"Moscow"},"items":[{"product_id":1}]}}`,
+		},
+		{
+			name:       "bad JSON",
+			body:       `{"name":`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"code":"MALFORMED_JSON"`,
+		},
+		{
+			name:       "wrong type",
+			body:       `{"name":"order","items":[{"product_id":"one"}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"message":"malformed JSON: `,
+		},
+		{
+			name:       "empty body",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"code":"MALFORMED_JSON"`,
+		},
+		{
+			name:       "validation",
+			body:       `{"items":[{"product_id":1},{"product_id":0}]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `{"error":{"message":"validation error","code":"VALIDATION_FAILED","validation":{` +
+				"\"address.city\":[\"Свойство `address.city` обязательно для заполнения\"]," +
+				"\"items.1.product_id\":[\"Свойство `items.1.product_id` должно содержать более `0` элементов\"]," +
+				"\"name\":[\"Свойство `name` обязательно для заполнения\"]}}",
+		},
+	}
+
+	for _, tc := range tcs {
+		req := newRequest(fasthttp.MethodPost, "/orders")
+		req.SetBodyString(tc.body)
+
+		if tc.contentType != "" {
+			req.Header.SetContentType(tc.contentType)
+		}
+
+		resp := doRequest(s.httpServer.Handler, req)
+
+		if resp.StatusCode() != tc.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tc.name, resp.StatusCode(), tc.wantStatus)
+		}
+
+		if got := string(resp.Body()); !strings.Contains(got, tc.wantBody) {
+			t.Errorf("%s: body = %s, want %s", tc.name, got, tc.wantBody)
+		}
+	}
+}
+
+func TestWithStrictContentType(t *testing.T) {
+	t.Parallel()
+
+	s := New(cfgstructs.WebServer{}, WithStrictContentType(true)).SetLogger(testLogger(t, nil))
+	s.SetRouter(bindRouter(nil))
+
+	tcs := map[string]int{
+		"":                                  http.StatusUnsupportedMediaType,
+		"text/plain":                        http.StatusUnsupportedMediaType,
+		"application/x-www-form-urlencoded": http.StatusUnsupportedMediaType,
+		"application/json":                  http.StatusCreated,
+		"application/json; charset=utf-8":   http.StatusCreated,
+		"application/merge-patch+json":      http.StatusCreated,
+	}
+
+	for contentType, want := range tcs {
+		req := newRequest(fasthttp.MethodPost, "/orders")
+		req.SetBodyString(`{"name":"order"}`)
+		req.Header.SetContentType(contentType)
+
+		if contentType == "" {
+			req.Header.Del(fasthttp.HeaderContentType)
+		}
+
+		resp := doRequest(s.httpServer.Handler, req)
+
+		if resp.StatusCode() != want {
+			t.Errorf("%q: status = %d, want %d: %s", contentType, resp.StatusCode(), want, resp.Body())
+		}
+	}
+}
+
+func TestDecodeErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	var order bindOrder
+
+	tcs := map[string]string{
+		`{"name":}`:  "malformed JSON at offset 9: invalid character '}' looking for beginning of value",
+		`{"name":1}`: "malformed JSON at offset 9: field name must be string, got number",
+		`[1]`:        "malformed JSON at offset 1: value must be fhserver.bindOrder, got array",
+	}
+
+	for body, want := range tcs {
+		err := stdjson.Unmarshal([]byte(body), &order)
+		if err == nil {
+			t.Fatalf("%s: Unmarshal error = nil", body)
+		}
+
+		if got := decodeErrorMessage(err); got != want {
+			t.Errorf("%s: message = %q, want %q", body, got, want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package fhserver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+)
+
+// WithBodyLimit sets the maximum size of request body as received, i.e. compressed size for compressed
+// requests. Requests over the limit are answered with 413 in the standard JSON error envelope
+// keeping the connection open. Unlike WithMaxRequestBodySize the limit is checked by a middleware.
+func WithBodyLimit(n int) Option {
+	return func(s *Server) {
+		s.bodyLimit = n
+	}
+}
+
+// defaultDecompressionFactor is the ratio of decompressed body limit to the max request body size.
+const defaultDecompressionFactor = 10
+
+// WithDecompressedBodyLimit sets the maximum size of request body after decompression.
+// Requests over the limit are answered with 413. Used only when compression is enabled in config.
+// By default the limit is the max request body size multiplied by decompression factor.
+func WithDecompressedBodyLimit(n int) Option {
+	return func(s *Server) {
+		s.decompressedBodyLimit = n
+	}
+}
+
+// WithDecompressionFactor sets decompressed body limit to the max request body size multiplied by factor,
+// 10 by default. Zero factor turns the limit off. Ignored if WithDecompressedBodyLimit is used.
+func WithDecompressionFactor(factor int) Option {
+	return func(s *Server) {
+		s.decompressionFactor = factor
+	}
+}
+
+// decompressedLimit returns the maximum size of decompressed request body, zero means no limit.
+func (s *Server) decompressedLimit() int {
+	if s.decompressedBodyLimit > 0 {
+		return s.decompressedBodyLimit
+	}
+
+	maxBody := s.httpServer.MaxRequestBodySize
+	if maxBody <= 0 {
+		maxBody = fasthttp.DefaultMaxRequestBodySize
+	}
+
+	return maxBody * s.decompressionFactor
+}
+
+// bodyTooLarge answers 413 with the limit in the message.
+func bodyTooLarge(ctx *fasthttp.RequestCtx, limit int) {
+	ctx.SetStatusCode(http.StatusRequestEntityTooLarge)
+	JSON(ctx, fmt.Sprintf("request body is too large, limit is %d bytes", limit))
+}
+
+// bodyLimitMiddleware rejects requests with body larger than limit. Streamed body size is unknown
+// beforehand, so it's limited on reading via RequestBodyReader.
+func bodyLimitMiddleware(next fasthttp.RequestHandler, limit int) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if ctx.Request.Header.ContentLength() > limit {
+			bodyTooLarge(ctx, limit)
+
+			return
+		}
+
+		if ctx.Request.IsBodyStream() {
+			ctx.SetUserValue(UserValueRequestBodyReader, newLimitedReader(RequestBodyReader(ctx), limit))
+		} else if len(ctx.Request.Body()) > limit {
+			// chunked request
+			bodyTooLarge(ctx, limit)
+
+			return
+		}
+
+		next(ctx)
+	}
+}
+
+// limitedReader returns fasthttp.ErrBodyTooLarge once more than n bytes are read.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func newLimitedReader(r io.Reader, limit int) *limitedReader {
+	return &limitedReader{r: r, n: int64(limit)}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, fasthttp.ErrBodyTooLarge
+	}
+
+	// read one byte over the limit to detect overflow
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+
+	if l.n < 0 {
+		return n + int(l.n), fasthttp.ErrBodyTooLarge
+	}
+
+	return n, err
+}
+
+// decompressBody decompresses in-memory request body reading at most limit bytes, zero limit means
+// no limit. It returns false if decompressed body exceeds the limit.
+func decompressBody(ctx *fasthttp.RequestCtx, limit int) (bool, error) {
+	r, err := decompressReader(&ctx.Request.Header, bytes.NewReader(ctx.Request.Body()))
+	if err != nil {
+		return true, fmt.Errorf("decompressBody error: %w", err)
+	}
+
+	if r == nil {
+		return true, nil
+	}
+
+	defer closeDecoder(r)
+
+	if limit > 0 {
+		r = newLimitedReader(r, limit)
+	}
+
+	b, err := io.ReadAll(r)
+
+	switch {
+	case err == fasthttp.ErrBodyTooLarge: //nolint:errorlint // returned as is by limitedReader
+		return false, nil
+	case err != nil:
+		return true, fmt.Errorf("decompressBody error: %w", err)
+	}
+
+	ctx.Request.SetBody(b)
+	ctx.Request.Header.Del(fasthttp.HeaderContentEncoding)
+	ctx.Request.Header.SetContentLength(len(b))
+
+	return true, nil
+}
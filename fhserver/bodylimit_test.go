@@ -0,0 +1,238 @@
+package fhserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func gzipBytes(t *testing.T, b []byte) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+
+	if _, err := w.Write(b); err != nil {
+		t.Fatalf("gzip write error: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("gzip close error: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestWithBodyLimit(t *testing.T) {
+	t.Parallel()
+
+	r := router.New()
+	r.POST("/echo", func(ctx *fasthttp.RequestCtx) {
+		b, err := io.ReadAll(RequestBodyReader(ctx))
+		if err != nil {
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			JSON(ctx, err.Error())
+
+			return
+		}
+
+		ctx.SetBody(b)
+	})
+
+	bomb := gzipBytes(t, make([]byte, 10000))
+
+	type testCase struct {
+		name    string
+		req     func() *fasthttp.Request
+		want    int
+		wantMsg string
+	}
+
+	tcs := []testCase{
+		{name: "content-length within limit", want: fasthttp.StatusOK, req: func() *fasthttp.Request {
+			req := newRequest("POST", "http://test/echo")
+			req.SetBody(bytes.Repeat([]byte("a"), 16))
+
+			return req
+		}},
+		{name: "content-length over limit", want: fasthttp.StatusRequestEntityTooLarge, wantMsg: "1024", req: func() *fasthttp.Request {
+			req := newRequest("POST", "http://test/echo")
+			req.SetBody(bytes.Repeat([]byte("a"), 2048))
+
+			return req
+		}},
+		{name: "chunked within limit", want: fasthttp.StatusOK, req: func() *fasthttp.Request {
+			req := newRequest("POST", "http://test/echo")
+			req.SetBodyStream(bytes.NewReader(bytes.Repeat([]byte("a"), 16)), -1)
+
+			return req
+		}},
+		{name: "chunked over limit", want: fasthttp.StatusRequestEntityTooLarge, wantMsg: "1024", req: func() *fasthttp.Request {
+			req := newRequest("POST", "http://test/echo")
+			req.SetBodyStream(bytes.NewReader(bytes.Repeat([]byte("a"), 2048)), -1)
+
+			return req
+		}},
+		{name: "decompressed over limit", want: fasthttp.StatusRequestEntityTooLarge, wantMsg: "4096", req: func() *fasthttp.Request {
+			req := newRequest("POST", "http://test/echo")
+			req.Header.Set(fasthttp.HeaderContentEncoding, "gzip")
+			req.SetBody(bomb)
+
+			return req
+		}},
+		{name: "decompressed within limit", want: fasthttp.StatusOK, req: func() *fasthttp.Request {
+			req := newRequest("POST", "http://test/echo")
+			req.Header.Set(fasthttp.HeaderContentEncoding, "gzip")
+			req.SetBody(gzipBytes(t, make([]byte, 100)))
+
+			return req
+		}},
+	}
+
+	s := New(cfgstructs.WebServer{Compress: true}, WithBodyLimit(1024), WithDecompressedBodyLimit(4096)).
+		SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	client := serveInmemory(t, s)
+
+	for _, tc := range tcs {
+		resp := &fasthttp.Response{}
+		if err := client.Do(tc.req(), resp); err != nil {
+			t.Fatalf("%s: request error: %v", tc.name, err)
+		}
+
+		if resp.StatusCode() != tc.want {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.want)
+		}
+
+		if tc.want != fasthttp.StatusRequestEntityTooLarge {
+			continue
+		}
+
+		if resp.ConnectionClose() {
+			t.Errorf("%s: connection must be kept open", tc.name)
+		}
+
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+
+		if err := json.Unmarshal(resp.Body(), &body); err != nil {
+			t.Fatalf("%s: json.Unmarshal error: %v, body: %s", tc.name, err, resp.Body())
+		}
+
+		if !strings.Contains(body.Error.Message, tc.wantMsg) {
+			t.Errorf("%s: error.message = %q, want limit %s", tc.name, body.Error.Message, tc.wantMsg)
+		}
+	}
+}
+
+func TestWithBodyLimit_stream(t *testing.T) {
+	t.Parallel()
+
+	r := router.New()
+	r.POST("/echo", func(ctx *fasthttp.RequestCtx) {
+		if _, err := io.ReadAll(RequestBodyReader(ctx)); err != nil {
+			ctx.SetStatusCode(fasthttp.StatusRequestEntityTooLarge)
+			JSON(ctx, err.Error())
+		}
+	})
+
+	s := New(cfgstructs.WebServer{}, WithBodyLimit(1024), WithStreamRequestBody(true), WithMaxRequestBodySize(16)).
+		SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	client := serveInmemory(t, s)
+
+	req := newRequest("POST", "http://test/echo")
+	req.SetBodyStream(bytes.NewReader(bytes.Repeat([]byte("a"), 4096)), -1)
+
+	resp := &fasthttp.Response{}
+	if err := client.Do(req, resp); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusRequestEntityTooLarge {
+		t.Errorf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestWithDecompressionFactor(t *testing.T) {
+	t.Parallel()
+
+	// 1 MiB of zeros is compressed to about 1 KiB
+	bomb := gzipBytes(t, make([]byte, 1<<20))
+
+	var called int32
+
+	r := router.New()
+	r.POST("/echo", func(ctx *fasthttp.RequestCtx) {
+		atomic.AddInt32(&called, 1)
+		ctx.SetBodyString(strconv.Itoa(len(ctx.PostBody())))
+	})
+
+	post := func(t *testing.T, opts ...Option) *fasthttp.Response {
+		t.Helper()
+
+		s := New(cfgstructs.WebServer{Compress: true}, append([]Option{WithMaxRequestBodySize(4096)}, opts...)...).
+			SetLogger(testLogger(t, nil))
+		s.SetRouter(r)
+
+		req := newRequest("POST", "http://test/echo")
+		req.Header.Set(fasthttp.HeaderContentEncoding, "gzip")
+		req.SetBody(bomb)
+
+		resp := &fasthttp.Response{}
+		if err := serveInmemory(t, s).Do(req, resp); err != nil {
+			t.Fatalf("request error: %v", err)
+		}
+
+		return resp
+	}
+
+	t.Run("default factor", func(t *testing.T) {
+		resp := post(t)
+
+		if resp.StatusCode() != fasthttp.StatusRequestEntityTooLarge {
+			t.Fatalf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusRequestEntityTooLarge)
+		}
+
+		if want := strconv.Itoa(4096 * defaultDecompressionFactor); !strings.Contains(string(resp.Body()), want) {
+			t.Errorf("body = %s, want limit %s", resp.Body(), want)
+		}
+
+		if atomic.LoadInt32(&called) != 0 {
+			t.Error("handler must not be called")
+		}
+	})
+
+	t.Run("custom factor", func(t *testing.T) {
+		resp := post(t, WithDecompressionFactor(512))
+
+		if resp.StatusCode() != fasthttp.StatusOK {
+			t.Fatalf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+		}
+
+		if got := string(resp.Body()); got != strconv.Itoa(1<<20) {
+			t.Errorf("handler got %s bytes, want %d", got, 1<<20)
+		}
+	})
+
+	t.Run("explicit limit", func(t *testing.T) {
+		resp := post(t, WithDecompressionFactor(512), WithDecompressedBodyLimit(1024))
+
+		if resp.StatusCode() != fasthttp.StatusRequestEntityTooLarge {
+			t.Errorf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusRequestEntityTooLarge)
+		}
+	})
+}
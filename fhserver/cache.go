@@ -0,0 +1,256 @@
+package fhserver
+
+import (
+	"bytes"
+	"container/list"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	cacheHeader = "X-Cache"
+
+	defaultCacheMaxEntries = 1024
+	defaultCacheMaxBytes   = 64 << 20
+)
+
+var defaultResponseCache = NewResponseCache(defaultCacheMaxEntries, defaultCacheMaxBytes)
+
+// Cached wraps route handler with in-memory response cache shared by all Cached routes,
+// see ResponseCache.Cached.
+func Cached(ttl time.Duration, h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return defaultResponseCache.Cached(ttl, h)
+}
+
+// ResponseCache is an in-memory LRU cache of 200 GET responses bounded by entries count and body bytes.
+type ResponseCache struct {
+	maxEntries int
+	maxBytes   int
+
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+	// request headers named by Vary of cached responses by their cache key without header values
+	vary map[string]*cacheVary
+}
+
+type cacheEntry struct {
+	key         string
+	base        string
+	contentType []byte
+	vary        []byte
+	body        []byte
+	expiresAt   time.Time
+}
+
+// cacheVary is the list of request headers responses of the same URL vary by.
+type cacheVary struct {
+	headers []string
+	entries int
+}
+
+// NewResponseCache creates response cache holding at most maxEntries responses with
+// maxBytes bodies total size. Zero value means no limit.
+func NewResponseCache(maxEntries, maxBytes int) *ResponseCache {
+	return &ResponseCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		vary:       make(map[string]*cacheVary),
+	}
+}
+
+// Cached serves GET requests from cache for ttl without invoking h and adds X-Cache: HIT|MISS header.
+// Cache key is the host, the path, sorted query string and values of request headers named by Vary
+// response header, e.g. Accept set by Negotiate. Only 200 responses without Set-Cookie,
+// Cache-Control: no-store and Vary: * headers are stored.
+func (c *ResponseCache) Cached(ttl time.Duration, h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if !ctx.IsGet() {
+			h(ctx)
+
+			return
+		}
+
+		base := cacheKey(ctx)
+
+		if e, ok := c.get(base, &ctx.Request.Header, time.Now()); ok {
+			ctx.SetStatusCode(fasthttp.StatusOK)
+			ctx.SetContentTypeBytes(e.contentType)
+			ctx.SetBody(e.body)
+			ctx.Response.Header.Set(cacheHeader, "HIT")
+
+			if len(e.vary) > 0 {
+				ctx.Response.Header.SetBytesV(fasthttp.HeaderVary, e.vary)
+			}
+
+			return
+		}
+
+		h(ctx)
+
+		ctx.Response.Header.Set(cacheHeader, "MISS")
+
+		headers, ok := varyHeaders(ctx.Response.Header.Peek(fasthttp.HeaderVary))
+		if !ok || !cacheable(&ctx.Response) {
+			return
+		}
+
+		c.add(&cacheEntry{
+			key:         variantKey(base, headers, &ctx.Request.Header),
+			base:        base,
+			contentType: append([]byte(nil), ctx.Response.Header.ContentType()...),
+			vary:        append([]byte(nil), ctx.Response.Header.Peek(fasthttp.HeaderVary)...),
+			body:        append([]byte(nil), ctx.Response.Body()...),
+			expiresAt:   time.Now().Add(ttl),
+		}, headers)
+	}
+}
+
+// Len returns number of cached responses.
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+// get returns entry of the request with cache key base and header h.
+func (c *ResponseCache) get(base string, h *fasthttp.RequestHeader, now time.Time) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var headers []string
+	if v, ok := c.vary[base]; ok {
+		headers = v.headers
+	}
+
+	el, ok := c.items[variantKey(base, headers, h)]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*cacheEntry)
+	if !now.Before(e.expiresAt) {
+		c.remove(el)
+
+		return nil, false
+	}
+
+	c.ll.MoveToFront(el)
+
+	return e, true
+}
+
+// add stores e varying by request headers, they replace headers of other responses of the URL.
+func (c *ResponseCache) add(e *cacheEntry, headers []string) {
+	if c.maxBytes > 0 && len(e.body) > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[e.key]; ok {
+		c.remove(el)
+	}
+
+	v, ok := c.vary[e.base]
+	if !ok {
+		v = &cacheVary{}
+		c.vary[e.base] = v
+	}
+
+	v.headers = headers
+	v.entries++
+
+	c.items[e.key] = c.ll.PushFront(e)
+	c.size += len(e.body)
+
+	for (c.maxEntries > 0 && c.ll.Len() > c.maxEntries) || (c.maxBytes > 0 && c.size > c.maxBytes) {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *ResponseCache) remove(el *list.Element) {
+	e := c.ll.Remove(el).(*cacheEntry)
+	delete(c.items, e.key)
+	c.size -= len(e.body)
+
+	if v := c.vary[e.base]; v != nil {
+		if v.entries--; v.entries <= 0 {
+			delete(c.vary, e.base)
+		}
+	}
+}
+
+// cacheKey returns request host and path with sorted query string, so args order doesn't matter.
+func cacheKey(ctx *fasthttp.RequestCtx) string {
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+
+	ctx.QueryArgs().CopyTo(args)
+	args.Sort(bytes.Compare)
+
+	return string(ctx.Host()) + string(ctx.Path()) + "?" + string(args.QueryString())
+}
+
+// variantKey returns cache key base extended with values of request headers.
+func variantKey(base string, headers []string, h *fasthttp.RequestHeader) string {
+	if len(headers) == 0 {
+		return base
+	}
+
+	var b strings.Builder
+
+	b.WriteString(base)
+
+	for _, name := range headers {
+		b.WriteByte(0)
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.Write(h.Peek(name))
+	}
+
+	return b.String()
+}
+
+// varyHeaders returns sorted lower case header names of Vary header value, false for "*".
+func varyHeaders(vary []byte) ([]string, bool) {
+	headers := make([]string, 0)
+
+	for _, v := range bytes.Split(vary, []byte(",")) {
+		name := strings.ToLower(string(bytes.TrimSpace(v)))
+
+		switch name {
+		case "":
+			continue
+		case "*":
+			return nil, false
+		}
+
+		headers = append(headers, name)
+	}
+
+	sort.Strings(headers)
+
+	return headers, true
+}
+
+func cacheable(resp *fasthttp.Response) bool {
+	if resp.StatusCode() != fasthttp.StatusOK || resp.IsBodyStream() {
+		return false
+	}
+
+	if len(resp.Header.Peek(fasthttp.HeaderSetCookie)) > 0 {
+		return false
+	}
+
+	return !bytes.Contains(bytes.ToLower(resp.Header.Peek(fasthttp.HeaderCacheControl)), []byte("no-store"))
+}
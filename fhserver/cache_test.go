@@ -0,0 +1,224 @@
+package fhserver
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestResponseCache_Cached(t *testing.T) {
+	t.Parallel()
+
+	counting := func(calls *int32) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			n := atomic.AddInt32(calls, 1)
+
+			if ctx.QueryArgs().Has("cookie") {
+				c := fasthttp.AcquireCookie()
+				c.SetKey("session")
+				c.SetValue("id")
+				ctx.Response.Header.SetCookie(c)
+				fasthttp.ReleaseCookie(c)
+			}
+
+			if ctx.QueryArgs().Has("no-store") {
+				ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "private, no-store")
+			}
+
+			if ctx.QueryArgs().Has("fail") {
+				ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+			}
+
+			ctx.SetContentType("application/json")
+			ctx.SetBodyString(strconv.Itoa(int(n)))
+		}
+	}
+
+	get := func(t *testing.T, h fasthttp.RequestHandler, uri, wantCache, wantBody string) {
+		t.Helper()
+
+		resp := doRequest(h, newRequest("GET", uri))
+
+		if got := string(resp.Header.Peek(cacheHeader)); got != wantCache {
+			t.Errorf("GET %s: X-Cache = %q, want %q", uri, got, wantCache)
+		}
+
+		if got := string(resp.Body()); got != wantBody {
+			t.Errorf("GET %s: body = %q, want %q", uri, got, wantBody)
+		}
+
+		if got := string(resp.Header.ContentType()); got != "application/json" {
+			t.Errorf("GET %s: content type = %q, want %q", uri, got, "application/json")
+		}
+	}
+
+	t.Run("hit", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int32
+
+		h := NewResponseCache(10, 0).Cached(time.Minute, counting(&calls))
+
+		get(t, h, "http://test/items?a=1&b=2", "MISS", "1")
+		get(t, h, "http://test/items?b=2&a=1", "HIT", "1")
+		get(t, h, "http://test/items?a=2", "MISS", "2")
+
+		if resp := doRequest(h, newRequest("POST", "http://test/items?a=1&b=2")); string(resp.Body()) != "3" {
+			t.Errorf("POST body = %q, want handler invoked", resp.Body())
+		}
+	})
+
+	t.Run("not cacheable", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int32
+
+		c := NewResponseCache(10, 0)
+		h := c.Cached(time.Minute, counting(&calls))
+
+		get(t, h, "http://test/items?cookie", "MISS", "1")
+		get(t, h, "http://test/items?no-store", "MISS", "2")
+		doRequest(h, newRequest("GET", "http://test/items?fail"))
+
+		if c.Len() != 0 {
+			t.Errorf("cached %d responses, want 0", c.Len())
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int32
+
+		h := NewResponseCache(10, 0).Cached(50*time.Millisecond, counting(&calls))
+
+		get(t, h, "http://test/items", "MISS", "1")
+		get(t, h, "http://test/items", "HIT", "1")
+
+		time.Sleep(60 * time.Millisecond)
+
+		get(t, h, "http://test/items", "MISS", "2")
+	})
+
+	t.Run("eviction", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int32
+
+		c := NewResponseCache(2, 0)
+		h := c.Cached(time.Minute, counting(&calls))
+
+		get(t, h, "http://test/a", "MISS", "1")
+		get(t, h, "http://test/b", "MISS", "2")
+		get(t, h, "http://test/a", "HIT", "1")
+		get(t, h, "http://test/c", "MISS", "3") // evicts least recently used /b
+
+		if c.Len() != 2 {
+			t.Errorf("cached %d responses, want 2", c.Len())
+		}
+
+		get(t, h, "http://test/a", "HIT", "1")
+		get(t, h, "http://test/b", "MISS", "4")
+	})
+
+	t.Run("eviction by size", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int32
+
+		c := NewResponseCache(0, 2)
+		h := c.Cached(time.Minute, counting(&calls))
+
+		get(t, h, "http://test/a", "MISS", "1")
+		get(t, h, "http://test/b", "MISS", "2")
+		get(t, h, "http://test/c", "MISS", "3")
+
+		if c.Len() != 2 {
+			t.Errorf("cached %d responses, want 2", c.Len())
+		}
+
+		get(t, h, "http://test/a", "MISS", "4")
+	})
+	t.Run("host", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int32
+
+		h := NewResponseCache(10, 0).Cached(time.Minute, counting(&calls))
+
+		get(t, h, "http://a.test/items", "MISS", "1")
+		get(t, h, "http://b.test/items", "MISS", "2")
+		get(t, h, "http://a.test/items", "HIT", "1")
+	})
+
+	t.Run("vary", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int32
+
+		c := NewResponseCache(10, 0)
+		h := c.Cached(time.Minute, func(ctx *fasthttp.RequestCtx) {
+			n := atomic.AddInt32(&calls, 1)
+
+			if ctx.QueryArgs().Has("any") {
+				ctx.Response.Header.Set(fasthttp.HeaderVary, "*")
+			}
+
+			Negotiate(ctx, n)
+		})
+
+		negotiate := func(uri, accept, wantCache, wantContentType string) {
+			t.Helper()
+
+			req := newRequest("GET", uri)
+			req.Header.Set(fasthttp.HeaderAccept, accept)
+
+			resp := doRequest(h, req)
+
+			if got := string(resp.Header.Peek(cacheHeader)); got != wantCache {
+				t.Errorf("GET %s %s: X-Cache = %q, want %q", uri, accept, got, wantCache)
+			}
+
+			if got := string(resp.Header.ContentType()); got != wantContentType {
+				t.Errorf("GET %s %s: content type = %q, want %q", uri, accept, got, wantContentType)
+			}
+
+			if got := string(resp.Header.Peek(fasthttp.HeaderVary)); got == "" {
+				t.Errorf("GET %s %s: Vary header is lost", uri, accept)
+			}
+		}
+
+		negotiate("http://test/items", "application/json", "MISS", "application/json")
+		negotiate("http://test/items", "application/xml", "MISS", ContentTypeXML)
+		negotiate("http://test/items", "application/json", "HIT", "application/json")
+		negotiate("http://test/items", "application/xml", "HIT", ContentTypeXML)
+		negotiate("http://test/items?any", "application/json", "MISS", "application/json")
+		negotiate("http://test/items?any", "application/json", "MISS", "application/json")
+
+		if c.Len() != 2 {
+			t.Errorf("cached %d responses, want 2", c.Len())
+		}
+	})
+
+	t.Run("vary eviction", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewResponseCache(1, 0)
+		h := c.Cached(time.Minute, func(ctx *fasthttp.RequestCtx) {
+			Negotiate(ctx, string(ctx.Path()))
+		})
+
+		doRequest(h, newRequest("GET", "http://test/a"))
+		doRequest(h, newRequest("GET", "http://test/b"))
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if _, ok := c.vary["test/a?"]; ok || len(c.vary) != 1 {
+			t.Errorf("vary headers of evicted responses are kept: %v", c.vary)
+		}
+	})
+}
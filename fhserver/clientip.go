@@ -0,0 +1,115 @@
+package fhserver
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+
+	"github.com/valyala/fasthttp"
+)
+
+// UserValueClientIP is a user value key holding client IP derived from proxy headers.
+const UserValueClientIP = "fhserver.clientIP"
+
+// SetTrustedProxies sets CIDRs (or single IPs) of reverse proxies. For requests coming from them
+// client IP is taken from X-Forwarded-For (the right-most untrusted hop) or X-Real-IP headers.
+// The headers are ignored for requests from other peers. Must be called before SetRouter.
+func (s *Server) SetTrustedProxies(cidrs ...string) error {
+	nets, err := parseCIDRs(cidrs)
+	if err != nil {
+		return fmt.Errorf("SetTrustedProxies error: %w", err)
+	}
+
+	s.trustedProxies = nets
+
+	return nil
+}
+
+// ClientIP returns IP of the client. It's the remote address of the connection
+// unless the request came through a trusted proxy, see Server.SetTrustedProxies.
+func ClientIP(ctx *fasthttp.RequestCtx) net.IP {
+	if ip, ok := ctx.UserValue(UserValueClientIP).(net.IP); ok {
+		return ip
+	}
+
+	return ctx.RemoteIP()
+}
+
+// parseCIDRs parses CIDRs, IPs without mask are treated as single host networks.
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+
+	for _, cidr := range cidrs {
+		if ip := net.ParseIP(cidr); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clientIPMiddleware derives client IP for requests from trusted proxies.
+func clientIPMiddleware(next fasthttp.RequestHandler, trusted []*net.IPNet) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if ip := forwardedClientIP(ctx, trusted); ip != nil {
+			ctx.SetUserValue(UserValueClientIP, ip)
+		}
+
+		next(ctx)
+	}
+}
+
+// forwardedClientIP returns client IP from proxy headers or nil if the remote peer isn't trusted
+// or the headers are absent.
+func forwardedClientIP(ctx *fasthttp.RequestCtx, trusted []*net.IPNet) net.IP {
+	if !containsIP(trusted, ctx.RemoteIP()) {
+		return nil
+	}
+
+	var leftmost net.IP
+
+	hops := bytes.Split(ctx.Request.Header.Peek("X-Forwarded-For"), []byte(","))
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(string(bytes.TrimSpace(hops[i])))
+		if ip == nil {
+			continue
+		}
+
+		if !containsIP(trusted, ip) {
+			return ip
+		}
+
+		leftmost = ip
+	}
+
+	// every hop is trusted
+	if leftmost != nil {
+		return leftmost
+	}
+
+	return net.ParseIP(string(bytes.TrimSpace(ctx.Request.Header.Peek("X-Real-IP"))))
+}
@@ -0,0 +1,75 @@
+package fhserver
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestServer_SetTrustedProxies(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name   string
+		remote string
+		xff    string
+		realIP string
+		want   string
+	}
+
+	tcs := []testCase{
+		{name: "no proxy headers", remote: "10.0.0.1", want: "10.0.0.1"},
+		{name: "single hop", remote: "10.0.0.1", xff: "203.0.113.7", want: "203.0.113.7"},
+		{name: "chained hops", remote: "10.0.0.1", xff: "198.51.100.1, 203.0.113.7, 10.0.0.2", want: "203.0.113.7"},
+		{name: "spoofed left-most hop", remote: "10.0.0.1", xff: "1.1.1.1, 203.0.113.7", want: "203.0.113.7"},
+		{name: "all hops trusted", remote: "10.0.0.1", xff: "10.0.0.3, 10.0.0.2", want: "10.0.0.3"},
+		{name: "x-real-ip", remote: "10.0.0.1", realIP: "203.0.113.7", want: "203.0.113.7"},
+		{name: "ipv6 proxy", remote: "fd00::1", xff: "2001:db8::7", want: "2001:db8::7"},
+		{name: "untrusted peer", remote: "198.51.100.9", xff: "203.0.113.7", realIP: "203.0.113.8", want: "198.51.100.9"},
+	}
+
+	buf := &syncBuffer{}
+
+	s := New(cfgstructs.WebServer{}).SetLogger(testLogger(t, buf))
+	if err := s.SetTrustedProxies("10.0.0.0/8", "fd00::1"); err != nil {
+		t.Fatalf("SetTrustedProxies error: %v", err)
+	}
+
+	var got net.IP
+
+	r := router.New()
+	r.GET("/ip", func(ctx *fasthttp.RequestCtx) { got = ClientIP(ctx) })
+	s.SetRouter(r)
+
+	for _, tc := range tcs {
+		req := newRequest("GET", "http://test/ip")
+		if tc.xff != "" {
+			req.Header.Set("X-Forwarded-For", tc.xff)
+		}
+
+		if tc.realIP != "" {
+			req.Header.Set("X-Real-IP", tc.realIP)
+		}
+
+		var ctx fasthttp.RequestCtx
+
+		ctx.Init(req, &net.TCPAddr{IP: net.ParseIP(tc.remote), Port: 1234}, nil)
+		s.httpServer.Handler(&ctx)
+
+		if got.String() != tc.want {
+			t.Errorf("%s: ClientIP = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+
+	if !strings.Contains(buf.String(), `"ip": "203.0.113.7"`) {
+		t.Errorf("log must contain client ip, got:\n%s", buf.String())
+	}
+
+	if err := s.SetTrustedProxies("10.0.0.0/33"); err == nil {
+		t.Error("SetTrustedProxies must fail on bad CIDR")
+	}
+}
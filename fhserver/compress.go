@@ -0,0 +1,158 @@
+package fhserver
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// defaultCompressMinSize is the response body size below which compression overhead isn't worth it.
+const defaultCompressMinSize = 1024
+
+// userValueSkipCompression marks requests which responses mustn't be compressed, e.g. upgraded ones.
+const userValueSkipCompression = "fhserver.skipCompression"
+
+// defaultCompressExcludedTypes are already compressed content types. SVG images are compressible.
+var defaultCompressExcludedTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/", "audio/",
+	"application/zip", "application/gzip", "application/x-gzip", "application/pdf",
+}
+
+// compressionConfig is implemented by configs that tune response compression.
+type compressionConfig interface {
+	GetCompressLevel() int
+	GetCompressMinSize() int
+}
+
+// compressOptions configures response compression enabled by config UseCompression.
+type compressOptions struct {
+	// gzip and deflate level, fasthttp.CompressDefaultCompression by default
+	level int
+	// brotli level, fasthttp.CompressBrotliDefaultCompression by default
+	brotliLevel int
+	// responses with smaller bodies aren't compressed
+	minSize int
+	// lower-cased content types which aren't compressed, entries ending with "/" match by prefix
+	excludedTypes []string
+}
+
+func defaultCompressOptions() compressOptions {
+	return compressOptions{
+		level:         fasthttp.CompressDefaultCompression,
+		brotliLevel:   fasthttp.CompressBrotliDefaultCompression,
+		minSize:       defaultCompressMinSize,
+		excludedTypes: defaultCompressExcludedTypes,
+	}
+}
+
+// WithCompressLevel sets gzip and deflate response compression level, e.g. fasthttp.CompressBestSpeed.
+// Overrides GetCompressLevel of the config.
+func WithCompressLevel(level int) Option {
+	return func(s *Server) {
+		s.compress.level = level
+	}
+}
+
+// WithCompressBrotliLevel sets brotli response compression level, e.g. fasthttp.CompressBrotliBestSpeed.
+func WithCompressBrotliLevel(level int) Option {
+	return func(s *Server) {
+		s.compress.brotliLevel = level
+	}
+}
+
+// WithCompressMinSize sets response body size in bytes below which response isn't compressed.
+// fasthttp never compresses bodies shorter than 200 bytes. Overrides GetCompressMinSize of the config.
+func WithCompressMinSize(n int) Option {
+	return func(s *Server) {
+		s.compress.minSize = n
+	}
+}
+
+// WithCompressExcludedTypes replaces the list of already compressed content types which responses
+// aren't compressed, e.g. "application/zip". Type ending with "/" matches by prefix, e.g. "video/".
+// By default images except SVG, video, audio, zip, gzip and pdf are excluded.
+func WithCompressExcludedTypes(types ...string) Option {
+	return func(s *Server) {
+		s.compress.excludedTypes = make([]string, 0, len(types))
+
+		for _, t := range types {
+			s.compress.excludedTypes = append(s.compress.excludedTypes, strings.ToLower(t))
+		}
+	}
+}
+
+// excluded reports whether response with contentType mustn't be compressed.
+func (o compressOptions) excluded(contentType []byte) bool {
+	if i := bytes.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+
+	mediaType := strings.ToLower(string(bytes.TrimSpace(contentType)))
+
+	for _, t := range o.excludedTypes {
+		if mediaType == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compressHandler compresses response body generated by next according to Accept-Encoding header.
+// Brotli is preferred over gzip and deflate when client accepts it.
+func compressHandler(next fasthttp.RequestHandler, opts compressOptions) fasthttp.RequestHandler {
+	// compresses response already written to ctx
+	compress := fasthttp.CompressHandlerBrotliLevel(func(*fasthttp.RequestCtx) {}, opts.brotliLevel, opts.level)
+
+	return func(ctx *fasthttp.RequestCtx) {
+		next(ctx)
+
+		if skip, _ := ctx.UserValue(userValueSkipCompression).(bool); skip {
+			return
+		}
+
+		// byte ranges refer to uncompressed body
+		if ctx.Response.StatusCode() == fasthttp.StatusPartialContent {
+			return
+		}
+
+		if !ctx.Response.IsBodyStream() && len(ctx.Response.Body()) < opts.minSize {
+			return
+		}
+
+		// body encoded by handler and already compressed content types are sent as is
+		if len(ctx.Response.Header.Peek(fasthttp.HeaderContentEncoding)) > 0 ||
+			opts.excluded(ctx.Response.Header.ContentType()) {
+			return
+		}
+
+		compress(ctx)
+		addVary(&ctx.Response.Header, fasthttp.HeaderAcceptEncoding)
+	}
+}
+
+// skipCompression turns response compression off for the request.
+func skipCompression(ctx *fasthttp.RequestCtx) {
+	ctx.SetUserValue(userValueSkipCompression, true)
+}
+
+// addVary appends header name to Vary response header unless it's already there.
+func addVary(h *fasthttp.ResponseHeader, name string) {
+	vary := h.Peek(fasthttp.HeaderVary)
+
+	for _, v := range bytes.Split(vary, []byte(",")) {
+		if bytes.EqualFold(bytes.TrimSpace(v), []byte(name)) {
+			return
+		}
+	}
+
+	if len(vary) == 0 {
+		h.Set(fasthttp.HeaderVary, name)
+
+		return
+	}
+
+	h.Set(fasthttp.HeaderVary, string(vary)+", "+name)
+}
@@ -0,0 +1,245 @@
+package fhserver
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/spacetab-io/configuration-structs-go/v2/contracts"
+	"github.com/valyala/fasthttp"
+)
+
+type compressionTestConfig struct {
+	cfgstructs.WebServer
+	level   int
+	minSize int
+}
+
+func (c compressionTestConfig) GetCompressLevel() int {
+	return c.level
+}
+
+func (c compressionTestConfig) GetCompressMinSize() int {
+	return c.minSize
+}
+
+func TestServer_compression(t *testing.T) {
+	t.Parallel()
+
+	var large strings.Builder
+	for i := 0; large.Len() < 64<<10; i++ {
+		large.WriteString(`{"id":`)
+		large.WriteString(strings.Repeat("7", i%13))
+		large.WriteString(`,"name":"item"},`)
+	}
+
+	r := router.New()
+	r.GET("/small", func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString(strings.Repeat("s", 500)) })
+	r.GET("/large", func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString(large.String()) })
+
+	get := func(t *testing.T, s *Server, uri string) *fasthttp.Response {
+		t.Helper()
+
+		req := newRequest("GET", "http://test"+uri)
+		req.Header.Set(fasthttp.HeaderAcceptEncoding, "gzip")
+
+		return doRequest(s.httpServer.Handler, req)
+	}
+
+	newServer := func(t *testing.T, cfg contracts.WebServerInterface, opts ...Option) *Server {
+		t.Helper()
+
+		s := New(cfg, opts...).SetLogger(testLogger(t, nil))
+		s.SetRouter(r)
+
+		return s
+	}
+
+	t.Run("min size", func(t *testing.T) {
+		t.Parallel()
+
+		s := newServer(t, cfgstructs.WebServer{Compress: true})
+
+		if resp := get(t, s, "/small"); len(resp.Header.Peek(fasthttp.HeaderContentEncoding)) != 0 {
+			t.Errorf("small response Content-Encoding = %q, want none", resp.Header.Peek(fasthttp.HeaderContentEncoding))
+		}
+
+		resp := get(t, s, "/large")
+		if enc := string(resp.Header.Peek(fasthttp.HeaderContentEncoding)); enc != "gzip" {
+			t.Fatalf("large response Content-Encoding = %q, want gzip", enc)
+		}
+
+		body, err := resp.BodyGunzip()
+		if err != nil {
+			t.Fatalf("BodyGunzip error: %v", err)
+		}
+
+		if string(body) != large.String() {
+			t.Error("decompressed body differs from the original")
+		}
+
+		s = newServer(t, cfgstructs.WebServer{Compress: true}, WithCompressMinSize(300))
+
+		if resp := get(t, s, "/small"); string(resp.Header.Peek(fasthttp.HeaderContentEncoding)) != "gzip" {
+			t.Errorf("small response Content-Encoding = %q, want gzip", resp.Header.Peek(fasthttp.HeaderContentEncoding))
+		}
+	})
+
+	t.Run("level", func(t *testing.T) {
+		t.Parallel()
+
+		fast := get(t, newServer(t, compressionTestConfig{
+			WebServer: cfgstructs.WebServer{Compress: true},
+			level:     fasthttp.CompressBestSpeed,
+			minSize:   defaultCompressMinSize,
+		}), "/large")
+		best := get(t, newServer(t, cfgstructs.WebServer{Compress: true}, WithCompressLevel(fasthttp.CompressBestCompression)), "/large")
+		none := get(t, newServer(t, cfgstructs.WebServer{Compress: true}, WithCompressLevel(fasthttp.CompressNoCompression)), "/large")
+
+		if len(best.Body()) >= len(fast.Body()) {
+			t.Errorf("best compression size %d must be less than best speed size %d", len(best.Body()), len(fast.Body()))
+		}
+
+		if len(none.Body()) < large.Len() {
+			t.Errorf("no compression size %d must not be less than body size %d", len(none.Body()), large.Len())
+		}
+
+		for _, resp := range []*fasthttp.Response{fast, best, none} {
+			body, err := resp.BodyGunzip()
+			if err != nil {
+				t.Fatalf("BodyGunzip error: %v", err)
+			}
+
+			if !bytes.Equal(body, []byte(large.String())) {
+				t.Error("decompressed body differs from the original")
+			}
+		}
+	})
+}
+
+func TestServer_compressionNegotiation(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat(`{"id":1,"name":"item"},`, 200)
+
+	r := router.New()
+	r.GET("/data", func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Set(fasthttp.HeaderVary, "Origin")
+		ctx.SetBodyString(body)
+	})
+
+	s := New(cfgstructs.WebServer{Compress: true}, WithCompressBrotliLevel(fasthttp.CompressBrotliBestSpeed)).
+		SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	decoders := map[string]func(resp *fasthttp.Response) ([]byte, error){
+		"br":      (*fasthttp.Response).BodyUnbrotli,
+		"gzip":    (*fasthttp.Response).BodyGunzip,
+		"deflate": (*fasthttp.Response).BodyInflate,
+		"": func(resp *fasthttp.Response) ([]byte, error) {
+			return resp.Body(), nil
+		},
+	}
+
+	tcs := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{acceptEncoding: "br, gzip", want: "br"},
+		{acceptEncoding: "gzip, deflate, br", want: "br"},
+		{acceptEncoding: "gzip, deflate", want: "gzip"},
+		{acceptEncoding: "deflate", want: "deflate"},
+		{acceptEncoding: "", want: ""},
+	}
+
+	for _, tc := range tcs {
+		req := newRequest("GET", "http://test/data")
+		if tc.acceptEncoding != "" {
+			req.Header.Set(fasthttp.HeaderAcceptEncoding, tc.acceptEncoding)
+		}
+
+		resp := doRequest(s.httpServer.Handler, req)
+
+		if got := string(resp.Header.Peek(fasthttp.HeaderContentEncoding)); got != tc.want {
+			t.Errorf("Accept-Encoding %q: Content-Encoding = %q, want %q", tc.acceptEncoding, got, tc.want)
+
+			continue
+		}
+
+		if got := string(resp.Header.Peek(fasthttp.HeaderVary)); got != "Origin, Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q: Vary = %q, want %q", tc.acceptEncoding, got, "Origin, Accept-Encoding")
+		}
+
+		decoded, err := decoders[tc.want](resp)
+		if err != nil {
+			t.Fatalf("Accept-Encoding %q: decode error: %v", tc.acceptEncoding, err)
+		}
+
+		if string(decoded) != body {
+			t.Errorf("Accept-Encoding %q: decoded body differs from the original", tc.acceptEncoding)
+		}
+	}
+}
+
+func TestServer_compressionExcludedTypes(t *testing.T) {
+	t.Parallel()
+
+	// PNG signature followed by compressible filler, so only the content type prevents compression
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 4096)...)
+	doc := strings.Repeat(`{"id":1,"name":"item"},`, 200)
+
+	r := router.New()
+	r.GET("/image.png", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetContentType("image/png")
+		ctx.SetBody(png)
+	})
+	r.GET("/archive.zip", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetContentType("Application/Zip")
+		ctx.SetBody(png)
+	})
+	r.GET("/doc.json", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetContentType("application/json; charset=utf-8")
+		ctx.SetBodyString(doc)
+	})
+
+	get := func(s *Server, uri string) *fasthttp.Response {
+		req := newRequest("GET", "http://test"+uri)
+		req.Header.Set(fasthttp.HeaderAcceptEncoding, "gzip")
+
+		return doRequest(s.httpServer.Handler, req)
+	}
+
+	s := New(cfgstructs.WebServer{Compress: true}).SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	for _, uri := range []string{"/image.png", "/archive.zip"} {
+		resp := get(s, uri)
+
+		if enc := resp.Header.Peek(fasthttp.HeaderContentEncoding); len(enc) != 0 {
+			t.Errorf("%s: Content-Encoding = %q, want none", uri, enc)
+		}
+
+		if !bytes.Equal(resp.Body(), png) {
+			t.Errorf("%s: body is modified", uri)
+		}
+
+		if vary := resp.Header.Peek(fasthttp.HeaderVary); len(vary) != 0 {
+			t.Errorf("%s: Vary = %q, want none", uri, vary)
+		}
+	}
+
+	resp := get(s, "/doc.json")
+	if enc := string(resp.Header.Peek(fasthttp.HeaderContentEncoding)); enc != "gzip" {
+		t.Errorf("/doc.json: Content-Encoding = %q, want gzip", enc)
+	}
+
+	s = New(cfgstructs.WebServer{Compress: true}, WithCompressExcludedTypes("application/")).
+		SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	if enc := get(s, "/doc.json").Header.Peek(fasthttp.HeaderContentEncoding); len(enc) != 0 {
+		t.Errorf("/doc.json excluded by prefix: Content-Encoding = %q, want none", enc)
+	}
+}
@@ -0,0 +1,124 @@
+package fhserver
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spacetab-io/configuration-structs-go/v2/contracts"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// userValueContext holds context returned by Context, it's cancelled when user values are reset.
+	userValueContext = "fhserver.context"
+	// userValueRequestDeadline holds deadline of the request set by WithRequestTimeout.
+	userValueRequestDeadline = "fhserver.requestDeadline"
+
+	// how often the client connection is checked for disconnect
+	disconnectPollInterval = 100 * time.Millisecond
+)
+
+// contextKey is a type of context keys defined by the package.
+type contextKey string
+
+// ContextKeyPrincipal is a context key holding the request principal (UserValuePrincipal).
+const ContextKeyPrincipal contextKey = "fhserver.principal"
+
+// WithRequestTimeout bounds contexts returned by Context with d counted from the request start,
+// so downstream calls are cancelled together. Handler itself isn't interrupted.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.requestTimeout = d
+	}
+}
+
+// Context returns context.Context of the request to be passed to the downstream calls, e.g. fhclient
+// requests. It carries the trace span (see TraceContext), request ID as contracts.ContextKeyRequestID
+// and the principal as ContextKeyPrincipal. Context is cancelled when the server shuts down,
+// the client closes plain TCP or unix connection (not detected on Windows), the request timeout
+// (WithRequestTimeout) expires or the response is sent. Subsequent calls during the request return
+// the same context.
+func Context(ctx *fasthttp.RequestCtx) context.Context {
+	if rc, ok := ctx.UserValue(userValueContext).(*requestContext); ok {
+		return rc
+	}
+
+	c := TraceContext(ctx)
+
+	if id := RequestID(ctx); id != uuid.Nil {
+		c = context.WithValue(c, contracts.ContextKeyRequestID, id)
+	}
+
+	if p := ctx.UserValue(UserValuePrincipal); p != nil {
+		c = context.WithValue(c, ContextKeyPrincipal, p)
+	}
+
+	var cancel context.CancelFunc
+
+	if deadline, ok := ctx.UserValue(userValueRequestDeadline).(time.Time); ok {
+		c, cancel = context.WithDeadline(c, deadline)
+	} else {
+		c, cancel = context.WithCancel(c)
+	}
+
+	rc := &requestContext{Context: c, cancel: cancel}
+
+	go rc.watch(ctx.Done(), ctx.Conn())
+
+	ctx.SetUserValue(userValueContext, rc)
+
+	return rc
+}
+
+// requestContext is cancelled by fasthttp calling Close on user values reset after the request.
+type requestContext struct {
+	context.Context
+	cancel context.CancelFunc
+}
+
+func (c *requestContext) Close() error {
+	c.cancel()
+
+	return nil
+}
+
+// watch cancels the context on server shutdown or client disconnect.
+func (c *requestContext) watch(shutdown <-chan struct{}, conn net.Conn) {
+	var poll <-chan time.Time
+
+	rc := rawConn(conn)
+	if rc != nil {
+		ticker := time.NewTicker(disconnectPollInterval)
+		defer ticker.Stop()
+
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-shutdown:
+			c.cancel()
+
+			return
+		case <-poll:
+			if peerClosed(rc) {
+				c.cancel()
+
+				return
+			}
+		}
+	}
+}
+
+// requestDeadlineMiddleware stores deadline of the request for Context.
+func requestDeadlineMiddleware(next fasthttp.RequestHandler, timeout time.Duration) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.SetUserValue(userValueRequestDeadline, time.Now().Add(timeout))
+
+		next(ctx)
+	}
+}
@@ -0,0 +1,181 @@
+package fhserver
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/spacetab-io/configuration-structs-go/v2/contracts"
+	"github.com/valyala/fasthttp"
+)
+
+func TestContext(t *testing.T) {
+	t.Parallel()
+
+	type result struct {
+		reqID     uuid.UUID
+		ctxID     interface{}
+		principal interface{}
+		same      bool
+		deadline  bool
+		err       error
+		ctx       context.Context
+	}
+
+	results := make(chan result, 1)
+
+	r := router.New()
+	r.GET("/ctx", BasicAuthMiddleware("user", "pass")(func(ctx *fasthttp.RequestCtx) {
+		c := Context(ctx)
+		_, deadline := c.Deadline()
+
+		results <- result{
+			reqID:     RequestID(ctx),
+			ctxID:     c.Value(contracts.ContextKeyRequestID),
+			principal: c.Value(ContextKeyPrincipal),
+			same:      Context(ctx) == c,
+			deadline:  deadline,
+			err:       c.Err(),
+			ctx:       c,
+		}
+	}))
+
+	s := New(cfgstructs.WebServer{}, WithRequestTimeout(time.Minute)).SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	client := serveInmemory(t, s)
+
+	id := uuid.New()
+
+	req := newRequest("GET", "http://test/ctx")
+	req.Header.Set(requestIDKey, id.String())
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("user:pass")))
+
+	resp := &fasthttp.Response{}
+	if err := client.Do(req, resp); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	res := <-results
+
+	// fhclient sends request ID taken by the same key
+	if got, ok := res.ctxID.(uuid.UUID); !ok || got != id || got != res.reqID {
+		t.Errorf("context request ID = %v, want %s", res.ctxID, id)
+	}
+
+	if res.principal != "user" {
+		t.Errorf("context principal = %v, want %q", res.principal, "user")
+	}
+
+	if !res.same {
+		t.Error("Context returned different contexts during the request")
+	}
+
+	if !res.deadline {
+		t.Error("context has no deadline with request timeout")
+	}
+
+	if res.err != nil {
+		t.Errorf("context error during the request = %v, want nil", res.err)
+	}
+
+	select {
+	case <-res.ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("context isn't cancelled after the response")
+	}
+}
+
+func TestContext_requestTimeout(t *testing.T) {
+	t.Parallel()
+
+	errs := make(chan error, 1)
+
+	r := router.New()
+	r.GET("/slow", func(ctx *fasthttp.RequestCtx) {
+		select {
+		case <-Context(ctx).Done():
+			errs <- Context(ctx).Err()
+		case <-time.After(5 * time.Second):
+			errs <- nil
+		}
+	})
+
+	s := New(cfgstructs.WebServer{}, WithRequestTimeout(50*time.Millisecond)).SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	client := serveInmemory(t, s)
+
+	if err := client.Do(newRequest("GET", "http://test/slow"), &fasthttp.Response{}); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if err := <-errs; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("context error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestContext_clientDisconnect(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	errs := make(chan error, 1)
+
+	r := router.New()
+	r.GET("/wait", func(ctx *fasthttp.RequestCtx) {
+		c := Context(ctx)
+		close(started)
+
+		select {
+		case <-c.Done():
+			errs <- c.Err()
+		case <-time.After(5 * time.Second):
+			errs <- nil
+		}
+	})
+
+	cfg := cfgstructs.WebServer{}
+	cfg.Timeouts.Shutdown = time.Second
+
+	s := testServer(t, cfg)
+	s.SetRouter(r)
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen error: %v", err)
+	}
+
+	go func() { _ = s.Serve(ln) }()
+
+	t.Cleanup(func() { _ = s.Stop() })
+
+	<-s.Ready()
+
+	conn, err := net.Dial("tcp4", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial error: %v", err)
+	}
+
+	if _, err := conn.Write([]byte("GET /wait HTTP/1.1\r\nHost: test\r\n\r\n")); err != nil {
+		t.Fatalf("request write error: %v", err)
+	}
+
+	<-started
+
+	_ = conn.Close()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("context error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("handler didn't return")
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+// +build !windows
+
+package fhserver
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// rawConn returns raw connection of plain TCP and unix connections, nil for others (e.g. TLS).
+func rawConn(conn net.Conn) syscall.RawConn {
+	if gc, ok := conn.(*gracefulConn); ok {
+		conn = gc.Conn
+	}
+
+	switch conn.(type) {
+	case *net.TCPConn, *net.UnixConn:
+	default:
+		return nil
+	}
+
+	rc, err := conn.(syscall.Conn).SyscallConn()
+	if err != nil {
+		return nil
+	}
+
+	return rc
+}
+
+// peerClosed peeks the connection without consuming data and reports whether the peer has closed it.
+func peerClosed(rc syscall.RawConn) bool {
+	var closed bool
+
+	err := rc.Control(func(fd uintptr) {
+		n, _, err := syscall.Recvfrom(int(fd), make([]byte, 1), syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+
+		switch {
+		case err == nil:
+			closed = n == 0
+		case errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EINTR):
+		default:
+			closed = true
+		}
+	})
+
+	// connection is already closed by the server
+	return closed || err != nil
+}
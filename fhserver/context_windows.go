@@ -0,0 +1,17 @@
+package fhserver
+
+import (
+	"net"
+	"syscall"
+)
+
+// rawConn returns nil, client disconnect isn't watched on Windows, so request context is only
+// cancelled on server shutdown and deadline.
+func rawConn(net.Conn) syscall.RawConn {
+	return nil
+}
+
+// peerClosed is never called as rawConn returns no connections.
+func peerClosed(syscall.RawConn) bool {
+	return false
+}
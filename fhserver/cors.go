@@ -0,0 +1,135 @@
+package fhserver
+
+import (
+	cors "github.com/AdhityaRamadhanus/fasthttpcors"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	defaultCORSMaxAge = 5600
+
+	// userValueRouteCORS marks requests served by handlers wrapped with WithCORS.
+	userValueRouteCORS = "fhserver.routeCORS"
+)
+
+// CORSOptions describes CORS behaviour of the server.
+type CORSOptions struct {
+	// AllowedOrigins is a list of origins a cross-domain request can be executed from.
+	// Empty list or "*" allows all origins.
+	AllowedOrigins []string
+	// AllowedHeaders is a list of non-simple headers the client is allowed to use.
+	// Empty list or "*" allows all headers.
+	AllowedHeaders []string
+	// AllowedMethods is a list of methods the client is allowed to use.
+	AllowedMethods []string
+	// ExposedHeaders is a list of headers which are safe to expose to the client.
+	ExposedHeaders []string
+	// AllowCredentials indicates whether the request can include user credentials.
+	AllowCredentials bool
+	// AllowMaxAge indicates how long (in seconds) the results of a preflight request can be cached.
+	AllowMaxAge int
+	// Debug turns on fasthttpcors debug output to stdout.
+	Debug bool
+}
+
+// DefaultCORSOptions returns CORS options used when nothing was set via SetCORSOptions.
+func DefaultCORSOptions() CORSOptions {
+	return CORSOptions{
+		AllowedOrigins:   []string{},
+		AllowedHeaders:   []string{},
+		AllowedMethods:   []string{"HEAD", "GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowCredentials: true,
+		AllowMaxAge:      defaultCORSMaxAge,
+		Debug:            true,
+	}
+}
+
+func (o CORSOptions) handler() *cors.CorsHandler {
+	return cors.NewCorsHandler(cors.Options{
+		AllowedOrigins:   o.AllowedOrigins,
+		AllowedHeaders:   o.AllowedHeaders,
+		AllowedMethods:   o.AllowedMethods,
+		ExposedHeaders:   o.ExposedHeaders,
+		AllowCredentials: o.AllowCredentials,
+		AllowMaxAge:      o.AllowMaxAge,
+		Debug:            o.Debug,
+	})
+}
+
+func (o CORSOptions) clone() CORSOptions {
+	o.AllowedOrigins = append([]string(nil), o.AllowedOrigins...)
+	o.AllowedHeaders = append([]string(nil), o.AllowedHeaders...)
+	o.AllowedMethods = append([]string(nil), o.AllowedMethods...)
+	o.ExposedHeaders = append([]string(nil), o.ExposedHeaders...)
+
+	return o
+}
+
+// SetCORSOptions sets CORS options. Must be called before SetRouter,
+// otherwise options are ignored and a warning is logged.
+func (s *Server) SetCORSOptions(opts CORSOptions) *Server {
+	if s.handler.Load() != nil {
+		if s.log != nil {
+			s.log.Warn().Msg("SetCORSOptions called after SetRouter, options are ignored")
+		}
+
+		return s
+	}
+
+	opts = opts.clone()
+	s.corsOptions = &opts
+
+	return s
+}
+
+// WithCORS wraps route handler h with its own CORS options taking precedence over the server ones,
+// e.g. to allow any origin for a public endpoint. Preflight requests are answered by the wrapper
+// without calling h, so register the handler for OPTIONS as well as for the route methods.
+// Works with CORS of the server turned off too.
+func WithCORS(opts CORSOptions, h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	h = opts.clone().handler().CorsMiddleware(h)
+
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.SetUserValue(userValueRouteCORS, true)
+
+		h(ctx)
+	}
+}
+
+// corsMiddleware applies server CORS options to requests not served by WithCORS handlers.
+// Preflight requests are answered at once unless the router has OPTIONS handler for the path.
+func corsMiddleware(next fasthttp.RequestHandler, opts CORSOptions, routerFor func(ctx *fasthttp.RequestCtx) *router.Router) fasthttp.RequestHandler {
+	applyCORS := opts.handler().CorsMiddleware(func(*fasthttp.RequestCtx) {})
+
+	return func(ctx *fasthttp.RequestCtx) {
+		if ctx.IsOptions() && !hasOPTIONSHandler(ctx, routerFor) {
+			applyCORS(ctx)
+
+			return
+		}
+
+		next(ctx)
+
+		if ctx.UserValue(userValueRouteCORS) != nil {
+			return
+		}
+
+		// CORS headers of the server are added to the response of the route
+		code := ctx.Response.StatusCode()
+		applyCORS(ctx)
+		ctx.SetStatusCode(code)
+	}
+}
+
+// hasOPTIONSHandler reports whether the router serving the request has OPTIONS handler for its path.
+func hasOPTIONSHandler(ctx *fasthttp.RequestCtx, routerFor func(ctx *fasthttp.RequestCtx) *router.Router) bool {
+	r := routerFor(ctx)
+	if r == nil {
+		return false
+	}
+
+	h, _ := r.Lookup(fasthttp.MethodOptions, string(ctx.Path()), nil)
+
+	return h != nil
+}
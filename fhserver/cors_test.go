@@ -0,0 +1,174 @@
+package fhserver
+
+import (
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestServer_SetCORSOptions(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name       string
+		opts       *CORSOptions
+		origin     string
+		wantOrigin string
+		wantMaxAge string
+	}
+
+	restricted := &CORSOptions{
+		AllowedOrigins: []string{"https://a.example"},
+		AllowedMethods: []string{"GET"},
+		AllowMaxAge:    60,
+	}
+
+	tcs := []testCase{
+		{name: "allowed origin", opts: restricted, origin: "https://a.example", wantOrigin: "https://a.example", wantMaxAge: "60"},
+		{name: "denied origin", opts: restricted, origin: "https://b.example"},
+		{name: "default options", origin: "https://b.example", wantOrigin: "https://b.example", wantMaxAge: "5600"},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := testServer(t, cfgstructs.WebServer{CORS: cfgstructs.CORSCfg{Enabled: true}})
+			if tc.opts != nil {
+				s.SetCORSOptions(*tc.opts)
+			}
+
+			s.SetRouter(testRouter())
+
+			req := newRequest("OPTIONS", "/ping")
+			req.Header.Set("Origin", tc.origin)
+			req.Header.Set("Access-Control-Request-Method", "GET")
+
+			resp := doRequest(s.httpServer.Handler, req)
+
+			if got := string(resp.Header.Peek("Access-Control-Allow-Origin")); got != tc.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tc.wantOrigin)
+			}
+
+			if got := string(resp.Header.Peek("Access-Control-Max-Age")); got != tc.wantMaxAge {
+				t.Errorf("Access-Control-Max-Age = %q, want %q", got, tc.wantMaxAge)
+			}
+		})
+	}
+}
+
+func TestServer_SetCORSOptions_copiesSlices(t *testing.T) {
+	t.Parallel()
+
+	origins := []string{"https://a.example"}
+
+	s := New(cfgstructs.WebServer{}).SetCORSOptions(CORSOptions{AllowedOrigins: origins})
+	origins[0] = "https://b.example"
+
+	if got := s.corsOptions.AllowedOrigins[0]; got != "https://a.example" {
+		t.Errorf("AllowedOrigins[0] = %q, want %q", got, "https://a.example")
+	}
+}
+
+func TestServer_SetCORSOptions_afterSetRouter(t *testing.T) {
+	t.Parallel()
+
+	s := testServer(t, cfgstructs.WebServer{CORS: cfgstructs.CORSCfg{Enabled: true}})
+	s.SetRouter(testRouter())
+	s.SetCORSOptions(CORSOptions{AllowedOrigins: []string{"https://a.example"}})
+
+	if s.corsOptions != nil {
+		t.Error("options set after SetRouter must be ignored")
+	}
+}
+
+func TestWithCORS(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name       string
+		method     string
+		path       string
+		origin     string
+		wantCode   int
+		wantOrigin string
+		wantBody   string
+	}
+
+	widget := WithCORS(CORSOptions{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}}, func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBodyString("widget")
+	})
+
+	r := router.New()
+	r.GET("/api", func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString("api") })
+	r.GET("/widget", widget)
+	r.OPTIONS("/widget", widget)
+
+	tcs := []testCase{
+		{name: "overridden preflight", method: "OPTIONS", path: "/widget", origin: "https://b.example", wantCode: 200, wantOrigin: "https://b.example"},
+		{name: "default preflight", method: "OPTIONS", path: "/api", origin: "https://b.example", wantCode: 200},
+		{name: "default preflight of allowed origin", method: "OPTIONS", path: "/api", origin: "https://a.example", wantCode: 200, wantOrigin: "https://a.example"},
+		{name: "overridden request", method: "GET", path: "/widget", origin: "https://b.example", wantCode: 200, wantOrigin: "https://b.example", wantBody: "widget"},
+		{name: "default request", method: "GET", path: "/api", origin: "https://b.example", wantCode: 200, wantBody: "api"},
+		{name: "default request of allowed origin", method: "GET", path: "/api", origin: "https://a.example", wantCode: 200, wantOrigin: "https://a.example", wantBody: "api"},
+	}
+
+	s := testServer(t, cfgstructs.WebServer{CORS: cfgstructs.CORSCfg{Enabled: true}}).
+		SetCORSOptions(CORSOptions{AllowedOrigins: []string{"https://a.example"}, AllowedMethods: []string{"GET"}})
+	s.SetRouter(r)
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := newRequest(tc.method, tc.path)
+			req.Header.Set("Origin", tc.origin)
+
+			if tc.method == "OPTIONS" {
+				req.Header.Set("Access-Control-Request-Method", "GET")
+			}
+
+			resp := doRequest(s.httpServer.Handler, req)
+
+			if resp.StatusCode() != tc.wantCode {
+				t.Errorf("status code = %d, want %d", resp.StatusCode(), tc.wantCode)
+			}
+
+			if got := string(resp.Header.Peek("Access-Control-Allow-Origin")); got != tc.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tc.wantOrigin)
+			}
+
+			if got := string(resp.Body()); got != tc.wantBody {
+				t.Errorf("body = %q, want %q", got, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestWithCORS_serverCORSDisabled(t *testing.T) {
+	t.Parallel()
+
+	widget := WithCORS(CORSOptions{}, func(ctx *fasthttp.RequestCtx) {})
+
+	r := router.New()
+	r.GET("/widget", widget)
+	r.GET("/api", func(ctx *fasthttp.RequestCtx) {})
+
+	s := testServer(t, cfgstructs.WebServer{})
+	s.SetRouter(r)
+
+	for path, want := range map[string]string{"/widget": "https://b.example", "/api": ""} {
+		req := newRequest("GET", path)
+		req.Header.Set("Origin", "https://b.example")
+
+		resp := doRequest(s.httpServer.Handler, req)
+
+		if got := string(resp.Header.Peek("Access-Control-Allow-Origin")); got != want {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package fhserver
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// UserValueCSRFToken is a user value key holding CSRF token of the request.
+	UserValueCSRFToken = "fhserver.csrfToken"
+
+	defaultCSRFCookieName = "csrf_token"
+	defaultCSRFHeaderName = "X-CSRF-Token"
+	defaultCSRFTTL        = 12 * time.Hour
+
+	csrfNonceLen = 16
+)
+
+// CSRFConfig configures double-submit cookie CSRF protection.
+type CSRFConfig struct {
+	// Secret is the key tokens are signed with. Required.
+	Secret []byte
+	// TTL is token lifetime, 12 hours if zero.
+	TTL time.Duration
+	// CookieName is "csrf_token" if empty.
+	CookieName string
+	// HeaderName is "X-CSRF-Token" if empty.
+	HeaderName string
+	// Secure sets Secure attribute of the cookie.
+	Secure bool
+}
+
+// CSRFToken returns CSRF token to be embedded into pages and sent back in the CSRF header.
+func CSRFToken(ctx *fasthttp.RequestCtx) string {
+	token, _ := ctx.UserValue(UserValueCSRFToken).(string)
+
+	return token
+}
+
+// CSRF returns middleware issuing HMAC signed CSRF token cookie and checking the token
+// is sent back in the header for unsafe methods. Mismatch is answered with 403.
+func CSRF(cfg CSRFConfig) Middleware {
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultCSRFTTL
+	}
+
+	if cfg.CookieName == "" {
+		cfg.CookieName = defaultCSRFCookieName
+	}
+
+	if cfg.HeaderName == "" {
+		cfg.HeaderName = defaultCSRFHeaderName
+	}
+
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			cookie := ctx.Request.Header.Cookie(cfg.CookieName)
+			valid := cfg.valid(cookie, time.Now())
+
+			token := string(cookie)
+			if !valid {
+				token = cfg.issue(ctx)
+			}
+
+			ctx.SetUserValue(UserValueCSRFToken, token)
+
+			if isSafeMethod(ctx) {
+				next(ctx)
+
+				return
+			}
+
+			header := ctx.Request.Header.Peek(cfg.HeaderName)
+			if !valid || subtle.ConstantTimeCompare(header, cookie) != 1 {
+				ctx.SetStatusCode(http.StatusForbidden)
+				JSON(ctx, "invalid csrf token")
+
+				return
+			}
+
+			next(ctx)
+		}
+	}
+}
+
+func isSafeMethod(ctx *fasthttp.RequestCtx) bool {
+	return ctx.IsGet() || ctx.IsHead() || ctx.IsOptions() || ctx.IsTrace()
+}
+
+// issue generates a new token and sets it as cookie.
+func (cfg CSRFConfig) issue(ctx *fasthttp.RequestCtx) string {
+	payload := make([]byte, csrfNonceLen+8)
+	_, _ = rand.Read(payload[:csrfNonceLen])
+	binary.BigEndian.PutUint64(payload[csrfNonceLen:], uint64(time.Now().Add(cfg.TTL).Unix()))
+
+	token := base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(cfg.sign(payload))
+
+	c := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(c)
+
+	c.SetKey(cfg.CookieName)
+	c.SetValue(token)
+	c.SetPath("/")
+	c.SetMaxAge(int(cfg.TTL.Seconds()))
+	c.SetSecure(cfg.Secure)
+	c.SetSameSite(fasthttp.CookieSameSiteStrictMode)
+	ctx.Response.Header.SetCookie(c)
+
+	return token
+}
+
+// valid checks token signature and expiration.
+func (cfg CSRFConfig) valid(token []byte, now time.Time) bool {
+	dot := bytes.IndexByte(token, '.')
+	if dot < 0 {
+		return false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(string(token[:dot]))
+	if err != nil || len(payload) != csrfNonceLen+8 {
+		return false
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(string(token[dot+1:]))
+	if err != nil || !hmac.Equal(sig, cfg.sign(payload)) {
+		return false
+	}
+
+	return now.Unix() < int64(binary.BigEndian.Uint64(payload[csrfNonceLen:]))
+}
+
+func (cfg CSRFConfig) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, cfg.Secret)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
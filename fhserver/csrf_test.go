@@ -0,0 +1,96 @@
+package fhserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestCSRF(t *testing.T) {
+	t.Parallel()
+
+	issue := func(t *testing.T, h fasthttp.RequestHandler) string {
+		t.Helper()
+
+		resp := doRequest(h, newRequest("GET", "http://test/form"))
+
+		c := fasthttp.AcquireCookie()
+		defer fasthttp.ReleaseCookie(c)
+
+		c.SetKey("csrf_token")
+
+		if !resp.Header.Cookie(c) {
+			t.Fatal("csrf cookie isn't issued")
+		}
+
+		if got := string(resp.Body()); got != string(c.Value()) {
+			t.Fatalf("CSRFToken = %q, want cookie value %q", got, c.Value())
+		}
+
+		return string(c.Value())
+	}
+
+	post := func(h fasthttp.RequestHandler, cookie, header string) int {
+		req := newRequest("POST", "http://test/form")
+		if cookie != "" {
+			req.Header.SetCookie("csrf_token", cookie)
+		}
+
+		if header != "" {
+			req.Header.Set("X-CSRF-Token", header)
+		}
+
+		return doRequest(h, req).StatusCode()
+	}
+
+	handler := func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString(CSRFToken(ctx)) }
+
+	t.Run("happy path", func(t *testing.T) {
+		t.Parallel()
+
+		h := CSRF(CSRFConfig{Secret: []byte("secret")})(handler)
+		token := issue(t, h)
+
+		if code := post(h, token, token); code != fasthttp.StatusOK {
+			t.Errorf("status code = %d, want %d", code, fasthttp.StatusOK)
+		}
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		t.Parallel()
+
+		h := CSRF(CSRFConfig{Secret: []byte("secret")})(handler)
+		token := issue(t, h)
+
+		if code := post(h, token, ""); code != fasthttp.StatusForbidden {
+			t.Errorf("no header: status code = %d, want %d", code, fasthttp.StatusForbidden)
+		}
+
+		if code := post(h, "", token); code != fasthttp.StatusForbidden {
+			t.Errorf("no cookie: status code = %d, want %d", code, fasthttp.StatusForbidden)
+		}
+	})
+
+	t.Run("stale token", func(t *testing.T) {
+		t.Parallel()
+
+		h := CSRF(CSRFConfig{Secret: []byte("secret"), TTL: time.Nanosecond})(handler)
+		token := issue(t, h)
+
+		if code := post(h, token, token); code != fasthttp.StatusForbidden {
+			t.Errorf("status code = %d, want %d", code, fasthttp.StatusForbidden)
+		}
+	})
+
+	t.Run("foreign secret", func(t *testing.T) {
+		t.Parallel()
+
+		token := issue(t, CSRF(CSRFConfig{Secret: []byte("other")})(handler))
+		h := CSRF(CSRFConfig{Secret: []byte("secret")})(handler)
+
+		if code := post(h, token, token); code != fasthttp.StatusForbidden {
+			t.Errorf("status code = %d, want %d", code, fasthttp.StatusForbidden)
+		}
+	})
+}
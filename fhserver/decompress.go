@@ -0,0 +1,184 @@
+package fhserver
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	log "github.com/spacetab-io/logs-go/v3"
+	"github.com/valyala/fasthttp"
+)
+
+// DecompressRequestHandler decompresses request body according to Content-Encoding header.
+// Streamed request body isn't loaded into memory, it is decompressed on reading via RequestBodyReader.
+// Corrupt bodies are answered with 400 and unsupported encodings with 415, handler isn't called.
+// After decompression Content-Encoding header is removed and Content-Length of in-memory body is updated.
+func DecompressRequestHandler(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return decompressRequestHandler(h, 0, nil)
+}
+
+// decompressRequestHandler is DecompressRequestHandler answering 413 if decompressed body
+// is larger than limit. Zero limit means no limit. Decode errors are logged if logger isn't nil.
+func decompressRequestHandler(h fasthttp.RequestHandler, limit int, logger *log.Logger) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		// plain body size is limited by WithBodyLimit
+		if len(ctx.Request.Header.Peek(fasthttp.HeaderContentEncoding)) == 0 {
+			h(ctx)
+
+			return
+		}
+
+		if !supportedContentEncoding(&ctx.Request.Header) {
+			ctx.SetStatusCode(http.StatusUnsupportedMediaType)
+			JSON(ctx, "unsupported content encoding")
+
+			return
+		}
+
+		var (
+			ok  = true
+			r   io.Reader
+			err error
+		)
+
+		// decompressed size is checked on reading, so compression bombs aren't loaded into memory
+		if ctx.Request.IsBodyStream() {
+			r, err = decompressBodyStream(ctx, limit)
+			// streamed body is read by the handler, so pooled decoder is released after it
+			defer closeDecoder(r)
+		} else {
+			ok, err = decompressBody(ctx, limit)
+		}
+
+		switch {
+		case err != nil:
+			if logger != nil {
+				logger.Warn().Err(err).Bytes("encoding", ctx.Request.Header.Peek(fasthttp.HeaderContentEncoding)).
+					Msg("cannot decompress request body")
+			}
+
+			ctx.SetStatusCode(http.StatusBadRequest)
+			JSON(ctx, "cannot decompress request body")
+		case !ok:
+			bodyTooLarge(ctx, limit)
+		default:
+			h(ctx)
+		}
+	}
+}
+
+// contentEncodingAliases maps legacy coding names to the RFC 7230 ones.
+var contentEncodingAliases = map[string]string{
+	"x-gzip":     "gzip",
+	"x-compress": "compress",
+}
+
+// supportedContentEncodings are codings which can be decompressed.
+var supportedContentEncodings = map[string]struct{}{
+	"gzip":     {},
+	"deflate":  {},
+	"br":       {},
+	"zstd":     {},
+	"identity": {},
+}
+
+// contentEncodings returns lower-cased Content-Encoding codings in the order they were applied.
+// Parameters and empty list elements are dropped, aliases are resolved.
+func contentEncodings(h *fasthttp.RequestHeader) []string {
+	value := h.Peek(fasthttp.HeaderContentEncoding)
+	if len(value) == 0 {
+		return nil
+	}
+
+	parts := bytes.Split(value, []byte(","))
+	encodings := make([]string, 0, len(parts))
+
+	for _, part := range parts {
+		if i := bytes.IndexByte(part, ';'); i >= 0 {
+			part = part[:i]
+		}
+
+		encoding := strings.ToLower(string(bytes.TrimSpace(part)))
+		if encoding == "" {
+			continue
+		}
+
+		if alias, ok := contentEncodingAliases[encoding]; ok {
+			encoding = alias
+		}
+
+		encodings = append(encodings, encoding)
+	}
+
+	return encodings
+}
+
+// supportedContentEncoding reports whether request body encoding can be decompressed.
+func supportedContentEncoding(h *fasthttp.RequestHeader) bool {
+	for _, e := range contentEncodings(h) {
+		if _, ok := supportedContentEncodings[e]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// zstdDecoderPool holds synchronous zstd decoders, so zstd contexts aren't allocated per request.
+var zstdDecoderPool sync.Pool
+
+// zstdReader decompresses zstd stream with pooled decoder.
+type zstdReader struct {
+	d *zstd.Decoder
+}
+
+func newZstdReader(r io.Reader) (*zstdReader, error) {
+	d, ok := zstdDecoderPool.Get().(*zstd.Decoder)
+	if !ok {
+		var err error
+
+		// single goroutine decoder doesn't start background workers
+		if d, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := d.Reset(r); err != nil {
+		zstdDecoderPool.Put(d)
+
+		return nil, err
+	}
+
+	return &zstdReader{d: d}, nil
+}
+
+func (z *zstdReader) Read(p []byte) (int, error) {
+	if z.d == nil {
+		return 0, zstd.ErrDecoderClosed
+	}
+
+	return z.d.Read(p)
+}
+
+// Close returns decoder to the pool.
+func (z *zstdReader) Close() error {
+	if z.d == nil {
+		return nil
+	}
+
+	_ = z.d.Reset(nil)
+	zstdDecoderPool.Put(z.d)
+	z.d = nil
+
+	return nil
+}
+
+// closeDecoder releases resources of decompressing reader r returned by decompressReader.
+func closeDecoder(r io.Reader) {
+	if c, ok := r.(io.Closer); ok {
+		_ = c.Close()
+	}
+}
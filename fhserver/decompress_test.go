@@ -0,0 +1,319 @@
+package fhserver
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/router"
+	"github.com/klauspost/compress/zstd"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestDecompressRequestHandler(t *testing.T) {
+	t.Parallel()
+
+	payload := []byte(`{"name":"item","tags":["a","b","c"]}`)
+
+	var called int
+
+	r := router.New()
+	r.POST("/echo", func(ctx *fasthttp.RequestCtx) {
+		called++
+
+		ctx.Response.Header.SetBytesV("X-Content-Encoding", ctx.Request.Header.Peek(fasthttp.HeaderContentEncoding))
+		ctx.Response.Header.Set("X-Content-Length", strconv.Itoa(ctx.Request.Header.ContentLength()))
+		ctx.SetBody(ctx.PostBody())
+	})
+
+	buf := &syncBuffer{}
+
+	s := New(cfgstructs.WebServer{Compress: true}).SetLogger(testLogger(t, buf))
+	s.SetRouter(r)
+
+	post := func(encoding string, body []byte) *fasthttp.Response {
+		req := newRequest("POST", "http://test/echo")
+		req.Header.Set(fasthttp.HeaderContentEncoding, encoding)
+		req.SetBody(body)
+
+		return doRequest(s.httpServer.Handler, req)
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		tcs := map[string][]byte{
+			"gzip":     gzipBytes(t, payload),
+			"deflate":  fasthttp.AppendDeflateBytes(nil, payload),
+			"br":       fasthttp.AppendBrotliBytes(nil, payload),
+			"identity": payload,
+		}
+
+		for encoding, body := range tcs {
+			resp := post(encoding, body)
+
+			if resp.StatusCode() != fasthttp.StatusOK {
+				t.Errorf("%s: status code = %d, want %d", encoding, resp.StatusCode(), fasthttp.StatusOK)
+
+				continue
+			}
+
+			if !bytes.Equal(resp.Body(), payload) {
+				t.Errorf("%s: handler got body %q, want %q", encoding, resp.Body(), payload)
+			}
+
+			if encoding == "identity" {
+				continue
+			}
+
+			if got := resp.Header.Peek("X-Content-Encoding"); len(got) != 0 {
+				t.Errorf("%s: handler got Content-Encoding %q, want none", encoding, got)
+			}
+
+			if got := string(resp.Header.Peek("X-Content-Length")); got != strconv.Itoa(len(payload)) {
+				t.Errorf("%s: handler got Content-Length %s, want %d", encoding, got, len(payload))
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		corrupt := gzipBytes(t, payload)
+		corrupt[len(corrupt)/2] ^= 0xff
+
+		tcs := []struct {
+			name     string
+			encoding string
+			body     []byte
+			want     int
+		}{
+			{name: "corrupt gzip", encoding: "gzip", body: corrupt, want: fasthttp.StatusBadRequest},
+			{name: "not gzip", encoding: "gzip", body: payload, want: fasthttp.StatusBadRequest},
+			{name: "corrupt br", encoding: "br", body: payload, want: fasthttp.StatusBadRequest},
+			{name: "unknown encoding", encoding: "compress", body: payload, want: fasthttp.StatusUnsupportedMediaType},
+		}
+
+		for _, tc := range tcs {
+			called = 0
+
+			resp := post(tc.encoding, tc.body)
+
+			if resp.StatusCode() != tc.want {
+				t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.want)
+			}
+
+			if called != 0 {
+				t.Errorf("%s: handler must not be called", tc.name)
+			}
+
+			var body struct {
+				Error struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+
+			if err := json.Unmarshal(resp.Body(), &body); err != nil || body.Error.Message == "" {
+				t.Errorf("%s: response isn't JSON error envelope: %s", tc.name, resp.Body())
+			}
+		}
+
+		if !strings.Contains(buf.String(), "cannot decompress request body") {
+			t.Errorf("decode error isn't logged, got:\n%s", buf.String())
+		}
+	})
+}
+
+func TestDecompressRequestHandler_zstd(t *testing.T) {
+	t.Parallel()
+
+	payload := []byte(strings.Repeat(`{"name":"item","tags":["a","b","c"]},`, 100))
+
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd.NewWriter error: %v", err)
+	}
+
+	compressed := enc.EncodeAll(payload, nil)
+
+	r := router.New()
+	r.POST("/echo", func(ctx *fasthttp.RequestCtx) {
+		b, err := io.ReadAll(RequestBodyReader(ctx))
+		if err != nil {
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			JSON(ctx, err.Error())
+
+			return
+		}
+
+		ctx.SetBody(b)
+	})
+
+	for _, stream := range []bool{false, true} {
+		s := New(cfgstructs.WebServer{Compress: true}, WithStreamRequestBody(stream), WithMaxRequestBodySize(512)).
+			SetLogger(testLogger(t, nil))
+		s.SetRouter(r)
+
+		client := serveInmemory(t, s)
+
+		// decoders are reused between requests
+		for i := 0; i < 3; i++ {
+			req := newRequest("POST", "http://test/echo")
+			req.Header.Set(fasthttp.HeaderContentEncoding, "zstd")
+			req.SetBody(compressed)
+
+			resp := &fasthttp.Response{}
+			if err := client.Do(req, resp); err != nil {
+				t.Fatalf("stream %v: request error: %v", stream, err)
+			}
+
+			if resp.StatusCode() != fasthttp.StatusOK {
+				t.Fatalf("stream %v: status code = %d, want %d, body: %s", stream, resp.StatusCode(), fasthttp.StatusOK, resp.Body())
+			}
+
+			if !bytes.Equal(resp.Body(), payload) {
+				t.Errorf("stream %v: handler got body %q, want %q", stream, resp.Body(), payload)
+			}
+		}
+
+		req := newRequest("POST", "http://test/echo")
+		req.Header.Set(fasthttp.HeaderContentEncoding, "zstd")
+		req.SetBody(payload[:400])
+
+		resp := &fasthttp.Response{}
+		if err := client.Do(req, resp); err != nil {
+			t.Fatalf("stream %v: request error: %v", stream, err)
+		}
+
+		if resp.StatusCode() != fasthttp.StatusBadRequest {
+			t.Errorf("stream %v: corrupt body status code = %d, want %d", stream, resp.StatusCode(), fasthttp.StatusBadRequest)
+		}
+	}
+}
+
+func TestContentEncodings(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		header    string
+		want      []string
+		supported bool
+	}{
+		{header: "", want: nil, supported: true},
+		{header: "gzip", want: []string{"gzip"}, supported: true},
+		{header: "GZIP", want: []string{"gzip"}, supported: true},
+		{header: "gzip ; q=1.0", want: []string{"gzip"}, supported: true},
+		{header: "x-gzip", want: []string{"gzip"}, supported: true},
+		{header: "  Br  ", want: []string{"br"}, supported: true},
+		{header: "gzip,br", want: []string{"gzip", "br"}, supported: true},
+		{header: "identity, ,Deflate", want: []string{"identity", "deflate"}, supported: true},
+		{header: "gzip, x-compress", want: []string{"gzip", "compress"}, supported: false},
+		{header: "xgzip", want: []string{"xgzip"}, supported: false},
+	}
+
+	for _, tc := range tcs {
+		var h fasthttp.RequestHeader
+		h.Set(fasthttp.HeaderContentEncoding, tc.header)
+
+		got := contentEncodings(&h)
+
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") || len(got) != len(tc.want) {
+			t.Errorf("%q: contentEncodings = %q, want %q", tc.header, got, tc.want)
+		}
+
+		if supported := supportedContentEncoding(&h); supported != tc.supported {
+			t.Errorf("%q: supportedContentEncoding = %v, want %v", tc.header, supported, tc.supported)
+		}
+	}
+}
+
+func TestDecompressRequestHandler_encodingSpellings(t *testing.T) {
+	t.Parallel()
+
+	payload := []byte(`{"name":"item"}`)
+
+	h := DecompressRequestHandler(func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBody(ctx.PostBody())
+	})
+
+	for _, encoding := range []string{"GZIP", "Gzip ; q=1.0", "x-gzip", " gzip"} {
+		req := newRequest("POST", "http://test/echo")
+		req.Header.Set(fasthttp.HeaderContentEncoding, encoding)
+		req.SetBody(gzipBytes(t, payload))
+
+		resp := doRequest(h, req)
+
+		if resp.StatusCode() != fasthttp.StatusOK {
+			t.Errorf("%q: status code = %d, want %d", encoding, resp.StatusCode(), fasthttp.StatusOK)
+		}
+
+		if !bytes.Equal(resp.Body(), payload) {
+			t.Errorf("%q: handler got body %q, want %q", encoding, resp.Body(), payload)
+		}
+	}
+}
+
+func TestDecompressRequestHandler_chained(t *testing.T) {
+	t.Parallel()
+
+	payload := []byte(strings.Repeat(`{"name":"item"},`, 50))
+
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd.NewWriter error: %v", err)
+	}
+
+	gzipBr := fasthttp.AppendBrotliBytes(nil, gzipBytes(t, payload))
+	deflateZstd := enc.EncodeAll(fasthttp.AppendDeflateBytes(nil, payload), nil)
+
+	var called int
+
+	h := DecompressRequestHandler(func(ctx *fasthttp.RequestCtx) {
+		called++
+
+		ctx.Response.Header.SetBytesV("X-Content-Encoding", ctx.Request.Header.Peek(fasthttp.HeaderContentEncoding))
+		ctx.SetBody(ctx.PostBody())
+	})
+
+	tcs := []struct {
+		encoding string
+		body     []byte
+		want     int
+	}{
+		{encoding: "gzip, br", body: gzipBr, want: fasthttp.StatusOK},
+		{encoding: "deflate, identity, zstd", body: deflateZstd, want: fasthttp.StatusOK},
+		{encoding: "br, gzip", body: gzipBr, want: fasthttp.StatusBadRequest},
+		{encoding: "gzip, gzip", body: gzipBytes(t, payload), want: fasthttp.StatusBadRequest},
+	}
+
+	for _, tc := range tcs {
+		called = 0
+
+		req := newRequest("POST", "http://test/echo")
+		req.Header.Set(fasthttp.HeaderContentEncoding, tc.encoding)
+		req.SetBody(tc.body)
+
+		resp := doRequest(h, req)
+
+		if resp.StatusCode() != tc.want {
+			t.Errorf("%q: status code = %d, want %d", tc.encoding, resp.StatusCode(), tc.want)
+
+			continue
+		}
+
+		if tc.want != fasthttp.StatusOK {
+			if called != 0 {
+				t.Errorf("%q: handler must not be called", tc.encoding)
+			}
+
+			continue
+		}
+
+		if !bytes.Equal(resp.Body(), payload) {
+			t.Errorf("%q: handler got body %q, want %q", tc.encoding, resp.Body(), payload)
+		}
+
+		if got := resp.Header.Peek("X-Content-Encoding"); len(got) != 0 {
+			t.Errorf("%q: handler got Content-Encoding %q, want none", tc.encoding, got)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package fhserver
+
+import (
+	stdjson "encoding/json"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// JSONEngine encodes and decodes JSON of responses and requests. jsoniter.API and sonic.API
+// implement it, StandardJSON is encoding/json.
+type JSONEngine interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// StandardJSON is JSONEngine of encoding/json.
+var StandardJSON JSONEngine = standardJSON{}
+
+// jsonEngine encodes responses, jsoniter compatible with encoding/json by default.
+var jsonEngine JSONEngine = json
+
+// SetJSONEngine replaces JSON engine used by JSON and other response helpers and by handlers
+// decoding request bodies, e.g. jsoniter.Config{SortMapKeys: true}.Froze() or StandardJSON.
+// Nil e restores the default jsoniter.ConfigCompatibleWithStandardLibrary.
+//
+// Responses are encoded with jsoniter.API engines in place, other engines make a copy and
+// responses aren't streamed, see WithJSONStreamThreshold. Error bodies of failed encoding
+// are written without the engine so that they can't fail.
+//
+// SetJSONEngine must be called before serving starts, it isn't safe to call concurrently
+// with request handling.
+func SetJSONEngine(e JSONEngine) {
+	if e == nil {
+		e = json
+	}
+
+	jsonEngine = e
+}
+
+// borrowJSONStream returns pooled stream of the engine, false if the engine isn't jsoniter.
+// The stream is returned to the pool with stream.Pool().ReturnStream.
+func borrowJSONStream() (*jsoniter.Stream, bool) {
+	api, ok := jsonEngine.(jsoniter.API)
+	if !ok {
+		return nil, false
+	}
+
+	return api.BorrowStream(nil), true
+}
+
+type standardJSON struct{}
+
+func (standardJSON) Marshal(v interface{}) ([]byte, error) {
+	return stdjson.Marshal(v)
+}
+
+func (standardJSON) Unmarshal(data []byte, v interface{}) error {
+	return stdjson.Unmarshal(data, v)
+}
@@ -0,0 +1,147 @@
+package fhserver
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	jsoniter "github.com/json-iterator/go"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// countingEngine counts Marshal calls of the wrapped engine.
+type countingEngine struct {
+	JSONEngine
+	calls int32
+}
+
+func (e *countingEngine) Marshal(v interface{}) ([]byte, error) {
+	atomic.AddInt32(&e.calls, 1)
+
+	return e.JSONEngine.Marshal(v)
+}
+
+// TestSetJSONEngine runs response helpers with different engines, it isn't parallel
+// as the engine is global.
+func TestSetJSONEngine(t *testing.T) {
+	t.Cleanup(func() { SetJSONEngine(nil) })
+
+	type item struct {
+		Name string `validate:"required"`
+	}
+
+	type testCase struct {
+		name     string
+		respond  func(ctx *fasthttp.RequestCtx)
+		wantCode int
+		// prefix of the body for engine specific messages
+		wantBody string
+	}
+
+	tcs := []testCase{
+		{
+			name:     "string",
+			respond:  func(ctx *fasthttp.RequestCtx) { JSON(ctx, "<ok>") },
+			wantCode: fasthttp.StatusOK,
+			wantBody: `{"data":"\u003cok\u003e"}`,
+		},
+		{
+			name:     "map",
+			respond:  func(ctx *fasthttp.RequestCtx) { JSON(ctx, map[string]interface{}{"b": 1, "a": []int{1, 2}}) },
+			wantCode: fasthttp.StatusOK,
+			wantBody: `{"data":{"a":[1,2],"b":1}}`,
+		},
+		{
+			name:     "error",
+			respond:  func(ctx *fasthttp.RequestCtx) { JSON(ctx, pkgErr.WithCode(pkgErr.ErrConflict, "ORDER_EXISTS")) },
+			wantCode: fasthttp.StatusConflict,
+			wantBody: `{"error":{"message":"conflict","code":"ORDER_EXISTS"}}`,
+		},
+		{
+			name:     "validation",
+			respond:  func(ctx *fasthttp.RequestCtx) { JSON(ctx, validator.New().Struct(item{})) },
+			wantCode: fasthttp.StatusUnprocessableEntity,
+			wantBody: `{"error":{"message":"validation error","code":"VALIDATION_FAILED",` +
+				`"validation":{"Name":["Свойство ` + "`Name`" + ` обязательно для заполнения"]}}}`,
+		},
+		{
+			name:     "marshal error",
+			respond:  func(ctx *fasthttp.RequestCtx) { JSON(ctx, map[string]interface{}{"ch": make(chan int)}) },
+			wantCode: fasthttp.StatusInternalServerError,
+			wantBody: `{"error":{"message":"`,
+		},
+		{
+			name: "streamed list",
+			respond: func(ctx *fasthttp.RequestCtx) {
+				ctx.SetUserValue(userValueJSONStreamThreshold, 1)
+				JSON(ctx, []int{1, 2, 3})
+			},
+			wantCode: fasthttp.StatusOK,
+			wantBody: `{"data":[1,2,3]}`,
+		},
+		{
+			name:     "page",
+			respond:  func(ctx *fasthttp.RequestCtx) { JSONPage(ctx, []int{1}, PageMeta{Total: 1}) },
+			wantCode: fasthttp.StatusOK,
+			wantBody: `{"data":[1],"meta":{"total":1}}`,
+		},
+		{
+			name:     "raw",
+			respond:  func(ctx *fasthttp.RequestCtx) { RawJSON(ctx, map[string]bool{"ok": true}) },
+			wantCode: fasthttp.StatusOK,
+			wantBody: `{"ok":true}`,
+		},
+		{
+			name:     "problem",
+			respond:  func(ctx *fasthttp.RequestCtx) { Problem(ctx, pkgErr.ErrNotFound) },
+			wantCode: fasthttp.StatusNotFound,
+			wantBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"route not found","instance":"/"}`,
+		},
+	}
+
+	engines := []struct {
+		name   string
+		engine JSONEngine
+	}{
+		{name: "default"},
+		{name: "encoding/json", engine: StandardJSON},
+		{name: "jsoniter sorted", engine: jsoniter.Config{EscapeHTML: true, SortMapKeys: true}.Froze()},
+	}
+
+	for _, e := range engines {
+		counting := &countingEngine{JSONEngine: e.engine}
+		if e.engine == nil {
+			counting.JSONEngine = json
+		}
+
+		SetJSONEngine(counting)
+
+		for _, tc := range tcs {
+			ctx := &fasthttp.RequestCtx{}
+
+			tc.respond(ctx)
+
+			if ctx.Response.StatusCode() != tc.wantCode {
+				t.Errorf("%s, %s: status code = %d, want %d", e.name, tc.name, ctx.Response.StatusCode(), tc.wantCode)
+			}
+
+			got := string(ctx.Response.Body())
+			if got != tc.wantBody && !(tc.wantCode == fasthttp.StatusInternalServerError && strings.HasPrefix(got, tc.wantBody)) {
+				t.Errorf("%s, %s: body = %s, want %s", e.name, tc.name, got, tc.wantBody)
+			}
+		}
+
+		// wrapper isn't jsoniter.API, so every response is marshaled by it
+		if calls := atomic.LoadInt32(&counting.calls); calls < int32(len(tcs)) {
+			t.Errorf("%s: engine is called %d times, want at least %d", e.name, calls, len(tcs))
+		}
+	}
+
+	SetJSONEngine(nil)
+
+	if jsonEngine != JSONEngine(json) {
+		t.Error("SetJSONEngine(nil) doesn't restore the default engine")
+	}
+}
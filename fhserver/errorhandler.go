@@ -0,0 +1,43 @@
+package fhserver
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+)
+
+// SetErrorHandler sets handler for errors occurred while reading requests
+// (too big header or body, read timeout, malformed request).
+func (s *Server) SetErrorHandler(h func(ctx *fasthttp.RequestCtx, err error)) *Server {
+	s.httpServer.ErrorHandler = h
+
+	return s
+}
+
+// errorHandler answers requests rejected by fasthttp with the standard JSON error envelope.
+func (s *Server) errorHandler(ctx *fasthttp.RequestCtx, err error) {
+	var (
+		smallBuffer *fasthttp.ErrSmallBuffer
+		netErr      *net.OpError
+	)
+
+	code, msg := http.StatusBadRequest, "error when parsing request"
+
+	switch {
+	case errors.As(err, &smallBuffer):
+		code, msg = http.StatusRequestHeaderFieldsTooLarge, "too big request header"
+	case errors.Is(err, fasthttp.ErrBodyTooLarge):
+		code, msg = http.StatusRequestEntityTooLarge, "too big request body"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		code, msg = http.StatusRequestTimeout, "request timeout"
+	}
+
+	if s.log != nil {
+		s.log.Warn().Err(err).Int("status", code).Bytes("ip", ctx.RemoteIP()).Msg("request rejected")
+	}
+
+	ctx.SetStatusCode(code)
+	JSON(ctx, msg)
+}
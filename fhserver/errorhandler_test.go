@@ -0,0 +1,68 @@
+package fhserver
+
+import (
+	"strings"
+	"testing"
+
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestServer_errorHandler(t *testing.T) {
+	t.Parallel()
+
+	s := New(cfgstructs.WebServer{}, WithReadBufferSize(1024)).SetLogger(testLogger(t, nil))
+	s.SetRouter(testRouter())
+
+	client := serveInmemory(t, s)
+
+	req := newRequest("GET", "http://test/ping")
+	req.Header.Set("X-Big", strings.Repeat("a", 2048))
+
+	resp := &fasthttp.Response{}
+	if err := client.Do(req, resp); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusRequestHeaderFieldsTooLarge)
+	}
+
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(resp.Body(), &body); err != nil {
+		t.Fatalf("json.Unmarshal error: %v, body: %s", err, resp.Body())
+	}
+
+	if body.Error.Message != "too big request header" {
+		t.Errorf("error.message = %q, want %q", body.Error.Message, "too big request header")
+	}
+}
+
+func TestServer_SetErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	s := New(cfgstructs.WebServer{}, WithReadBufferSize(1024)).SetLogger(testLogger(t, nil)).
+		SetErrorHandler(func(ctx *fasthttp.RequestCtx, err error) {
+			ctx.SetStatusCode(fasthttp.StatusTeapot)
+		})
+	s.SetRouter(testRouter())
+
+	client := serveInmemory(t, s)
+
+	req := newRequest("GET", "http://test/ping")
+	req.Header.Set("X-Big", strings.Repeat("a", 2048))
+
+	resp := &fasthttp.Response{}
+	if err := client.Do(req, resp); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusTeapot {
+		t.Errorf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusTeapot)
+	}
+}
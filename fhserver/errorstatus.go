@@ -0,0 +1,77 @@
+package fhserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	pkgErr "github.com/spacetab-io/http-go/errors"
+)
+
+// errorStatus is a response status and message of the registered error.
+type errorStatus struct {
+	err       error
+	status    int
+	publicMsg string
+}
+
+// errorStatuses holds mappings registered with RegisterErrorStatus in registration order.
+var errorStatuses struct {
+	sync.RWMutex
+	list []errorStatus
+}
+
+// RegisterErrorStatus makes JSON answer errors matching err (errors.Is) with status and publicMsg
+// instead of the error message (if publicMsg isn't empty), e.g. for sentinel errors of domain
+// packages which can't implement errors.HTTPStatuser. Registered mappings take precedence over
+// the built-in ones. Registration of the same error again overwrites its mapping and reports it
+// with overwritten. Status out of 100..599 range isn't registered, ErrInvalidStatus is returned.
+func RegisterErrorStatus(err error, status int, publicMsg string) (overwritten bool, e error) {
+	if err == nil {
+		return false, nil
+	}
+
+	if status < http.StatusContinue || status > maxStatusCode {
+		return false, fmt.Errorf("%w %d of error %q", pkgErr.ErrInvalidStatus, status, err.Error())
+	}
+
+	errorStatuses.Lock()
+	defer errorStatuses.Unlock()
+
+	mapping := errorStatus{err: err, status: status, publicMsg: publicMsg}
+
+	for i, es := range errorStatuses.list {
+		// errors.Is doesn't panic on uncomparable errors unlike ==
+		if errors.Is(es.err, err) && errors.Is(err, es.err) {
+			errorStatuses.list[i] = mapping
+
+			return true, nil
+		}
+	}
+
+	errorStatuses.list = append(errorStatuses.list, mapping)
+
+	return false, nil
+}
+
+// registeredErrorStatus returns status and message registered for err.
+func registeredErrorStatus(err error) (status int, msg string, ok bool) {
+	errorStatuses.RLock()
+	defer errorStatuses.RUnlock()
+
+	for _, es := range errorStatuses.list {
+		if !errors.Is(err, es.err) {
+			continue
+		}
+
+		msg = es.publicMsg
+		if msg == "" {
+			msg = err.Error()
+		}
+
+		return es.status, msg, true
+	}
+
+	return 0, "", false
+}
@@ -0,0 +1,69 @@
+package fhserver
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRegisterErrorStatus(t *testing.T) {
+	errQuotaExceeded := errors.New("quota exceeded: tenant 42 used 1000 of 1000 requests")
+	errPaymentRequired := errors.New("payment required")
+
+	t.Cleanup(func() {
+		errorStatuses.Lock()
+		errorStatuses.list = nil
+		errorStatuses.Unlock()
+	})
+
+	register := func(err error, status int, publicMsg string, wantOverwritten bool) {
+		t.Helper()
+
+		overwritten, e := RegisterErrorStatus(err, status, publicMsg)
+		if e != nil || overwritten != wantOverwritten {
+			t.Errorf("RegisterErrorStatus(%v, %d) = %v, %v, want %v, nil", err, status, overwritten, e, wantOverwritten)
+		}
+	}
+
+	register(errQuotaExceeded, fasthttp.StatusBadRequest, "", false)
+	register(errQuotaExceeded, fasthttp.StatusTooManyRequests, "quota exceeded", true)
+	register(errPaymentRequired, fasthttp.StatusPaymentRequired, "", false)
+	// registered mappings take precedence over the built-in ones
+	register(pkgErr.ErrConflict, fasthttp.StatusPreconditionFailed, "", false)
+
+	// invalid statuses aren't registered
+	for _, status := range []int{0, 99, 600} {
+		if _, err := RegisterErrorStatus(pkgErr.ErrRecordNotFound, status, ""); !errors.Is(err, pkgErr.ErrInvalidStatus) {
+			t.Errorf("RegisterErrorStatus status %d error = %v, want %v", status, err, pkgErr.ErrInvalidStatus)
+		}
+	}
+
+	type testCase struct {
+		name     string
+		err      error
+		wantCode int
+		wantBody string
+	}
+
+	tcs := []testCase{
+		{name: "public message", err: errQuotaExceeded, wantCode: 429, wantBody: `{"error":{"message":"quota exceeded"}}`},
+		{name: "wrapped", err: fmt.Errorf("charge: %w", errPaymentRequired), wantCode: 402, wantBody: `{"error":{"message":"charge: payment required"}}`},
+		{name: "built-in overridden", err: pkgErr.ErrConflict, wantCode: 412, wantBody: `{"error":{"message":"conflict"}}`},
+		{name: "not registered", err: pkgErr.ErrRecordNotFound, wantCode: 404, wantBody: `{"error":{"message":"record not found"}}`},
+	}
+
+	for _, tc := range tcs {
+		resp := doRequest(func(ctx *fasthttp.RequestCtx) { JSON(ctx, tc.err) }, newRequest("GET", "/"))
+
+		if resp.StatusCode() != tc.wantCode {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.wantCode)
+		}
+
+		if got := string(resp.Body()); got != tc.wantBody {
+			t.Errorf("%s: body = %s, want %s", tc.name, got, tc.wantBody)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package fhserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/valyala/fasthttp"
+)
+
+// WithETag turns on ETag middleware. It's placed before response compression,
+// so the tag is computed over uncompressed body and doesn't depend on Accept-Encoding.
+func WithETag() Option {
+	return func(s *Server) {
+		s.etag = true
+	}
+}
+
+// ETag sets strong ETag header on 200 GET responses and answers 304 without body
+// if request If-None-Match header matches it. ETag set by handler is kept as is.
+// Used with Use it sees compressed body, so prefer WithETag when compression is enabled.
+func ETag() Middleware {
+	return etagMiddleware
+}
+
+func etagMiddleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		next(ctx)
+
+		if !ctx.IsGet() || ctx.Response.StatusCode() != fasthttp.StatusOK || ctx.Response.IsBodyStream() {
+			return
+		}
+
+		etag := ctx.Response.Header.Peek(fasthttp.HeaderETag)
+		if len(etag) == 0 {
+			sum := sha256.Sum256(ctx.Response.Body())
+			etag = []byte(`"` + hex.EncodeToString(sum[:16]) + `"`)
+			ctx.Response.Header.SetBytesV(fasthttp.HeaderETag, etag)
+		}
+
+		if etagMatch(ctx.Request.Header.Peek(fasthttp.HeaderIfNoneMatch), etag) {
+			// caching headers (ETag, Cache-Control, Expires, Vary) are kept
+			ctx.Response.ResetBody()
+			ctx.Response.SetStatusCode(fasthttp.StatusNotModified)
+		}
+	}
+}
+
+// etagMatch reports whether If-None-Match header value matches etag using weak comparison.
+func etagMatch(ifNoneMatch, etag []byte) bool {
+	if len(ifNoneMatch) == 0 {
+		return false
+	}
+
+	etag = bytes.TrimPrefix(etag, []byte("W/"))
+
+	for _, tag := range bytes.Split(ifNoneMatch, []byte(",")) {
+		tag = bytes.TrimSpace(tag)
+
+		if bytes.Equal(tag, []byte("*")) || bytes.Equal(bytes.TrimPrefix(tag, []byte("W/")), etag) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,108 @@
+package fhserver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestWithETag(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("rarely changing data ", 100)
+
+	r := router.New()
+	r.GET("/data", func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "max-age=60")
+		ctx.SetBodyString(body)
+	})
+	r.POST("/data", func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString(body) })
+
+	s := New(cfgstructs.WebServer{Compress: true}, WithETag()).SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	h := s.httpServer.Handler
+
+	plain := doRequest(h, newRequest("GET", "http://test/data"))
+	etag := string(plain.Header.Peek(fasthttp.HeaderETag))
+
+	if etag == "" {
+		t.Fatal("ETag header isn't set")
+	}
+
+	req := newRequest("GET", "http://test/data")
+	req.Header.Set(fasthttp.HeaderAcceptEncoding, "gzip")
+
+	compressed := doRequest(h, req)
+	if string(compressed.Header.Peek(fasthttp.HeaderContentEncoding)) != "gzip" {
+		t.Fatal("response isn't compressed")
+	}
+
+	if got := string(compressed.Header.Peek(fasthttp.HeaderETag)); got != etag {
+		t.Errorf("compressed response ETag = %q, want %q", got, etag)
+	}
+
+	t.Run("match", func(t *testing.T) {
+		t.Parallel()
+
+		for _, inm := range []string{etag, `"other", W/` + etag, "*"} {
+			req := newRequest("GET", "http://test/data")
+			req.Header.Set(fasthttp.HeaderIfNoneMatch, inm)
+
+			resp := doRequest(h, req)
+
+			if resp.StatusCode() != fasthttp.StatusNotModified {
+				t.Errorf("If-None-Match %s: status code = %d, want %d", inm, resp.StatusCode(), fasthttp.StatusNotModified)
+			}
+
+			if len(resp.Body()) != 0 {
+				t.Errorf("If-None-Match %s: body = %q, want empty", inm, resp.Body())
+			}
+
+			if got := string(resp.Header.Peek(fasthttp.HeaderETag)); got != etag {
+				t.Errorf("If-None-Match %s: ETag = %q, want %q", inm, got, etag)
+			}
+
+			if got := string(resp.Header.Peek(fasthttp.HeaderCacheControl)); got != "max-age=60" {
+				t.Errorf("If-None-Match %s: Cache-Control = %q, want %q", inm, got, "max-age=60")
+			}
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		req := newRequest("GET", "http://test/data")
+		req.Header.Set(fasthttp.HeaderIfNoneMatch, `"stale"`)
+
+		resp := doRequest(h, req)
+
+		if resp.StatusCode() != fasthttp.StatusOK {
+			t.Errorf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+		}
+
+		if string(resp.Body()) != body {
+			t.Error("full body must be sent")
+		}
+	})
+
+	t.Run("non-GET", func(t *testing.T) {
+		t.Parallel()
+
+		req := newRequest("POST", "http://test/data")
+		req.Header.Set(fasthttp.HeaderIfNoneMatch, etag)
+
+		resp := doRequest(h, req)
+
+		if resp.StatusCode() != fasthttp.StatusOK {
+			t.Errorf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+		}
+
+		if got := resp.Header.Peek(fasthttp.HeaderETag); len(got) != 0 {
+			t.Errorf("ETag = %q, want none", got)
+		}
+	})
+}
@@ -1,19 +1,23 @@
 package fhserver
 
 import (
-	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
-	"syscall"
+	"sync/atomic"
+	"time"
 
-	cors "github.com/AdhityaRamadhanus/fasthttpcors"
 	"github.com/fasthttp/router"
 	"github.com/spacetab-io/configuration-structs-go/v2/contracts"
 	"github.com/spacetab-io/http-go/errors"
 	log "github.com/spacetab-io/logs-go/v3"
 	"github.com/valyala/fasthttp"
-	"github.com/valyala/fasthttp/reuseport"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
 var (
@@ -22,15 +26,110 @@ var (
 )
 
 type Server struct {
-	log        *log.Logger
-	config     contracts.WebServerInterface
-	router     *router.Router
-	httpServer fasthttp.Server
+	log            *log.Logger
+	config         contracts.WebServerInterface
+	corsOptions    *CORSOptions
+	tlsConfig      *tls.Config
+	network        string
+	noReusePort    bool
+	unixSocketMode os.FileMode
+	listenerFile   *os.File
+	httpServer     fasthttp.Server
+
+	// holds fasthttp.RequestHandler composed by SetRouter
+	handler atomic.Value
+
+	// becomes non-zero when graceful shutdown starts
+	shuttingDown uint32
+	// becomes non-zero when pre-shutdown delay starts, see WithPreShutdownDelay
+	draining         uint32
+	preShutdownDelay time.Duration
+	// connections are closed after response when older, see WithMaxConnAge
+	maxConnAge time.Duration
+	// requests being handled, counted when maxInFlight > 0
+	inFlight    int32
+	maxInFlight int
+	// holds *maintenance
+	maintenance atomic.Value
+
+	// prefork mode, see EnablePrefork
+	prefork         bool
+	preforkChildren int
+
+	// zero-downtime restart, see EnableUpgrade
+	upgrade bool
+	// notifies the process which started this one with the upgrade
+	upgradeReady *os.File
+
+	// response compression, see WithCompressLevel
+	compress compressOptions
+
+	// request body size limits, see WithBodyLimit
+	bodyLimit             int
+	decompressedBodyLimit int
+	decompressionFactor   int
+
+	// requests tracer, see WithTracerProvider
+	tracer trace.Tracer
+	// bounds request contexts, see WithRequestTimeout
+	requestTimeout time.Duration
+
+	accessLog       accessLogOptions
+	panicHook       panicHook
+	trustedProxies  []*net.IPNet
+	securityHeaders *SecurityHeaders
+	etag            bool
+
+	// user middlewares, see Use
+	middlewares []Middleware
+
+	// routers selected by Host header, see SetHostRouter
+	hostRouters       map[string]*router.Router
+	unknownHostStatus int
+
+	// request ID in successful responses, see WithResponseRequestID
+	responseRequestID bool
+	// Negotiate answers 406 to unsupported types, see WithNegotiationRequired
+	negotiationRequired bool
+	// JSON lists bigger than this are streamed, see WithJSONStreamThreshold
+	jsonStreamThreshold int
+	// successful responses always have data field, see WithAlwaysData
+	alwaysData bool
+	// Bind answers 415 to non-JSON bodies, see WithStrictContentType
+	strictContentType bool
+	// pre-encoded JSON is validated, see WithDebug
+	debug bool
+
+	// X-Service-Version header value
+	version string
+
+	// additional listen addresses
+	extraAddrs    []string
+	shutdownHooks []shutdownHook
+	// cancelled when graceful shutdown starts, see ShutdownContext
+	shutdownCtx    context.Context
+	shutdownCancel context.CancelFunc
+	// background jobs, see Go
+	jobsMu      sync.Mutex
+	jobsPending int
+	jobsIdle    chan struct{}
+	// custom signal handlers
+	signalHandlers map[os.Signal]func()
+
+	mu        sync.Mutex
+	listeners []*gracefulListener
+	hostname  string
+	stopOnce  sync.Once
+	stopErr   error
+
+	// closed when listener is bound and server starts serving
+	ready     chan struct{}
+	readyOnce sync.Once
 }
 
 // New creates a new WebServer Server.
-func New(config contracts.WebServerInterface) *Server {
-	return &Server{
+func New(config contracts.WebServerInterface, opts ...Option) *Server {
+	s := &Server{
 		log: nil,
 		httpServer: fasthttp.Server{
 			Name:               "Service",
@@ -40,98 +139,235 @@ func New(config contracts.WebServerInterface) *Server {
 			MaxConnsPerIP:      config.GetMaxConnsPerIP(),
 			MaxRequestsPerConn: config.GetMaxRequestsPerConn(),
 		},
-		config: config,
+		config:              config,
+		compress:            defaultCompressOptions(),
+		decompressionFactor: defaultDecompressionFactor,
+		ready:               make(chan struct{}),
 	}
-}
 
-// HasAcceptEncodingBytes returns true if the header contains
-// the given Accept-Encoding value.
-func hasContentEncodingBytes(h *fasthttp.RequestHeader, encoding []byte) bool {
-	ae := h.Peek(fasthttp.HeaderContentEncoding)
-	n := bytes.Index(ae, encoding)
+	s.shutdownCtx, s.shutdownCancel = context.WithCancel(context.Background())
+
+	s.httpServer.Handler = s.keepAliveMiddleware(s.handle)
+	s.httpServer.ErrorHandler = s.errorHandler
+
+	if c, ok := config.(maxInFlightConfig); ok {
+		s.maxInFlight = c.GetMaxInFlight()
+	}
 
-	if n < 0 {
-		return false
+	if c, ok := config.(preShutdownDelayConfig); ok {
+		s.preShutdownDelay = c.GetPreShutdownDelay()
 	}
 
-	b := ae[n+len(encoding):]
+	if c, ok := config.(responseRequestIDConfig); ok {
+		s.responseRequestID = c.GetResponseRequestID()
+	}
 
-	if len(b) > 0 && b[0] != ',' {
-		return false
+	if c, ok := config.(compressionConfig); ok {
+		s.compress.level = c.GetCompressLevel()
+		s.compress.minSize = c.GetCompressMinSize()
 	}
 
-	if n == 0 {
-		return true
+	for _, opt := range opts {
+		opt(s)
 	}
 
-	return ae[n-1] == ' '
+	return s
 }
 
-func DecompressRequestHandler(h fasthttp.RequestHandler) fasthttp.RequestHandler {
-	return func(ctx *fasthttp.RequestCtx) {
-		b := ctx.Request.Body()
-		if hasContentEncodingBytes(&ctx.Request.Header, []byte("gzip")) {
-			b, _ = ctx.Request.BodyGunzip()
-		}
+// Addr returns the address the main server listener is bound to or nil if the server isn't running.
+// Useful with ":0" listen addresses.
+func (s *Server) Addr() net.Addr {
+	addrs := s.Addrs()
+	if len(addrs) == 0 {
+		return nil
+	}
 
-		if hasContentEncodingBytes(&ctx.Request.Header, []byte("deflate")) {
-			b, _ = ctx.Request.BodyInflate()
-		}
+	return addrs[0]
+}
 
-		if hasContentEncodingBytes(&ctx.Request.Header, []byte("br")) {
-			b, _ = ctx.Request.BodyUnbrotli()
-		}
+// Addrs returns addresses of all server listeners: the configured one first,
+// then ones added with AddListener. Returns nil if the server isn't running.
+func (s *Server) Addrs() []net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		ctx.Request.SetBody(b)
+	if len(s.listeners) == 0 {
+		return nil
+	}
 
-		h(ctx)
+	addrs := make([]net.Addr, 0, len(s.listeners))
+	for _, ln := range s.listeners {
+		addrs = append(addrs, ln.Addr())
 	}
+
+	return addrs
+}
+
+// Ready returns a channel which is closed once the listener is bound and the server is serving.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Fasthttp returns the underlying fasthttp server to tune options not exposed by the package.
+// Handler and ErrorHandler are set by New and mustn't be replaced. Options must be changed
+// before the server starts.
+func (s *Server) Fasthttp() *fasthttp.Server {
+	return &s.httpServer
+}
+
+// SetName sets the value of the Server response header.
+func (s *Server) SetName(name string) *Server {
+	s.httpServer.Name = name
+
+	return s
+}
+
+// HideServerHeader turns off the Server response header.
+func (s *Server) HideServerHeader() *Server {
+	s.httpServer.NoDefaultServerHeader = true
+
+	return s
 }
 
+// SetLogger sets server logger. Must be called before SetRouter to get requests logged.
+// Records below logger.Level are dropped, the level may be changed at runtime with LogLevelHandler.
 func (s *Server) SetLogger(logger log.Logger) *Server {
+	logger = withAtomicLevel(logger)
 	s.log = &logger
 
 	return s
 }
 
-func (s *Server) SetRouter(r *router.Router) {
+// SetRouter composes middlewares chain around the router handler. It may be called again
+// while the server is running to replace routes, new requests are served with the new chain.
+// r serves hosts not registered with SetHostRouter.
+func (s *Server) SetRouter(r *router.Router) error {
+	if r == nil {
+		return errors.ErrNilRouter
+	}
+
+	// maintenance mode is checked before routing
+	h := s.maintenanceMiddleware(s.hostHandler(r))
+
+	// ETag is computed over uncompressed body
+	if s.etag {
+		h = etagMiddleware(h)
+	}
+
 	// compression
-	h := r.Handler
 	if s.config.UseCompression() {
-		h = fasthttp.CompressHandler(h)
-		h = DecompressRequestHandler(h)
+		h = compressHandler(h, s.compress)
+		h = decompressRequestHandler(h, s.decompressedLimit(), s.log)
+	}
+
+	// compressed body size is checked before decompression
+	if s.bodyLimit > 0 {
+		h = bodyLimitMiddleware(h, s.bodyLimit)
 	}
 
+	// verified client certificate subject
+	if s.tlsConfig != nil && s.tlsConfig.ClientCAs != nil {
+		h = clientCertMiddleware(h)
+	}
+
+	// user middlewares
+	h = s.useMiddlewares(h)
+
 	// panic and fatal recovery
-	h = recoveryMiddleware(h)
-
-	// use custom logging
-	h = loggingMiddleware(h, s.log)
-
-	// nolint
-	withCors := cors.NewCorsHandler(cors.Options{
-		// if you leave allowedOrigins empty then fasthttpcors will treat it as "*"
-		AllowedOrigins: []string{}, // Only allow example.com to access the resource
-		// if you leave allowedHeaders empty then fasthttpcors will accept any non-simple headers
-		AllowedHeaders: []string{}, // only allow x-something-client and Content-Type in actual request
-		// if you leave this empty, only simple method will be accepted
-		AllowedMethods:   []string{"HEAD", "GET", "POST", "PUT", "DELETE", "OPTIONS"}, // only allow get or post to resource
-		AllowCredentials: true,                                                        // resource doesn't support credentials
-		AllowMaxAge:      5600,                                                        // cache the preflight result
-		Debug:            true,
-	})
+	h = recoveryMiddleware(h, s.log, s.panicHook)
+
+	// load shedding
+	if s.maxInFlight > 0 {
+		h = s.inFlightMiddleware(h, s.maxInFlight)
+	}
+
+	// use custom logging, skipped when logger isn't set
+	if s.log != nil {
+		h = loggingMiddleware(h, s.log, s.accessLog)
+	}
+
+	// request ID is set before logging
+	h = requestIDMiddleware(h, s.responseRequestID)
+
+	if s.requestTimeout > 0 {
+		h = requestDeadlineMiddleware(h, s.requestTimeout)
+	}
+
+	if s.negotiationRequired {
+		h = negotiationRequiredMiddleware(h)
+	}
+
+	if s.alwaysData {
+		h = alwaysDataMiddleware(h)
+	}
+
+	if s.jsonStreamThreshold > 0 {
+		h = jsonStreamMiddleware(h, s.jsonStreamThreshold)
+	}
+
+	if s.strictContentType {
+		h = strictContentTypeMiddleware(h)
+	}
+
+	if s.debug {
+		h = debugMiddleware(h)
+	}
+
+	if len(s.trustedProxies) > 0 {
+		h = clientIPMiddleware(h, s.trustedProxies)
+	}
+
+	if s.tracer != nil {
+		h = tracingMiddleware(h, s.tracer)
+	}
 
 	if s.config.CORSEnabled() {
-		h = withCors.CorsMiddleware(h)
+		corsOptions := DefaultCORSOptions()
+		if s.corsOptions != nil {
+			corsOptions = *s.corsOptions
+		}
+
+		h = corsMiddleware(h, corsOptions, s.hostRouter(r))
+	}
+
+	if s.version != "" {
+		h = versionHeaderMiddleware(h, s.version)
 	}
 
-	s.httpServer.Handler = h
+	if s.securityHeaders != nil {
+		h = securityHeadersMiddleware(h, *s.securityHeaders)
+	}
+
+	s.handler.Store(h)
+
+	return nil
+}
+
+// handle serves request with the chain composed by the last SetRouter call.
+func (s *Server) handle(ctx *fasthttp.RequestCtx) {
+	h, ok := s.handler.Load().(fasthttp.RequestHandler)
+	if !ok {
+		ctx.SetStatusCode(http.StatusServiceUnavailable)
+		JSON(ctx, errors.ErrNilRouter.Error())
+
+		return
+	}
 
-	s.router = r
+	h(ctx)
 }
 
-// Run starts the HTTP server and performs a graceful shutdown.
-func (s *Server) Run(wg *sync.WaitGroup) {
+// Run starts the HTTP server and performs a graceful shutdown on SIGINT/SIGTERM.
+// It returns an error if the server cannot be started, failed while serving or
+// wasn't gracefully stopped. If wg is not nil, wg.Done is called before Run returns.
+// In prefork mode Run of the parent process supervises children, see EnablePrefork.
+// Use Serve to serve a listener created elsewhere.
+func (s *Server) Run(wg *sync.WaitGroup) error {
+	return s.RunContext(context.Background(), wg)
+}
+
+// RunContext starts the HTTP server and performs a graceful shutdown
+// on SIGINT/SIGTERM or when ctx is done. Errors are the same as for Run.
+func (s *Server) RunContext(ctx context.Context, wg *sync.WaitGroup) error {
 	if wg != nil {
 		defer wg.Done()
 	}
@@ -140,28 +376,39 @@ func (s *Server) Run(wg *sync.WaitGroup) {
 		s.log.Debug().Msg("Server Run")
 	}
 
-	if s.router == nil {
+	if s.handler.Load() == nil {
 		if s.log != nil {
 			s.log.Error().Err(errors.ErrNilRouter).Send()
 		}
 
-		return
+		return errors.ErrNilRouter
+	}
+
+	if s.prefork {
+		idx, isChild := preforkChildIndex()
+		if !isChild {
+			return s.runPrefork(ctx)
+		}
+
+		if s.log != nil {
+			s.log.Logger = s.log.Logger.With(zap.Int("prefork-child", idx))
+		}
 	}
 
-	// create a fast listener ;)
-	// NOTE: Package reuseport provides a TCP net.Listener with SO_REUSEPORT support.
-	// SO_REUSEPORT allows linear scaling server performance on multi-CPU servers.
-	ln, err := reuseport.Listen("tcp4", s.config.GetListenAddress())
+	lns, err := s.listenAll()
 	if err != nil {
 		if s.log != nil {
-			s.log.Error().Err(err).Msg("error in reuseport listener")
+			s.log.Error().Err(err).Msg("error in listener")
 		}
 
-		return
+		return errors.WrappedError("Server.Run", "listen", err)
 	}
 
-	// create a graceful shutdown listener
-	graceful := newGracefulListener(ln, s.config.GetShutdownTimeout(), s.log)
+	// create graceful shutdown listeners
+	listeners := make([]*gracefulListener, 0, len(lns))
+	for _, ln := range lns {
+		listeners = append(listeners, s.newGracefulListener(ln))
+	}
 
 	// Get hostname
 	hostname, err := os.Hostname()
@@ -170,68 +417,302 @@ func (s *Server) Run(wg *sync.WaitGroup) {
 			s.log.Error().Err(err).Msg("hostname unavailable")
 		}
 
-		return
+		for _, graceful := range listeners {
+			_ = graceful.Close()
+		}
+
+		return errors.WrappedError("Server.Run", "os.Hostname", err)
 	}
 
+	s.mu.Lock()
+	s.listeners = listeners
+	s.hostname = hostname
+	s.mu.Unlock()
+
 	// Error handling
-	listenErr := make(chan error, 1)
+	listenErr := make(chan error, len(listeners))
 
-	// Run server
-	go func() {
+	// Run server on every listener
+	for _, graceful := range listeners {
 		if s.log != nil {
 			s.log.Debug().Msgf("%s - Web server starting on port %v", hostname, graceful.Addr())
-			s.log.Debug().Msgf("%s - Press Ctrl+C to stop", hostname)
 		}
 
-		listenErr <- s.httpServer.Serve(graceful) // or s.httpServer.ListenAndServeTLS()
-	}()
+		go func(graceful *gracefulListener) {
+			listenErr <- s.serveGraceful(graceful)
+		}(graceful)
+	}
+
+	if s.log != nil {
+		s.log.Debug().Msgf("%s - Press Ctrl+C to stop", hostname)
+	}
 
-	// SIGINT/SIGTERM handling
+	// SIGINT/SIGTERM and custom signals handling
 	osSignals := make(chan os.Signal, 1)
-	signal.Notify(osSignals, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(osSignals, s.signals()...)
+
+	defer signal.Stop(osSignals)
+
+	s.readyOnce.Do(func() { close(s.ready) })
+	s.notifyUpgraded()
+
+	ctxDone := ctx.Done()
+	serving := len(listeners)
+
+	var (
+		runErr error
+		// fired when pre-shutdown delay is over
+		preShutdown <-chan time.Time
+	)
 
 	// Handle channels/graceful shutdown
 signalLoop:
-	for {
+	for serving > 0 {
 		select {
 		// If server.ListenAndServe() cannot start due to errors such
 		// as "port in use" it will return an error.
 		case err := <-listenErr:
-			if err != nil {
+			serving--
+
+			// listener closed by shutdown is a clean stop
+			if err != nil && !isClosedConnError(err) {
 				if s.log != nil {
 					s.log.Error().Err(err).Msg("listener error")
 				}
-			}
 
-			break signalLoop
+				runErr = errors.WrappedError("Server.Run", "Serve", err)
+
+				// error on any listener stops the whole server
+				if err := s.Stop(); err != nil {
+					break signalLoop
+				}
+			}
 		// handle termination signal
-		case <-osSignals:
-			if s.log != nil {
-				s.log.Debug().Str("hostname", hostname).Msg("Shutdown signal received.")
+		case sig := <-osSignals:
+			if fn, ok := s.signalHandlers[sig]; ok {
+				if s.log != nil {
+					s.log.Debug().Str("hostname", hostname).Str("signal", sig.String()).Msg("Signal received.")
+				}
+
+				fn()
+
+				continue
 			}
 
-			// Servers in the process of shutting down should disable Keep-Alive
-			s.httpServer.DisableKeepalive = true
+			if s.upgrade && sig == upgradeSignal {
+				if s.log != nil {
+					s.log.Debug().Str("hostname", hostname).Msg("Upgrade signal received.")
+				}
+
+				// old process keeps serving if the new one can't start
+				if err := s.upgradeProcess(listeners); err != nil {
+					if s.log != nil {
+						s.log.Error().Err(err).Msg("upgrade error")
+					}
 
-			// Attempt the graceful shutdown by closing the listener
-			// and completing all inflight requests.
-			if err := graceful.Close(); err != nil {
+					continue
+				}
+			} else {
 				if s.log != nil {
-					s.log.Error().Err(err).Msg("graceful close error")
+					s.log.Debug().Str("hostname", hostname).Msg("Shutdown signal received.")
+				}
+
+				// the second signal skips the delay
+				if atomic.LoadUint32(&s.draining) == 0 {
+					if preShutdown = s.startPreShutdownDelay(hostname); preShutdown != nil {
+						continue
+					}
+				} else if s.log != nil {
+					s.log.Debug().Str("hostname", hostname).Msg("Pre-shutdown delay skipped.")
 				}
+			}
+
+			if err := s.Stop(); err != nil {
+				runErr = err
 
 				break signalLoop
 			}
+		// handle context cancellation
+		case <-ctxDone:
+			// done channel stays closed, stop selecting it
+			ctxDone = nil
+
+			if s.log != nil {
+				s.log.Debug().Str("hostname", hostname).Msg("Shutdown context done.")
+			}
+
+			// signal received earlier has started the delay already
+			if atomic.LoadUint32(&s.draining) != 0 {
+				continue
+			}
+
+			if preShutdown = s.startPreShutdownDelay(hostname); preShutdown != nil {
+				continue
+			}
 
-			// if err := s.httpServer.Shutdown(); err != nil {
-			//	log.Error().Err(err).Msg("graceful shutdown error")
-			//
-			//	break signalLoop
-			//}
+			if err := s.Stop(); err != nil {
+				runErr = err
+
+				break signalLoop
+			}
+		// pre-shutdown delay is over
+		case <-preShutdown:
+			preShutdown = nil
 
 			if s.log != nil {
-				s.log.Debug().Str("hostname", hostname).Msg("Server gracefully stopped.")
+				s.log.Debug().Str("hostname", hostname).Msg("Pre-shutdown delay finished.")
+			}
+
+			if err := s.Stop(); err != nil {
+				runErr = err
+
+				break signalLoop
 			}
 		}
 	}
+
+	// wait for shutdown started elsewhere (e.g. by Stop call) to complete
+	if err := s.Stop(); err != nil && runErr == nil {
+		runErr = err
+	}
+
+	return runErr
+}
+
+// Serve serves connections accepted by ln with the chain composed by SetRouter until Stop is called
+// or ln is closed. Unlike Run it uses ln as is without reuseport, signal handling and prefork,
+// e.g. for listeners created by other libraries or fasthttputil.InmemoryListener in tests.
+// ln is wrapped with TLS if it's configured and drained on Stop like Run listeners.
+// Run remains the batteries-included way to start the server.
+func (s *Server) Serve(ln net.Listener) error {
+	if s.handler.Load() == nil {
+		if s.log != nil {
+			s.log.Error().Err(errors.ErrNilRouter).Send()
+		}
+
+		return errors.ErrNilRouter
+	}
+
+	graceful := s.newGracefulListener(ln)
+
+	s.mu.Lock()
+	s.listeners = append(s.listeners, graceful)
+
+	if s.hostname == "" {
+		s.hostname, _ = os.Hostname()
+	}
+
+	s.mu.Unlock()
+
+	s.readyOnce.Do(func() { close(s.ready) })
+
+	if err := s.serveGraceful(graceful); err != nil && !isClosedConnError(err) {
+		if s.log != nil {
+			s.log.Error().Err(err).Msg("listener error")
+		}
+
+		_ = s.Stop()
+
+		return errors.WrappedError("Server.Serve", "Serve", err)
+	}
+
+	// wait for shutdown started by Stop call to complete
+	return s.Stop()
+}
+
+// serveGraceful serves HTTPS over the graceful listener if TLS is configured,
+// so TLS connections are drained on shutdown too.
+func (s *Server) serveGraceful(graceful *gracefulListener) error {
+	var ln net.Listener = graceful
+	if s.tlsConfig != nil {
+		ln = tls.NewListener(graceful, s.tlsConfig)
+	}
+
+	return s.httpServer.Serve(ln)
+}
+
+// Stop performs a graceful shutdown of the running server: disables keep-alive,
+// closes listeners and waits for in-flight requests and background jobs (see Go)
+// up to the shutdown timeout.
+// It is safe to call Stop several times and concurrently with signal handling.
+func (s *Server) Stop() error {
+	return s.StopWithTimeout(s.config.GetShutdownTimeout())
+}
+
+// StopWithTimeout is the same as Stop but waits for in-flight requests up to d.
+func (s *Server) StopWithTimeout(d time.Duration) error {
+	s.mu.Lock()
+	listeners, hostname := s.listeners, s.hostname
+	s.mu.Unlock()
+
+	if len(listeners) == 0 {
+		return errors.ErrServerNotRunning
+	}
+
+	s.stopOnce.Do(func() {
+		s.stopErr = s.shutdown(listeners, hostname, d)
+	})
+
+	return s.stopErr
+}
+
+// shutdown stops keep-alive and closes graceful listeners waiting for in-flight requests.
+func (s *Server) shutdown(listeners []*gracefulListener, hostname string, maxWaitTime time.Duration) error {
+	hooksCtx, cancel := context.WithTimeout(context.Background(), maxWaitTime)
+	defer cancel()
+
+	// Servers in the process of shutting down should disable Keep-Alive
+	atomic.StoreUint32(&s.shuttingDown, 1)
+	s.shutdownCancel()
+
+	s.runShutdownHooks(hooksCtx, true)
+
+	// Attempt the graceful shutdown by closing listeners
+	// and completing all inflight requests.
+	errs := make(chan error, len(listeners))
+	for _, graceful := range listeners {
+		go func(graceful *gracefulListener) {
+			errs <- graceful.closeWithTimeout(maxWaitTime)
+		}(graceful)
+	}
+
+	var closeErr error
+
+	for range listeners {
+		if err := <-errs; err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+
+	if pending := s.waitJobs(hooksCtx); pending > 0 && s.log != nil {
+		s.log.Error().Int("pending", pending).Msg("background jobs are still running after shutdown timeout")
+	}
+
+	s.runShutdownHooks(hooksCtx, false)
+
+	if closeErr != nil {
+		if s.log != nil {
+			s.log.Error().Err(closeErr).Msg("graceful close error")
+		}
+
+		return closeErr
+	}
+
+	if s.log != nil {
+		s.log.Debug().Str("hostname", hostname).Msg("Server gracefully stopped.")
+	}
+
+	return nil
+}
+
+// keepAliveMiddleware closes keep-alive connections once pre-shutdown delay or shutdown has started
+// and connections older than WithMaxConnAge.
+func (s *Server) keepAliveMiddleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		next(ctx)
+
+		if s.isDraining() || (s.maxConnAge > 0 && time.Since(ctx.ConnTime()) >= s.maxConnAge) {
+			ctx.SetConnectionClose()
+		}
+	}
 }
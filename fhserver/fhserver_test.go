@@ -0,0 +1,307 @@
+package fhserver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	log "github.com/spacetab-io/logs-go/v3"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func testLogger(t *testing.T, w io.Writer) log.Logger {
+	t.Helper()
+
+	if w == nil {
+		w = io.Discard
+	}
+
+	logger, err := log.Init(&cfgstructs.Logs{Level: "debug", Format: "text"}, "test", "http-go", "test", w)
+	if err != nil {
+		t.Fatalf("log.Init error: %v", err)
+	}
+
+	return logger
+}
+
+func testServer(t *testing.T, cfg cfgstructs.WebServer) *Server {
+	t.Helper()
+
+	return New(cfg).SetLogger(testLogger(t, nil))
+}
+
+func testRouter() *router.Router {
+	r := router.New()
+	r.GET("/ping", func(ctx *fasthttp.RequestCtx) {
+		JSON(ctx, "pong")
+	})
+
+	return r
+}
+
+func doRequest(h fasthttp.RequestHandler, req *fasthttp.Request) *fasthttp.Response {
+	var ctx fasthttp.RequestCtx
+
+	ctx.Init(req, nil, nil)
+	h(&ctx)
+
+	resp := &fasthttp.Response{}
+	ctx.Response.CopyTo(resp)
+
+	return resp
+}
+
+func newRequest(method, uri string) *fasthttp.Request {
+	req := &fasthttp.Request{}
+	req.Header.SetMethod(method)
+	req.SetRequestURI(uri)
+
+	return req
+}
+
+// testConfig allows overriding listen address of cfgstructs.WebServer.
+type testConfig struct {
+	cfgstructs.WebServer
+	listenAddress string
+}
+
+func (c testConfig) GetListenAddress() string {
+	if c.listenAddress != "" {
+		return c.listenAddress
+	}
+
+	return c.WebServer.GetListenAddress()
+}
+
+// serveInmemory serves composed server handler over in-memory listener and returns client for it.
+func serveInmemory(t *testing.T, s *Server) *fasthttp.Client {
+	t.Helper()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	go func() { _ = s.httpServer.Serve(ln) }()
+
+	t.Cleanup(func() { _ = ln.Close() })
+
+	return &fasthttp.Client{Dial: func(string) (net.Conn, error) { return ln.Dial() }}
+}
+
+func TestServer_SetName(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name  string
+		setup func(s *Server)
+		want  string
+	}
+
+	tcs := []testCase{
+		{name: "default", setup: func(s *Server) {}, want: "Service"},
+		{name: "custom", setup: func(s *Server) { s.SetName("api") }, want: "api"},
+		{name: "hidden", setup: func(s *Server) { s.HideServerHeader() }, want: ""},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := testServer(t, cfgstructs.WebServer{})
+			tc.setup(s)
+			s.SetRouter(testRouter())
+
+			client := serveInmemory(t, s)
+
+			req := newRequest("GET", "http://test/ping")
+			resp := &fasthttp.Response{}
+
+			if err := client.Do(req, resp); err != nil {
+				t.Fatalf("request error: %v", err)
+			}
+
+			if got := string(resp.Header.Peek("Server")); got != tc.want {
+				t.Errorf("Server header = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// syncBuffer is a concurrency safe bytes.Buffer for log output.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestServer_SetRouter_noLogger(t *testing.T) {
+	t.Parallel()
+
+	cfg := cfgstructs.WebServer{Compress: true}
+	cfg.CORS.Enabled = true
+
+	s := New(cfg)
+	s.SetRouter(testRouter())
+
+	resp := doRequest(s.httpServer.Handler, newRequest("GET", "http://test/ping"))
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+	}
+}
+
+func TestServer_SetRouter_swap(t *testing.T) {
+	t.Parallel()
+
+	s := testServer(t, cfgstructs.WebServer{})
+
+	if err := s.SetRouter(nil); !errors.Is(err, pkgErr.ErrNilRouter) {
+		t.Errorf("SetRouter(nil) error = %v, want %v", err, pkgErr.ErrNilRouter)
+	}
+
+	newRouter := func(body string) *router.Router {
+		r := router.New()
+		r.GET("/which", func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString(body) })
+
+		return r
+	}
+
+	if err := s.SetRouter(newRouter("old")); err != nil {
+		t.Fatalf("SetRouter error: %v", err)
+	}
+
+	client := serveInmemory(t, s)
+
+	get := func() (string, error) {
+		resp := &fasthttp.Response{}
+		if err := client.Do(newRequest("GET", "http://test/which"), resp); err != nil {
+			return "", err
+		}
+
+		return string(resp.Body()), nil
+	}
+
+	stop := make(chan struct{})
+	traffic := make(chan error, 1)
+
+	go func() {
+		for {
+			select {
+			case <-stop:
+				traffic <- nil
+
+				return
+			default:
+			}
+
+			body, err := get()
+			if err == nil && body != "old" && body != "new" {
+				err = fmt.Errorf("unexpected body %q", body)
+			}
+
+			if err != nil {
+				traffic <- err
+
+				return
+			}
+		}
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	if err := s.SetRouter(newRouter("new")); err != nil {
+		t.Fatalf("SetRouter error: %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(stop)
+
+	if err := <-traffic; err != nil {
+		t.Fatalf("request during swap: %v", err)
+	}
+
+	if body, err := get(); err != nil || body != "new" {
+		t.Errorf("after swap body = %q, err = %v, want %q", body, err, "new")
+	}
+}
+
+func TestServer_Serve(t *testing.T) {
+	t.Parallel()
+
+	cfg := cfgstructs.WebServer{}
+	cfg.Timeouts.Shutdown = time.Second
+
+	s := testServer(t, cfg)
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	if err := s.Serve(ln); !errors.Is(err, pkgErr.ErrNilRouter) {
+		t.Errorf("Serve without router error = %v, want %v", err, pkgErr.ErrNilRouter)
+	}
+
+	s.Fasthttp().Name = "injected"
+	s.SetRouter(testRouter())
+
+	done := make(chan error, 1)
+
+	go func() { done <- s.Serve(ln) }()
+
+	<-s.Ready()
+
+	client := &fasthttp.Client{Dial: func(string) (net.Conn, error) { return ln.Dial() }}
+
+	req := newRequest("GET", "http://test/ping")
+	req.SetConnectionClose()
+
+	resp := &fasthttp.Response{}
+	if err := client.Do(req, resp); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+	}
+
+	if got := string(resp.Header.Peek("Server")); got != "injected" {
+		t.Errorf("Server header = %q, want %q", got, "injected")
+	}
+
+	// middleware chain is applied
+	if len(resp.Header.Peek(requestIDKey)) == 0 {
+		t.Errorf("%s header is not set", requestIDKey)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop error: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after Stop")
+	}
+}
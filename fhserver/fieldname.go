@@ -0,0 +1,62 @@
+package fhserver
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// embeddedFieldPrefix marks names of embedded structs flattened by JSON, such namespace parts
+// are dropped from field names of validation errors.
+const embeddedFieldPrefix = "-"
+
+// UseJSONFieldNames makes v name fields by their json tags, so that validation errors are
+// reported with field names clients send, e.g. "items.0.product_id" instead of
+// "Items.0.ProductID". Fields without json name keep struct field names, fields of embedded
+// structs are reported without the embedded struct name as JSON flattens them.
+func UseJSONFieldNames(v *validator.Validate) {
+	v.RegisterTagNameFunc(jsonFieldName)
+}
+
+// UseParamFieldNames makes v name fields by their query and form tags (see BindQuery and
+// BindMultipart), fields without them are named like UseJSONFieldNames does, so the same
+// validator reports field names clients send to Bind, BindQuery and BindMultipart.
+func UseParamFieldNames(v *validator.Validate) {
+	v.RegisterTagNameFunc(paramFieldName)
+}
+
+// paramFieldName returns query or form name of fld, json name if there is none.
+func paramFieldName(fld reflect.StructField) string {
+	for _, tag := range []string{"query", "form"} {
+		if name := paramName(fld, tag); name != "" {
+			return name
+		}
+	}
+
+	return jsonFieldName(fld)
+}
+
+// jsonFieldName returns json name of fld, empty one makes validator use struct field name.
+func jsonFieldName(fld reflect.StructField) string {
+	name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+
+	if name == "" && fld.Anonymous && isJSONEmbedded(fld.Type) {
+		return embeddedFieldPrefix + fld.Name
+	}
+
+	return name
+}
+
+// isJSONEmbedded reports whether fields of embedded t are encoded as fields of the outer struct.
+func isJSONEmbedded(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct && !t.Implements(jsonMarshalerType) && !t.Implements(textMarshalerType) &&
+		!reflect.PtrTo(t).Implements(jsonMarshalerType) && !reflect.PtrTo(t).Implements(textMarshalerType)
+}
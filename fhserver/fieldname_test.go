@@ -0,0 +1,119 @@
+package fhserver
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	errs "github.com/spacetab-io/errors-go"
+)
+
+type fieldNameBase struct {
+	ID string `json:"id" validate:"required"`
+}
+
+type fieldNameAudit struct {
+	Author string `json:"author" validate:"required"`
+}
+
+type fieldNameItem struct {
+	*fieldNameAudit
+	ProductID int `json:"product_id" validate:"gt=0"`
+}
+
+type fieldNameAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type fieldNameForm struct {
+	fieldNameBase
+	Meta      fieldNameBase    `json:"meta"`
+	FirstName string           `json:"first_name" validate:"required"`
+	Nickname  string           `json:",omitempty" validate:"required"`
+	Secret    string           `json:"-" validate:"required"`
+	Address   fieldNameAddress `json:"address"`
+	Items     []fieldNameItem  `json:"items" validate:"dive"`
+}
+
+func TestUseJSONFieldNames(t *testing.T) {
+	t.Parallel()
+
+	form := fieldNameForm{Items: []fieldNameItem{
+		{fieldNameAudit: &fieldNameAudit{Author: "me"}, ProductID: 1},
+		{fieldNameAudit: &fieldNameAudit{}},
+	}}
+
+	type testCase struct {
+		name      string
+		jsonNames bool
+		want      []string
+	}
+
+	tcs := []testCase{
+		{
+			name:      "json names",
+			jsonNames: true,
+			want: []string{
+				"Nickname", "Secret", "address.city", "first_name", "id",
+				"items.1.author", "items.1.product_id", "meta.id",
+			},
+		},
+		{
+			name: "struct names",
+			want: []string{
+				"Address.City", "FirstName", "Items.1.ProductID",
+				"Items.1.fieldNameAudit.Author", "Meta.ID", "Nickname", "Secret",
+				"fieldNameBase.ID",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		v := validator.New()
+		if tc.jsonNames {
+			UseJSONFieldNames(v)
+		}
+
+		var validationErrs validator.ValidationErrors
+		if err := v.Struct(form); !errors.As(err, &validationErrs) {
+			t.Fatalf("%s: Struct error = %v, want validation errors", tc.name, err)
+		}
+
+		got := make([]string, 0, len(tc.want))
+		for field := range makeErrorsSlice(validationErrs, "en") {
+			got = append(got, string(field))
+		}
+
+		sort.Strings(got)
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: fields = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestGetFieldName(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		namespace string
+		field     string
+		want      errs.FieldName
+	}
+
+	tcs := []testCase{
+		{namespace: "form.first_name", field: "first_name", want: "first_name"},
+		{namespace: "form.items[0].product_id", field: "product_id", want: "items.0.product_id"},
+		{namespace: "form.-Base.id", field: "id", want: "id"},
+		{namespace: "form.items[2].-Audit.author", field: "author", want: "items.2.author"},
+		{namespace: "form.-Base", field: "-Base", want: "Base"},
+	}
+
+	for _, tc := range tcs {
+		if got := getFieldName(tc.namespace, tc.field); got != tc.want {
+			t.Errorf("getFieldName(%q, %q) = %q, want %q", tc.namespace, tc.field, got, tc.want)
+		}
+	}
+}
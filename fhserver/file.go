@@ -0,0 +1,150 @@
+package fhserver
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// File sends r as attachment named filename, e.g. generated CSV export. Known size is sent
+// as Content-Length, negative size means unknown one and chunked body. If r is io.ReadSeeker
+// and size is known, single byte range requests are answered with 206 Partial Content.
+// Response isn't compressed. r is closed after sending if it's io.Closer. HEAD requests get
+// headers only, r isn't read then.
+func File(ctx *fasthttp.RequestCtx, filename, contentType string, r io.Reader, size int64) {
+	skipCompression(ctx)
+
+	ctx.SetContentType(contentType)
+	ctx.Response.Header.Set(fasthttp.HeaderContentDisposition, contentDisposition(filename))
+	ctx.SetStatusCode(fasthttp.StatusOK)
+
+	seeker, ok := r.(io.ReadSeeker)
+	if !ok || size < 0 {
+		setBodyStream(ctx, r, bodySize(size))
+
+		return
+	}
+
+	ctx.Response.Header.Set(fasthttp.HeaderAcceptRanges, "bytes")
+
+	byteRange := ctx.Request.Header.Peek(fasthttp.HeaderRange)
+	// multiple ranges aren't supported, the whole file is sent
+	if len(byteRange) == 0 || bytes.IndexByte(byteRange, ',') >= 0 {
+		setBodyStream(ctx, r, bodySize(size))
+
+		return
+	}
+
+	start, end, err := fasthttp.ParseByteRange(byteRange, int(size))
+	if err != nil {
+		_ = closeReader(r)
+		ctx.Response.Header.Set(fasthttp.HeaderContentRange, "bytes */"+strconv.FormatInt(size, 10))
+		ctx.SetStatusCode(fasthttp.StatusRequestedRangeNotSatisfiable)
+		ctx.ResetBody()
+
+		return
+	}
+
+	if _, err := seeker.Seek(int64(start), io.SeekStart); err != nil {
+		_ = closeReader(r)
+		JSON(ctx, err)
+
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusPartialContent)
+	ctx.Response.Header.SetContentRange(start, end, int(size))
+	setBodyStream(ctx, &limitedReadCloser{Reader: io.LimitReader(r, int64(end-start+1)), r: r}, end-start+1)
+}
+
+// FileBytes sends b as attachment named filename, see File.
+func FileBytes(ctx *fasthttp.RequestCtx, filename, contentType string, b []byte) {
+	File(ctx, filename, contentType, bytes.NewReader(b), int64(len(b)))
+}
+
+// contentDisposition returns attachment Content-Disposition header value with ASCII filename
+// for old clients and RFC 5987 encoded UTF-8 one if filename has other characters.
+func contentDisposition(filename string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < ' ' || r > '~' || r == '"' || r == '\\' {
+			return '_'
+		}
+
+		return r
+	}, filename)
+
+	value := `attachment; filename="` + fallback + `"`
+	if fallback == filename {
+		return value
+	}
+
+	return value + "; filename*=UTF-8''" + encodeRFC5987(filename)
+}
+
+// encodeRFC5987 percent-encodes s except attr-char characters of RFC 5987.
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			strings.IndexByte("!#$&+-.^_`|~", c) >= 0:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0x0f])
+		}
+	}
+
+	return b.String()
+}
+
+// setBodyStream sends r as response body of size, -1 for unknown size. Response to HEAD request
+// gets only Content-Length of known size and r is closed, fasthttp closes the stream of unknown
+// size without reading.
+func setBodyStream(ctx *fasthttp.RequestCtx, r io.Reader, size int) {
+	if ctx.IsHead() && size >= 0 {
+		_ = closeReader(r)
+		ctx.Response.Header.SetContentLength(size)
+
+		return
+	}
+
+	ctx.SetBodyStream(r, size)
+}
+
+// bodySize returns body size argument of SetBodyStream, -1 for unknown size.
+func bodySize(size int64) int {
+	if size < 0 {
+		return -1
+	}
+
+	return int(size)
+}
+
+// closeReader closes r if it's io.Closer.
+func closeReader(r io.Reader) error {
+	if c, ok := r.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// limitedReadCloser reads a part of r and closes r.
+type limitedReadCloser struct {
+	io.Reader
+	r io.Reader
+}
+
+func (l *limitedReadCloser) Close() error {
+	return closeReader(l.r)
+}
@@ -0,0 +1,110 @@
+package fhserver
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("id,title\n"), 1000)
+
+	type testCase struct {
+		name             string
+		path             string
+		byteRange        string
+		wantCode         int
+		wantBody         []byte
+		wantContentRange string
+		wantLength       int
+	}
+
+	tcs := []testCase{
+		{name: "whole", path: "/bytes", wantCode: http.StatusOK, wantBody: content, wantLength: len(content)},
+		{
+			name: "range", path: "/bytes", byteRange: "bytes=9-17", wantCode: http.StatusPartialContent,
+			wantBody: content[9:18], wantContentRange: "bytes 9-17/9000", wantLength: 9,
+		},
+		{
+			name: "suffix range", path: "/bytes", byteRange: "bytes=-5", wantCode: http.StatusPartialContent,
+			wantBody: content[len(content)-5:], wantContentRange: "bytes 8995-8999/9000", wantLength: 5,
+		},
+		{
+			name: "unsatisfiable range", path: "/bytes", byteRange: "bytes=9000-", wantCode: http.StatusRequestedRangeNotSatisfiable,
+			wantBody: []byte{}, wantContentRange: "bytes */9000", wantLength: 0,
+		},
+		{name: "multiple ranges", path: "/bytes", byteRange: "bytes=0-1,5-6", wantCode: http.StatusOK, wantBody: content, wantLength: len(content)},
+		// reader isn't seekable, size is unknown
+		{name: "stream", path: "/stream", byteRange: "bytes=0-1", wantCode: http.StatusOK, wantBody: content, wantLength: -1},
+	}
+
+	r := router.New()
+	r.GET("/bytes", func(ctx *fasthttp.RequestCtx) {
+		FileBytes(ctx, "отчёт \"2022\".csv", "text/csv", content)
+	})
+	r.GET("/stream", func(ctx *fasthttp.RequestCtx) {
+		File(ctx, "export.csv", "text/csv", io.MultiReader(bytes.NewReader(content)), -1)
+	})
+
+	s := testServer(t, cfgstructs.WebServer{Compress: true})
+	s.SetRouter(r)
+
+	client := serveInmemory(t, s)
+
+	for _, tc := range tcs {
+		req := newRequest("GET", "http://localhost"+tc.path)
+		req.Header.Set(fasthttp.HeaderAcceptEncoding, "gzip")
+
+		if tc.byteRange != "" {
+			req.Header.Set(fasthttp.HeaderRange, tc.byteRange)
+		}
+
+		resp := &fasthttp.Response{}
+		if err := client.Do(req, resp); err != nil {
+			t.Fatalf("%s: request error: %v", tc.name, err)
+		}
+
+		if resp.StatusCode() != tc.wantCode {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.wantCode)
+		}
+
+		if enc := resp.Header.Peek(fasthttp.HeaderContentEncoding); len(enc) > 0 {
+			t.Errorf("%s: Content-Encoding = %q, want none", tc.name, enc)
+		}
+
+		if !bytes.Equal(resp.Body(), tc.wantBody) {
+			t.Errorf("%s: body of %d bytes, want %d bytes", tc.name, len(resp.Body()), len(tc.wantBody))
+		}
+
+		if got := string(resp.Header.Peek(fasthttp.HeaderContentRange)); got != tc.wantContentRange {
+			t.Errorf("%s: Content-Range = %q, want %q", tc.name, got, tc.wantContentRange)
+		}
+
+		if resp.Header.ContentLength() != tc.wantLength {
+			t.Errorf("%s: Content-Length = %d, want %d", tc.name, resp.Header.ContentLength(), tc.wantLength)
+		}
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]string{
+		"report.csv":         `attachment; filename="report.csv"`,
+		"отчёт \"2022\".csv": `attachment; filename="_____ _2022_.csv"; filename*=UTF-8''%D0%BE%D1%82%D1%87%D1%91%D1%82%20%222022%22.csv`,
+		"a\\b;c.pdf":         `attachment; filename="a_b;c.pdf"; filename*=UTF-8''a%5Cb%3Bc.pdf`,
+	}
+
+	for filename, want := range tcs {
+		if got := contentDisposition(filename); got != want {
+			t.Errorf("contentDisposition(%q) = %s, want %s", filename, got, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package fhserver
+
+import (
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+// Group registers routes under a common path prefix wrapped with group middlewares.
+// Group middlewares are executed after the global chain composed by SetRouter, in registration
+// order, parent group middlewares first.
+type Group struct {
+	group       *router.Group
+	middlewares []Middleware
+}
+
+// NewGroup returns routes group of r with path prefix and middlewares.
+func NewGroup(r *router.Router, path string, mw ...Middleware) *Group {
+	return &Group{group: r.Group(path), middlewares: mw}
+}
+
+// Group returns nested routes group inheriting g middlewares.
+func (g *Group) Group(path string, mw ...Middleware) *Group {
+	middlewares := make([]Middleware, 0, len(g.middlewares)+len(mw))
+	middlewares = append(middlewares, g.middlewares...)
+	middlewares = append(middlewares, mw...)
+
+	return &Group{group: g.group.Group(path), middlewares: middlewares}
+}
+
+// Use appends group middlewares. It affects only routes registered after the call.
+func (g *Group) Use(mw ...Middleware) *Group {
+	g.middlewares = append(g.middlewares, mw...)
+
+	return g
+}
+
+// GET is a shortcut for Handle(fasthttp.MethodGet, path, handler).
+func (g *Group) GET(path string, handler fasthttp.RequestHandler) {
+	g.Handle(fasthttp.MethodGet, path, handler)
+}
+
+// HEAD is a shortcut for Handle(fasthttp.MethodHead, path, handler).
+func (g *Group) HEAD(path string, handler fasthttp.RequestHandler) {
+	g.Handle(fasthttp.MethodHead, path, handler)
+}
+
+// POST is a shortcut for Handle(fasthttp.MethodPost, path, handler).
+func (g *Group) POST(path string, handler fasthttp.RequestHandler) {
+	g.Handle(fasthttp.MethodPost, path, handler)
+}
+
+// PUT is a shortcut for Handle(fasthttp.MethodPut, path, handler).
+func (g *Group) PUT(path string, handler fasthttp.RequestHandler) {
+	g.Handle(fasthttp.MethodPut, path, handler)
+}
+
+// PATCH is a shortcut for Handle(fasthttp.MethodPatch, path, handler).
+func (g *Group) PATCH(path string, handler fasthttp.RequestHandler) {
+	g.Handle(fasthttp.MethodPatch, path, handler)
+}
+
+// DELETE is a shortcut for Handle(fasthttp.MethodDelete, path, handler).
+func (g *Group) DELETE(path string, handler fasthttp.RequestHandler) {
+	g.Handle(fasthttp.MethodDelete, path, handler)
+}
+
+// OPTIONS is a shortcut for Handle(fasthttp.MethodOptions, path, handler).
+func (g *Group) OPTIONS(path string, handler fasthttp.RequestHandler) {
+	g.Handle(fasthttp.MethodOptions, path, handler)
+}
+
+// ANY registers handler for all methods.
+func (g *Group) ANY(path string, handler fasthttp.RequestHandler) {
+	g.Handle(router.MethodWild, path, handler)
+}
+
+// Handle registers handler wrapped with group middlewares for method and path relative to group prefix.
+func (g *Group) Handle(method, path string, handler fasthttp.RequestHandler) {
+	g.group.Handle(method, path, chainMiddlewares(handler, g.middlewares))
+}
@@ -0,0 +1,64 @@
+package fhserver
+
+import (
+	"strings"
+	"testing"
+
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestNewGroup(t *testing.T) {
+	t.Parallel()
+
+	marker := func(name string) Middleware {
+		return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+			return func(ctx *fasthttp.RequestCtx) {
+				ctx.Response.Header.Add("X-Order", name)
+				next(ctx)
+			}
+		}
+	}
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Add("X-Order", "handler")
+	}
+
+	r := NewRouter()
+	r.GET("/public", handler)
+
+	admin := NewGroup(r, "/admin", marker("auth"), marker("ipfilter"))
+	admin.GET("/users", handler)
+	admin.Group("/audit", marker("audit")).POST("/events", handler)
+
+	s := testServer(t, cfgstructs.WebServer{}).Use(marker("global"))
+	s.SetRouter(r)
+
+	tcs := []struct {
+		name   string
+		method string
+		uri    string
+		want   []string
+	}{
+		{name: "public route", method: "GET", uri: "/public", want: []string{"global", "handler"}},
+		{name: "group route", method: "GET", uri: "/admin/users", want: []string{"global", "auth", "ipfilter", "handler"}},
+		{name: "nested group route", method: "POST", uri: "/admin/audit/events", want: []string{"global", "auth", "ipfilter", "audit", "handler"}},
+		{name: "unknown group path", method: "GET", uri: "/admin/missing", want: []string{"global"}},
+	}
+
+	for _, tc := range tcs {
+		resp := doRequest(s.httpServer.Handler, newRequest(tc.method, "http://test"+tc.uri))
+
+		var got []string
+
+		resp.Header.VisitAll(func(key, value []byte) {
+			if string(key) == "X-Order" {
+				got = append(got, string(value))
+			}
+		})
+
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: X-Order = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package fhserver
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+
+	defaultHealthCheckTimeout = 5 * time.Second
+
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// HealthCheck is a named readiness check. Check must return nil when the dependency is healthy.
+// Check is cancelled after Timeout (5 seconds if zero).
+type HealthCheck struct {
+	Name    string
+	Check   func(ctx context.Context) error
+	Timeout time.Duration
+}
+
+// HealthReport is the data of health endpoints response.
+type HealthReport struct {
+	Status string              `json:"status"`
+	Checks []HealthCheckResult `json:"checks,omitempty"`
+}
+
+// HealthCheckResult is the result of a single HealthCheck.
+type HealthCheckResult struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// RegisterHealth adds liveness (LivenessPath) and readiness (ReadinessPath) endpoints to r.
+// Liveness always answers 200 without running checks. Readiness runs checks concurrently and answers
+// 200 if all of them passed and 503 otherwise. Readiness answers 503 once pre-shutdown delay or graceful
+// shutdown started, so load balancers stop sending traffic to the instance.
+func (s *Server) RegisterHealth(r *router.Router, checks ...HealthCheck) {
+	r.GET(LivenessPath, func(ctx *fasthttp.RequestCtx) {
+		JSON(ctx, HealthReport{Status: HealthStatusOK})
+	})
+
+	r.GET(ReadinessPath, func(ctx *fasthttp.RequestCtx) {
+		if s.isDraining() {
+			ctx.SetStatusCode(http.StatusServiceUnavailable)
+			JSON(ctx, HealthReport{Status: HealthStatusFail})
+
+			return
+		}
+
+		// RequestCtx is reused after the handler returns, so checks get their own context
+		report := runHealthChecks(context.Background(), checks)
+		if report.Status != HealthStatusOK {
+			ctx.SetStatusCode(http.StatusServiceUnavailable)
+		}
+
+		JSON(ctx, report)
+	})
+}
+
+func runHealthChecks(ctx context.Context, checks []HealthCheck) HealthReport {
+	report := HealthReport{Status: HealthStatusOK, Checks: make([]HealthCheckResult, len(checks))}
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(checks))
+
+	for i, check := range checks {
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+
+			report.Checks[i] = runHealthCheck(ctx, check)
+		}(i, check)
+	}
+
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		if res.Status != HealthStatusOK {
+			report.Status = HealthStatusFail
+		}
+	}
+
+	return report
+}
+
+// runHealthCheck runs check with timeout. Result is returned on timeout even if check ignores ctx.
+func runHealthCheck(ctx context.Context, check HealthCheck) HealthCheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	t := time.Now()
+	done := make(chan error, 1)
+
+	go func() { done <- check.Check(ctx) }()
+
+	var err error
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := HealthCheckResult{Name: check.Name, Status: HealthStatusOK, Duration: time.Since(t)}
+	if err != nil {
+		res.Status = HealthStatusFail
+		res.Error = err.Error()
+	}
+
+	return res
+}
@@ -0,0 +1,145 @@
+package fhserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestServer_RegisterHealth(t *testing.T) {
+	t.Parallel()
+
+	pass := HealthCheck{Name: "pass", Check: func(ctx context.Context) error { return nil }}
+	fail := HealthCheck{Name: "fail", Check: func(ctx context.Context) error { return errors.New("db is down") }}
+	slow := HealthCheck{Name: "slow", Timeout: 50 * time.Millisecond, Check: func(ctx context.Context) error {
+		time.Sleep(time.Second)
+
+		return nil
+	}}
+
+	type testCase struct {
+		name       string
+		checks     []HealthCheck
+		wantCode   int
+		wantStatus string
+		wantChecks map[string]string
+	}
+
+	tcs := []testCase{
+		{
+			name:       "passing",
+			checks:     []HealthCheck{pass},
+			wantCode:   fasthttp.StatusOK,
+			wantStatus: HealthStatusOK,
+			wantChecks: map[string]string{"pass": HealthStatusOK},
+		},
+		{
+			name:       "failing",
+			checks:     []HealthCheck{pass, fail},
+			wantCode:   fasthttp.StatusServiceUnavailable,
+			wantStatus: HealthStatusFail,
+			wantChecks: map[string]string{"pass": HealthStatusOK, "fail": HealthStatusFail},
+		},
+		{
+			name:       "timing out",
+			checks:     []HealthCheck{slow},
+			wantCode:   fasthttp.StatusServiceUnavailable,
+			wantStatus: HealthStatusFail,
+			wantChecks: map[string]string{"slow": HealthStatusFail},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := testServer(t, cfgstructs.WebServer{})
+			r := router.New()
+			s.RegisterHealth(r, tc.checks...)
+
+			resp := doRequest(r.Handler, newRequest("GET", "http://test"+LivenessPath))
+			if resp.StatusCode() != fasthttp.StatusOK {
+				t.Errorf("liveness status code = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+			}
+
+			started := time.Now()
+			resp = doRequest(r.Handler, newRequest("GET", "http://test"+ReadinessPath))
+
+			if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+				t.Errorf("readiness took %v, checks must be bounded by timeout", elapsed)
+			}
+
+			if resp.StatusCode() != tc.wantCode {
+				t.Errorf("readiness status code = %d, want %d", resp.StatusCode(), tc.wantCode)
+			}
+
+			var body struct {
+				Data HealthReport `json:"data"`
+			}
+
+			if err := json.Unmarshal(resp.Body(), &body); err != nil {
+				t.Fatalf("json.Unmarshal error: %v, body: %s", err, resp.Body())
+			}
+
+			if body.Data.Status != tc.wantStatus {
+				t.Errorf("status = %q, want %q", body.Data.Status, tc.wantStatus)
+			}
+
+			if len(body.Data.Checks) != len(tc.wantChecks) {
+				t.Fatalf("checks len = %d, want %d", len(body.Data.Checks), len(tc.wantChecks))
+			}
+
+			for _, res := range body.Data.Checks {
+				if res.Status != tc.wantChecks[res.Name] {
+					t.Errorf("check %q status = %q, want %q", res.Name, res.Status, tc.wantChecks[res.Name])
+				}
+			}
+		})
+	}
+}
+
+func TestServer_RegisterHealth_shutdown(t *testing.T) {
+	t.Parallel()
+
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Port: 0}
+	cfg.Timeouts.Shutdown = time.Second
+
+	r := router.New()
+	s := testServer(t, cfg)
+	s.RegisterHealth(r)
+	s.SetRouter(r)
+
+	readiness := make(chan int, 1)
+
+	s.OnPreShutdown(func(ctx context.Context) {
+		readiness <- doRequest(s.httpServer.Handler, newRequest("GET", "http://test"+ReadinessPath)).StatusCode()
+	})
+
+	done := make(chan error, 1)
+
+	go func() { done <- s.Run(nil) }()
+
+	<-s.Ready()
+
+	if code := doRequest(s.httpServer.Handler, newRequest("GET", "http://test"+ReadinessPath)).StatusCode(); code != fasthttp.StatusOK {
+		t.Errorf("readiness before shutdown status code = %d, want %d", code, fasthttp.StatusOK)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop error: %v", err)
+	}
+
+	if code := <-readiness; code != fasthttp.StatusServiceUnavailable {
+		t.Errorf("readiness during shutdown status code = %d, want %d", code, fasthttp.StatusServiceUnavailable)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("Run error: %v", err)
+	}
+}
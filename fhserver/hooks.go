@@ -0,0 +1,44 @@
+package fhserver
+
+import (
+	"context"
+	"time"
+)
+
+type shutdownHook struct {
+	fn     func(ctx context.Context)
+	before bool
+}
+
+// OnShutdown registers a hook executed once during graceful stop after listeners are closed
+// and in-flight requests are drained. Hooks get a context bounded by the shutdown timeout
+// and are executed in registration order.
+func (s *Server) OnShutdown(fn func(ctx context.Context)) *Server {
+	s.shutdownHooks = append(s.shutdownHooks, shutdownHook{fn: fn})
+
+	return s
+}
+
+// OnPreShutdown is the same as OnShutdown but the hook is executed before listeners are closed.
+func (s *Server) OnPreShutdown(fn func(ctx context.Context)) *Server {
+	s.shutdownHooks = append(s.shutdownHooks, shutdownHook{fn: fn, before: true})
+
+	return s
+}
+
+// runShutdownHooks executes hooks of the given stage logging their duration.
+func (s *Server) runShutdownHooks(ctx context.Context, before bool) {
+	for i, hook := range s.shutdownHooks {
+		if hook.before != before {
+			continue
+		}
+
+		t := time.Now()
+
+		hook.fn(ctx)
+
+		if s.log != nil {
+			s.log.Debug().Int("hook", i).Dur("duration", time.Since(t)).Msg("shutdown hook done")
+		}
+	}
+}
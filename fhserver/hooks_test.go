@@ -0,0 +1,71 @@
+package fhserver
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+)
+
+func TestServer_OnShutdown(t *testing.T) {
+	t.Parallel()
+
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Port: 0}
+	cfg.Timeouts.Shutdown = time.Second
+
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+
+	record := func(name string) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("hook %s context has no deadline", name)
+			}
+
+			mu.Lock()
+			calls = append(calls, name)
+			mu.Unlock()
+		}
+	}
+
+	s := testServer(t, cfg).
+		OnShutdown(record("after-1")).
+		OnPreShutdown(record("before")).
+		OnShutdown(record("after-2"))
+	s.SetRouter(testRouter())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() { done <- s.RunContext(ctx, nil) }()
+
+	<-s.Ready()
+
+	// race Stop with context cancellation
+	go func() { _ = s.Stop() }()
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatalf("RunContext error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	want := []string{"before", "after-1", "after-2"}
+	if len(calls) != len(want) {
+		t.Fatalf("hook calls = %v, want %v", calls, want)
+	}
+
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("hook calls = %v, want %v", calls, want)
+
+			break
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package fhserver
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/valyala/fasthttp"
+)
+
+// maxInFlightConfig is implemented by configs that limit the number of concurrently handled requests.
+type maxInFlightConfig interface {
+	GetMaxInFlight() int
+}
+
+// WithMaxInFlight sets the maximum number of concurrently handled requests.
+// Requests over the limit are answered with 503 and Retry-After header. Zero means no limit.
+// Overrides GetMaxInFlight of the config.
+func WithMaxInFlight(n int) Option {
+	return func(s *Server) {
+		s.maxInFlight = n
+	}
+}
+
+// InFlight returns the number of requests being handled at the moment.
+// It's counted only when max in-flight limit is set.
+func (s *Server) InFlight() int {
+	return int(atomic.LoadInt32(&s.inFlight))
+}
+
+// inFlightMiddleware sheds requests exceeding the in-flight limit.
+func (s *Server) inFlightMiddleware(next fasthttp.RequestHandler, limit int) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		defer atomic.AddInt32(&s.inFlight, -1)
+
+		if int(atomic.AddInt32(&s.inFlight, 1)) > limit {
+			ctx.Response.Header.Set("Retry-After", strconv.Itoa(defaultRetryAfter))
+			ctx.SetStatusCode(http.StatusServiceUnavailable)
+			JSON(ctx, "too many requests in flight")
+
+			return
+		}
+
+		next(ctx)
+	}
+}
@@ -0,0 +1,110 @@
+package fhserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+type maxInFlightTestConfig struct {
+	cfgstructs.WebServer
+	maxInFlight int
+}
+
+func (c maxInFlightTestConfig) GetMaxInFlight() int {
+	return c.maxInFlight
+}
+
+func TestServer_inFlightMiddleware(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	entered := make(chan struct{}, 2)
+
+	r := router.New()
+	r.GET("/block", func(ctx *fasthttp.RequestCtx) {
+		entered <- struct{}{}
+		<-release
+		JSON(ctx, "done")
+	})
+	r.GET("/ping", func(ctx *fasthttp.RequestCtx) { JSON(ctx, "pong") })
+
+	s := New(maxInFlightTestConfig{maxInFlight: 2}).SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	client := serveInmemory(t, s)
+
+	blocked := make(chan int, 2)
+
+	for i := 0; i < 2; i++ {
+		go func() {
+			resp := &fasthttp.Response{}
+			if err := client.Do(newRequest("GET", "http://test/block"), resp); err != nil {
+				blocked <- 0
+
+				return
+			}
+
+			blocked <- resp.StatusCode()
+		}()
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-entered:
+		case <-time.After(5 * time.Second):
+			t.Fatal("blocking handler wasn't called")
+		}
+	}
+
+	if got := s.InFlight(); got != 2 {
+		t.Errorf("InFlight = %d, want 2", got)
+	}
+
+	resp := &fasthttp.Response{}
+	if err := client.Do(newRequest("GET", "http://test/ping"), resp); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusServiceUnavailable {
+		t.Errorf("over limit: status code = %d, want %d", resp.StatusCode(), fasthttp.StatusServiceUnavailable)
+	}
+
+	if len(resp.Header.Peek("Retry-After")) == 0 {
+		t.Error("Retry-After header must be set")
+	}
+
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		if code := <-blocked; code != fasthttp.StatusOK {
+			t.Errorf("blocked request status code = %d, want %d", code, fasthttp.StatusOK)
+		}
+	}
+
+	if got := s.InFlight(); got != 0 {
+		t.Errorf("InFlight after release = %d, want 0", got)
+	}
+
+	resp.Reset()
+
+	if err := client.Do(newRequest("GET", "http://test/ping"), resp); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("after release: status code = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+	}
+}
+
+func TestWithMaxInFlight(t *testing.T) {
+	t.Parallel()
+
+	s := New(maxInFlightTestConfig{maxInFlight: 2}, WithMaxInFlight(5))
+	if s.maxInFlight != 5 {
+		t.Errorf("maxInFlight = %d, want 5", s.maxInFlight)
+	}
+}
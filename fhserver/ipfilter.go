@@ -0,0 +1,39 @@
+package fhserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+)
+
+// IPFilter returns middleware answering 403 to clients not allowed by allow/deny CIDRs lists.
+// Deny list wins over allow list, empty allow list allows everything not denied.
+// Client IP is taken with ClientIP, so trusted proxies are respected.
+// The middleware may be used globally with Server.Use or wrap handlers of a routes group.
+func IPFilter(allow []string, deny []string) (Middleware, error) {
+	allowNets, err := parseCIDRs(allow)
+	if err != nil {
+		return nil, fmt.Errorf("IPFilter allow list error: %w", err)
+	}
+
+	denyNets, err := parseCIDRs(deny)
+	if err != nil {
+		return nil, fmt.Errorf("IPFilter deny list error: %w", err)
+	}
+
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			ip := ClientIP(ctx)
+
+			if containsIP(denyNets, ip) || (len(allowNets) > 0 && !containsIP(allowNets, ip)) {
+				ctx.SetStatusCode(http.StatusForbidden)
+				JSON(ctx, http.StatusText(http.StatusForbidden))
+
+				return
+			}
+
+			next(ctx)
+		}
+	}, nil
+}
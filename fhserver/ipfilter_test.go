@@ -0,0 +1,86 @@
+package fhserver
+
+import (
+	"net"
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestIPFilter(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name   string
+		allow  []string
+		deny   []string
+		remote string
+		want   int
+	}
+
+	tcs := []testCase{
+		{name: "allowed", allow: []string{"192.168.0.0/16"}, remote: "192.168.1.1", want: fasthttp.StatusOK},
+		{name: "not allowed", allow: []string{"192.168.0.0/16"}, remote: "10.0.0.1", want: fasthttp.StatusForbidden},
+		{name: "deny wins", allow: []string{"192.168.0.0/16"}, deny: []string{"192.168.1.0/24"}, remote: "192.168.1.1", want: fasthttp.StatusForbidden},
+		{name: "empty allow", deny: []string{"10.0.0.0/8"}, remote: "192.168.1.1", want: fasthttp.StatusOK},
+		{name: "empty allow denied", deny: []string{"10.0.0.0/8"}, remote: "10.1.1.1", want: fasthttp.StatusForbidden},
+		{name: "ipv6 allowed", allow: []string{"2001:db8::/32"}, remote: "2001:db8::1", want: fasthttp.StatusOK},
+		{name: "ipv6 not allowed", allow: []string{"2001:db8::/32"}, remote: "2001:db9::1", want: fasthttp.StatusForbidden},
+		{name: "ipv6 denied", deny: []string{"fe80::/10"}, remote: "fe80::1", want: fasthttp.StatusForbidden},
+		{name: "ipv4 client ipv6 list", allow: []string{"2001:db8::/32"}, remote: "192.168.1.1", want: fasthttp.StatusForbidden},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			filter, err := IPFilter(tc.allow, tc.deny)
+			if err != nil {
+				t.Fatalf("IPFilter error: %v", err)
+			}
+
+			h := filter(func(ctx *fasthttp.RequestCtx) {})
+
+			var ctx fasthttp.RequestCtx
+
+			ctx.Init(newRequest("GET", "http://test/"), &net.TCPAddr{IP: net.ParseIP(tc.remote)}, nil)
+			h(&ctx)
+
+			if ctx.Response.StatusCode() != tc.want {
+				t.Errorf("status code = %d, want %d", ctx.Response.StatusCode(), tc.want)
+			}
+		})
+	}
+
+	if _, err := IPFilter([]string{"bad"}, nil); err == nil {
+		t.Error("IPFilter must fail on bad CIDR")
+	}
+}
+
+func TestIPFilter_group(t *testing.T) {
+	t.Parallel()
+
+	filter, err := IPFilter([]string{"10.0.0.0/8"}, nil)
+	if err != nil {
+		t.Fatalf("IPFilter error: %v", err)
+	}
+
+	r := router.New()
+	r.GET("/public", func(ctx *fasthttp.RequestCtx) {})
+
+	admin := r.Group("/admin")
+	admin.GET("/stats", filter(func(ctx *fasthttp.RequestCtx) {}))
+
+	s := testServer(t, cfgstructs.WebServer{})
+	s.SetRouter(r)
+
+	for uri, want := range map[string]int{"/public": fasthttp.StatusOK, "/admin/stats": fasthttp.StatusForbidden} {
+		resp := doRequest(s.httpServer.Handler, newRequest("GET", "http://test"+uri))
+		if resp.StatusCode() != want {
+			t.Errorf("%s: status code = %d, want %d", uri, resp.StatusCode(), want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package fhserver
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// Go runs fn in a background goroutine tracked by the server, e.g. webhook fan-out started
+// by a handler. fn gets ShutdownContext and should return soon after it's cancelled: graceful
+// shutdown waits for running jobs after connections are drained, up to the shutdown timeout.
+// Panics in fn are recovered and logged.
+func (s *Server) Go(fn func(ctx context.Context)) {
+	s.jobsMu.Lock()
+	s.jobsPending++
+	s.jobsMu.Unlock()
+
+	go func() {
+		defer s.jobDone()
+
+		defer func() {
+			if rvr := recover(); rvr != nil && s.log != nil {
+				s.log.Error().
+					Str("panic", fmt.Sprintf("%v", rvr)).
+					Str("stack", string(debug.Stack())).
+					Msg("background job panic recovered")
+			}
+		}()
+
+		fn(s.shutdownCtx)
+	}()
+}
+
+// ShutdownContext returns context cancelled when graceful shutdown starts.
+func (s *Server) ShutdownContext() context.Context {
+	return s.shutdownCtx
+}
+
+// jobDone marks background job finished, waitJobs is notified when there are none left.
+func (s *Server) jobDone() {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+
+	s.jobsPending--
+
+	if s.jobsPending == 0 && s.jobsIdle != nil {
+		close(s.jobsIdle)
+		s.jobsIdle = nil
+	}
+}
+
+// waitJobs waits for background jobs until ctx is done and returns the number of jobs still running.
+func (s *Server) waitJobs(ctx context.Context) int {
+	s.jobsMu.Lock()
+
+	if s.jobsPending == 0 {
+		s.jobsMu.Unlock()
+
+		return 0
+	}
+
+	if s.jobsIdle == nil {
+		s.jobsIdle = make(chan struct{})
+	}
+
+	idle := s.jobsIdle
+	s.jobsMu.Unlock()
+
+	select {
+	case <-idle:
+		return 0
+	case <-ctx.Done():
+	}
+
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+
+	return s.jobsPending
+}
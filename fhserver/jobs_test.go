@@ -0,0 +1,102 @@
+package fhserver
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+// runJobsTestServer starts server with shutdown timeout d serving in-memory listener.
+func runJobsTestServer(t *testing.T, d time.Duration, buf *syncBuffer) *Server {
+	t.Helper()
+
+	cfg := cfgstructs.WebServer{}
+	cfg.Timeouts.Shutdown = d
+
+	s := New(cfg).SetLogger(testLogger(t, buf))
+	s.SetRouter(testRouter())
+
+	go func() { _ = s.Serve(fasthttputil.NewInmemoryListener()) }()
+
+	<-s.Ready()
+
+	return s
+}
+
+func TestServer_Go(t *testing.T) {
+	t.Parallel()
+
+	buf := &syncBuffer{}
+
+	s := runJobsTestServer(t, time.Second, buf)
+
+	var finished int32
+
+	s.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		// job completes its work after the shutdown has started
+		time.Sleep(100 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+	})
+
+	s.Go(func(ctx context.Context) {
+		panic("job failure")
+	})
+
+	if err := s.ShutdownContext().Err(); err != nil {
+		t.Fatalf("ShutdownContext error before shutdown = %v, want nil", err)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop error: %v", err)
+	}
+
+	if atomic.LoadInt32(&finished) != 1 {
+		t.Error("Stop returned before background job finished")
+	}
+
+	if s.ShutdownContext().Err() == nil {
+		t.Error("ShutdownContext isn't cancelled after shutdown")
+	}
+
+	if !strings.Contains(buf.String(), "background job panic recovered") {
+		t.Errorf("job panic isn't logged, got:\n%s", buf.String())
+	}
+}
+
+func TestServer_Go_timeout(t *testing.T) {
+	t.Parallel()
+
+	const timeout = 200 * time.Millisecond
+
+	buf := &syncBuffer{}
+
+	s := runJobsTestServer(t, timeout, buf)
+
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	// job ignores shutdown context
+	s.Go(func(context.Context) {
+		<-release
+	})
+
+	start := time.Now()
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop error: %v", err)
+	}
+
+	if d := time.Since(start); d > timeout+time.Second {
+		t.Errorf("Stop took %s, want about %s", d, timeout)
+	}
+
+	if !strings.Contains(buf.String(), "background jobs are still running") || !strings.Contains(buf.String(), "pending") {
+		t.Errorf("pending jobs aren't logged, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,192 @@
+package fhserver
+
+import (
+	"bufio"
+	"encoding"
+	stdjson "encoding/json"
+	"io"
+	"reflect"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/valyala/fasthttp"
+)
+
+// userValueJSONStreamThreshold is JSON stream threshold of the request, see WithJSONStreamThreshold.
+const userValueJSONStreamThreshold = "fhserver.jsonStreamThreshold"
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*stdjson.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// WithJSONStreamThreshold makes JSON stream successful responses with list data once more than
+// size bytes are encoded: the rest of the list is encoded while the response is sent, flushing
+// size bytes at a time, so big lists aren't held in memory. Smaller responses are buffered.
+// Size <= 0 disables streaming.
+//
+// Status code of the streamed response is already sent when the rest of the list is encoded,
+// so encoding errors are logged and the response is truncated instead of 500. Handlers must
+// not modify the list after JSON.
+func WithJSONStreamThreshold(size int) Option {
+	return func(s *Server) {
+		s.jsonStreamThreshold = size
+	}
+}
+
+// jsonStreamMiddleware passes JSON stream threshold to JSON, see WithJSONStreamThreshold.
+func jsonStreamMiddleware(next fasthttp.RequestHandler, threshold int) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.SetUserValue(userValueJSONStreamThreshold, threshold)
+
+		next(ctx)
+	}
+}
+
+// writeJSON encodes obj into the response body with pooled stream without intermediate copy
+// made by json.Marshal, big lists are streamed, see WithJSONStreamThreshold. Engines other
+// than jsoniter marshal obj.
+func writeJSON(ctx *fasthttp.RequestCtx, obj *Response) error {
+	// stream has no writer: jsoniter drops buffer capacity on each write to it
+	stream, ok := borrowJSONStream()
+	if !ok {
+		res, err := jsonEngine.Marshal(obj)
+		if err != nil {
+			return err
+		}
+
+		ctx.SetBody(res)
+
+		return nil
+	}
+
+	threshold, _ := ctx.UserValue(userValueJSONStreamThreshold).(int)
+	items := reflect.ValueOf(obj.Data)
+
+	// Content-Length of HEAD response is the size of the whole body
+	if threshold <= 0 || obj.Error != nil || ctx.IsHead() || !isJSONStreamable(items) {
+		defer stream.Pool().ReturnStream(stream)
+
+		stream.WriteVal(obj)
+
+		if stream.Error != nil {
+			return stream.Error
+		}
+
+		ctx.ResetBody()
+
+		return flushJSONStream(ctx.Response.BodyWriter(), stream)
+	}
+
+	stream.WriteObjectStart()
+	stream.WriteObjectField("data")
+	stream.WriteArrayStart()
+
+	i := 0
+	for ; i < items.Len() && stream.Buffered() < threshold && stream.Error == nil; i++ {
+		writeJSONItem(stream, items, i)
+	}
+
+	if err := stream.Error; err != nil {
+		stream.Pool().ReturnStream(stream)
+
+		return err
+	}
+
+	if i == items.Len() {
+		defer stream.Pool().ReturnStream(stream)
+
+		writeJSONTail(stream, obj)
+		ctx.ResetBody()
+
+		return flushJSONStream(ctx.Response.BodyWriter(), stream)
+	}
+
+	// request context isn't available while streaming
+	logger := requestLogger(ctx)
+	path := string(ctx.Path())
+
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer stream.Pool().ReturnStream(stream)
+
+		if err := streamJSON(w, stream, obj, items, i, threshold); err != nil && logger != nil {
+			logger.Warn().Err(err).Str("path", path).Msg("json stream error")
+		}
+	})
+
+	return nil
+}
+
+// streamJSON encodes items starting from i and the rest of obj to w, flushing the stream
+// when it has buffered threshold bytes.
+func streamJSON(w io.Writer, stream *jsoniter.Stream, obj *Response, items reflect.Value, i, threshold int) error {
+	for ; i < items.Len(); i++ {
+		if stream.Buffered() >= threshold {
+			if err := flushJSONStream(w, stream); err != nil {
+				return err
+			}
+		}
+
+		writeJSONItem(stream, items, i)
+
+		if stream.Error != nil {
+			return stream.Error
+		}
+	}
+
+	writeJSONTail(stream, obj)
+
+	return flushJSONStream(w, stream)
+}
+
+func writeJSONItem(stream *jsoniter.Stream, items reflect.Value, i int) {
+	if i > 0 {
+		stream.WriteMore()
+	}
+
+	stream.WriteVal(items.Index(i).Interface())
+}
+
+// writeJSONTail closes data list and writes the rest of obj the same way json.Marshal does.
+func writeJSONTail(stream *jsoniter.Stream, obj *Response) {
+	stream.WriteArrayEnd()
+
+	if obj.Meta != nil {
+		stream.WriteMore()
+		stream.WriteObjectField("meta")
+		stream.WriteVal(obj.Meta)
+	}
+
+	if obj.RequestID != "" {
+		stream.WriteMore()
+		stream.WriteObjectField("request_id")
+		stream.WriteString(obj.RequestID)
+	}
+
+	stream.WriteObjectEnd()
+}
+
+// flushJSONStream moves bytes buffered by stream to w.
+func flushJSONStream(w io.Writer, stream *jsoniter.Stream) error {
+	_, err := w.Write(stream.Buffer())
+	stream.SetBuffer(stream.Buffer()[:0])
+
+	return err
+}
+
+// isJSONStreamable reports whether v is a list encoded by json.Marshal as array of its elements.
+func isJSONStreamable(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return false
+		}
+	case reflect.Array:
+	default:
+		return false
+	}
+
+	t := v.Type()
+
+	return t.Elem().Kind() != reflect.Uint8 && !t.Implements(jsonMarshalerType) && !t.Implements(textMarshalerType) &&
+		!reflect.PtrTo(t).Implements(jsonMarshalerType) && !reflect.PtrTo(t).Implements(textMarshalerType)
+}
@@ -0,0 +1,228 @@
+package fhserver
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+type streamTestItem struct {
+	ID    int               `json:"id"`
+	Title string            `json:"title"`
+	Tags  map[string]string `json:"tags,omitempty"`
+	Note  *string           `json:"note"`
+}
+
+// streamTestMarshaler is a list with its own json encoding.
+type streamTestMarshaler []int
+
+func (streamTestMarshaler) MarshalJSON() ([]byte, error) { return []byte(`"custom"`), nil }
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+
+	items := make([]streamTestItem, 50)
+	for i := range items {
+		items[i] = streamTestItem{ID: i, Title: "<b>order</b> " + strings.Repeat("x", i), Tags: map[string]string{"b": "2", "a": "1"}}
+	}
+
+	type testCase struct {
+		name     string
+		response interface{}
+	}
+
+	tcs := []testCase{
+		{name: "slice", response: items},
+		{name: "empty slice", response: []int{}},
+		{name: "nil slice", response: []int(nil)},
+		{name: "array", response: [3]string{"a", "b", "c"}},
+		{name: "bytes", response: []byte("raw")},
+		{name: "marshaler", response: streamTestMarshaler{1, 2}},
+		{name: "page", response: pageResponse{items: items, meta: PageMeta{Total: 100, Page: 1, PerPage: 50}}},
+		{name: "struct", response: items[1]},
+		{name: "error", response: pkgErr.ErrNotFound},
+	}
+
+	for _, tc := range tcs {
+		want := &fasthttp.RequestCtx{}
+		want.SetUserValue(userValueResponseRequestID, true)
+		want.SetUserValue(requestIDKey, uuid.MustParse("8a3f2f9e-4a8b-4c52-9d7e-2d3c6f1b0a11"))
+
+		// reference output is encoded by json.Marshal
+		respond(want, tc.response, responseEncoding{
+			contentType: jsonEncoding.contentType,
+			marshal:     jsonEncoding.marshal,
+			errorBody:   errorBody,
+		})
+
+		for _, threshold := range []int{0, 1, 64, 1 << 20} {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.SetUserValue(userValueResponseRequestID, true)
+			ctx.SetUserValue(requestIDKey, uuid.MustParse("8a3f2f9e-4a8b-4c52-9d7e-2d3c6f1b0a11"))
+			ctx.SetUserValue(userValueJSONStreamThreshold, threshold)
+
+			JSON(ctx, tc.response)
+
+			if ctx.Response.StatusCode() != want.Response.StatusCode() {
+				t.Errorf("%s, threshold %d: status code = %d, want %d",
+					tc.name, threshold, ctx.Response.StatusCode(), want.Response.StatusCode())
+			}
+
+			if got := string(ctx.Response.Body()); got != string(want.Response.Body()) {
+				t.Errorf("%s, threshold %d: body =\n%s\nwant\n%s", tc.name, threshold, got, want.Response.Body())
+			}
+		}
+	}
+}
+
+func TestWriteJSON_error(t *testing.T) {
+	t.Parallel()
+
+	items := make([]interface{}, 100)
+	for i := range items {
+		items[i] = strings.Repeat("x", 100)
+	}
+
+	items[len(items)-1] = math.Inf(1)
+
+	type testCase struct {
+		name      string
+		threshold int
+		wantCode  int
+		wantBody  string
+	}
+
+	tcs := []testCase{
+		{
+			name:      "buffered",
+			threshold: 1 << 20,
+			wantCode:  fasthttp.StatusInternalServerError,
+			wantBody:  `{"error":{"message":"unsupported value: +Inf"}}`,
+		},
+		{
+			// status is sent before encoding fails
+			name:      "streamed",
+			threshold: 256,
+			wantCode:  fasthttp.StatusOK,
+			wantBody:  `{"data":["` + strings.Repeat("x", 100) + `",`,
+		},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.SetUserValue(userValueJSONStreamThreshold, tc.threshold)
+
+		JSON(ctx, items)
+
+		if ctx.Response.StatusCode() != tc.wantCode {
+			t.Errorf("%s: status code = %d, want %d", tc.name, ctx.Response.StatusCode(), tc.wantCode)
+		}
+
+		if got := string(ctx.Response.Body()); !strings.HasPrefix(got, tc.wantBody) || tc.wantCode != fasthttp.StatusOK && got != tc.wantBody {
+			t.Errorf("%s: body = %s, want %s", tc.name, got, tc.wantBody)
+		}
+	}
+}
+
+func TestWithJSONStreamThreshold(t *testing.T) {
+	t.Parallel()
+
+	items := make([]streamTestItem, 1000)
+	for i := range items {
+		items[i] = streamTestItem{ID: i, Title: "order"}
+	}
+
+	want, err := json.Marshal(Response{Data: items})
+	if err != nil {
+		t.Fatalf("json.Marshal error: %v", err)
+	}
+
+	r := router.New()
+	r.GET("/orders", func(ctx *fasthttp.RequestCtx) {
+		JSON(ctx, items)
+	})
+	r.GET("/broken", func(ctx *fasthttp.RequestCtx) {
+		JSON(ctx, []float64{1, 2, 3, math.Inf(1)})
+	})
+
+	buf := &syncBuffer{}
+
+	s := New(cfgstructs.WebServer{}, WithJSONStreamThreshold(1024)).SetLogger(testLogger(t, buf))
+	s.SetRouter(r)
+
+	client := serveInmemory(t, s)
+
+	resp := &fasthttp.Response{}
+	if err := client.Do(newRequest("GET", "http://localhost/orders"), resp); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+	}
+
+	// streamed response has no Content-Length
+	if resp.Header.ContentLength() != -1 {
+		t.Errorf("Content-Length = %d, want chunked response", resp.Header.ContentLength())
+	}
+
+	if !bytes.Equal(resp.Body(), want) {
+		t.Errorf("streamed body differs from json.Marshal output:\n%s\nwant\n%s", resp.Body(), want)
+	}
+
+	// small response is buffered and fails with 500
+	if err := client.Do(newRequest("GET", "http://localhost/broken"), resp); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusInternalServerError {
+		t.Errorf("broken status code = %d, want %d", resp.StatusCode(), fasthttp.StatusInternalServerError)
+	}
+}
+
+func BenchmarkJSON_large(b *testing.B) {
+	// about 5 MB of json
+	items := make([]streamTestItem, 50000)
+	for i := range items {
+		items[i] = streamTestItem{ID: i, Title: strings.Repeat("report line ", 7), Tags: map[string]string{"kind": "daily"}}
+	}
+
+	size, _ := json.Marshal(Response{Data: items})
+
+	for _, bc := range []struct {
+		name      string
+		enc       responseEncoding
+		threshold int
+	}{
+		{name: "marshal", enc: responseEncoding{contentType: "application/json", marshal: jsonEncoding.marshal, errorBody: errorBody}},
+		{name: "buffered", enc: jsonEncoding},
+		{name: "streamed", enc: jsonEncoding, threshold: 64 << 10},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(size)))
+
+			w := bufio.NewWriter(io.Discard)
+
+			for i := 0; i < b.N; i++ {
+				ctx := &fasthttp.RequestCtx{}
+				ctx.SetUserValue(userValueJSONStreamThreshold, bc.threshold)
+
+				respond(ctx, items, bc.enc)
+
+				if err := ctx.Response.Write(w); err != nil {
+					b.Fatalf("write error: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package fhserver
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// langMatcher returns available language equal to lowercase language tag, false if there is none.
+type langMatcher func(tag string) (string, bool)
+
+// SetDefaultLanguage replaces the language of localized messages used when the client accepts
+// none of available ones, "ru" by default. The language should have validation messages, see
+// RegisterValidationMessages.
+//
+// SetDefaultLanguage must be called before serving starts, it isn't safe to call concurrently
+// with request handling.
+func SetDefaultLanguage(lang string) {
+	defaultLang = strings.ToLower(lang)
+}
+
+// ResolvedLang returns language of localized response messages, e.g. validation errors:
+// the most preferred language of Accept-Language header having validation messages (see
+// RegisterValidationMessages and UseValidatorTranslations), "ru" by default (see
+// SetDefaultLanguage). Content-Language request header is used if there is no Accept-Language
+// one for older clients sending it.
+func ResolvedLang(ctx *fasthttp.RequestCtx) string {
+	return negotiateLang(ctx, func(tag string) (string, bool) {
+		return tag, hasValidationLang(tag)
+	}, defaultLang, nil)
+}
+
+// NegotiateLanguage returns the most preferred language of Accept-Language header among
+// available ones, e.g. "en" for "en-GB,en;q=0.9,ru;q=0.5" and available "ru" and "en".
+// Language tags match exactly or by primary subtag (en-GB matches en), "*" matches the default
+// language or the first available one not excluded with q=0. Content-Language request header
+// is used if there is no Accept-Language one. Without match it returns the default language
+// (see SetDefaultLanguage) or the first of available ones if the default one isn't available.
+// Vary: Accept-Language is added, so caches don't mix localized responses up.
+func NegotiateLanguage(ctx *fasthttp.RequestCtx, available []string) string {
+	addVary(&ctx.Response.Header, fasthttp.HeaderAcceptLanguage)
+
+	fallback := defaultLang
+
+	if len(available) > 0 {
+		fallback = available[0]
+	}
+
+	match := func(tag string) (string, bool) {
+		for _, lang := range available {
+			if strings.EqualFold(lang, tag) {
+				return lang, true
+			}
+		}
+
+		return "", false
+	}
+
+	if lang, ok := match(defaultLang); ok {
+		fallback = lang
+	}
+
+	return negotiateLang(ctx, match, fallback, available)
+}
+
+// negotiateLang returns language of request headers matched by match, fallback if there is none.
+// "*" of Accept-Language matches fallback, then the first of others.
+func negotiateLang(ctx *fasthttp.RequestCtx, match langMatcher, fallback string, others []string) string {
+	if accept := string(ctx.Request.Header.Peek(fasthttp.HeaderAcceptLanguage)); accept != "" {
+		if lang, ok := acceptedLang(accept, match, append([]string{fallback}, others...)); ok {
+			return lang
+		}
+
+		return fallback
+	}
+
+	if lang, ok := matchLang(string(ctx.Request.Header.Peek(fasthttp.HeaderContentLanguage)), match); ok {
+		return lang
+	}
+
+	return fallback
+}
+
+// acceptedLang returns the most preferred language of Accept-Language header matched by match,
+// "*" matches the first of wildcard languages not excluded with q=0.
+func acceptedLang(accept string, match langMatcher, wildcard []string) (string, bool) {
+	type langRange struct {
+		tag string
+		q   float64
+	}
+
+	ranges := make([]langRange, 0)
+	excluded := make(map[string]bool)
+
+	for _, part := range strings.Split(accept, ",") {
+		tag, q := parseMediaRange(part)
+
+		switch {
+		case tag == "":
+		case q > 0:
+			ranges = append(ranges, langRange{tag: tag, q: q})
+		default:
+			excluded[tag] = true
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, r := range ranges {
+		if r.tag != "*" {
+			if lang, ok := matchLang(r.tag, match); ok {
+				return lang, true
+			}
+
+			continue
+		}
+
+		for _, lang := range wildcard {
+			if !excluded[strings.ToLower(lang)] {
+				return lang, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// matchLang returns language matching tag exactly or by primary subtag, e.g. "en" for "en-US".
+func matchLang(tag string, match langMatcher) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", false
+	}
+
+	if lang, ok := match(tag); ok {
+		return lang, true
+	}
+
+	if i := strings.IndexByte(tag, '-'); i > 0 {
+		return match(tag[:i])
+	}
+
+	return "", false
+}
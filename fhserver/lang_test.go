@@ -0,0 +1,190 @@
+package fhserver
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+func TestResolvedLang(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name            string
+		acceptLanguage  string
+		contentLanguage string
+		want            string
+	}
+
+	tcs := []testCase{
+		{name: "no headers", want: "ru"},
+		{name: "exact", acceptLanguage: "en", want: "en"},
+		{name: "region", acceptLanguage: "en-US", want: "en"},
+		{name: "quality", acceptLanguage: "de;q=0.9, en;q=0.5, ru;q=0.7", want: "ru"},
+		{name: "excluded", acceptLanguage: "en;q=0, *;q=0.1", want: "ru"},
+		{name: "unknown", acceptLanguage: "fr-CA, de", want: "ru"},
+		{name: "legacy Content-Language", contentLanguage: "en", want: "en"},
+		{name: "Accept-Language wins", acceptLanguage: "ru", contentLanguage: "en", want: "ru"},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+
+		if tc.acceptLanguage != "" {
+			ctx.Request.Header.Set(fasthttp.HeaderAcceptLanguage, tc.acceptLanguage)
+		}
+
+		if tc.contentLanguage != "" {
+			ctx.Request.Header.Set(fasthttp.HeaderContentLanguage, tc.contentLanguage)
+		}
+
+		if got := ResolvedLang(ctx); got != tc.want {
+			t.Errorf("%s: ResolvedLang = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name            string
+		available       []string
+		acceptLanguage  string
+		contentLanguage string
+		want            string
+	}
+
+	available := []string{"en", "ru", "pt-BR"}
+
+	tcs := []testCase{
+		{name: "no headers", available: available, want: "ru"},
+		{name: "weighted", available: available, acceptLanguage: "en-GB,en;q=0.9,ru;q=0.5", want: "en"},
+		{name: "weighted order", available: available, acceptLanguage: "en;q=0.4, ru;q=0.8", want: "ru"},
+		{name: "exact region", available: available, acceptLanguage: "pt-br", want: "pt-BR"},
+		{name: "region fallback", available: available, acceptLanguage: "ru-UA", want: "ru"},
+		{name: "unknown region", available: available, acceptLanguage: "pt-PT", want: "ru"},
+		{name: "unknown tags", available: available, acceptLanguage: "de, x-klingon;q=0.5, fr", want: "ru"},
+		{name: "wildcard", available: available, acceptLanguage: "de, *;q=0.5", want: "ru"},
+		{name: "wildcard after match", available: available, acceptLanguage: "*;q=0.1, en", want: "en"},
+		{name: "wildcard without default", available: available, acceptLanguage: "ru;q=0, *", want: "en"},
+		{name: "excluded", available: available, acceptLanguage: "en;q=0, ru;q=0.3", want: "ru"},
+		{name: "malformed", available: available, acceptLanguage: ",;q=1, en;q=x", want: "en"},
+		{name: "Content-Language", available: available, contentLanguage: "EN-US", want: "en"},
+		{name: "Accept-Language wins", available: available, acceptLanguage: "ru", contentLanguage: "en", want: "ru"},
+		{name: "default not available", available: []string{"en", "de"}, acceptLanguage: "fr", want: "en"},
+		{name: "nothing available", acceptLanguage: "en", want: "ru"},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+
+		if tc.acceptLanguage != "" {
+			ctx.Request.Header.Set(fasthttp.HeaderAcceptLanguage, tc.acceptLanguage)
+		}
+
+		if tc.contentLanguage != "" {
+			ctx.Request.Header.Set(fasthttp.HeaderContentLanguage, tc.contentLanguage)
+		}
+
+		if got := NegotiateLanguage(ctx, tc.available); got != tc.want {
+			t.Errorf("%s: NegotiateLanguage = %q, want %q", tc.name, got, tc.want)
+		}
+
+		if got := string(ctx.Response.Header.Peek(fasthttp.HeaderVary)); got != fasthttp.HeaderAcceptLanguage {
+			t.Errorf("%s: Vary = %q, want %q", tc.name, got, fasthttp.HeaderAcceptLanguage)
+		}
+	}
+}
+
+// TestSetDefaultLanguage isn't parallel as the default language is global.
+func TestSetDefaultLanguage(t *testing.T) {
+	t.Cleanup(func() { SetDefaultLanguage("ru") })
+
+	SetDefaultLanguage("EN")
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set(fasthttp.HeaderAcceptLanguage, "fr")
+
+	if got := ResolvedLang(ctx); got != "en" {
+		t.Errorf("ResolvedLang = %q, want %q", got, "en")
+	}
+
+	if got := NegotiateLanguage(ctx, []string{"ru", "en"}); got != "en" {
+		t.Errorf("NegotiateLanguage = %q, want %q", got, "en")
+	}
+}
+
+func TestJSON_contentLanguage(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		Name string `validate:"required"`
+	}
+
+	type testCase struct {
+		name     string
+		accept   string
+		response interface{}
+		wantLang string
+		wantBody string
+	}
+
+	validationErr := validator.New().Struct(item{})
+
+	tcs := []testCase{
+		{
+			name:     "ru",
+			accept:   "ru-RU",
+			response: validationErr,
+			wantLang: "ru",
+			wantBody: `{"error":{"message":"validation error","code":"VALIDATION_FAILED",` +
+				"\"validation\":{\"Name\":[\"Свойство `Name` обязательно для заполнения\"]}}}",
+		},
+		{
+			name:     "en",
+			accept:   "en-GB, ru;q=0.5",
+			response: validationErr,
+			wantLang: "en",
+			wantBody: `{"error":{"message":"validation error","code":"VALIDATION_FAILED",` +
+				"\"validation\":{\"Name\":[\"Property `Name` is required\"]}}}",
+		},
+		{
+			name:     "unknown falls back",
+			accept:   "fr",
+			response: validationErr,
+			wantLang: "ru",
+			wantBody: `{"error":{"message":"validation error","code":"VALIDATION_FAILED",` +
+				"\"validation\":{\"Name\":[\"Свойство `Name` обязательно для заполнения\"]}}}",
+		},
+		{
+			name:     "not localized error",
+			accept:   "en",
+			response: pkgErr.ErrConflict,
+			wantBody: `{"error":{"message":"conflict"}}`,
+		},
+		{
+			name:     "success",
+			accept:   "en",
+			response: "ok",
+			wantBody: `{"data":"ok"}`,
+		},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.Set(fasthttp.HeaderAcceptLanguage, tc.accept)
+
+		JSON(ctx, tc.response)
+
+		if got := string(ctx.Response.Header.Peek(fasthttp.HeaderContentLanguage)); got != tc.wantLang {
+			t.Errorf("%s: Content-Language = %q, want %q", tc.name, got, tc.wantLang)
+		}
+
+		if got := string(ctx.Response.Body()); got != tc.wantBody {
+			t.Errorf("%s: body = %s, want %s", tc.name, got, tc.wantBody)
+		}
+	}
+}
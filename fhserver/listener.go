@@ -0,0 +1,253 @@
+package fhserver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp/reuseport"
+)
+
+const (
+	unixSocketPrefix      = "unix://"
+	defaultUnixSocketMode = os.FileMode(0o660)
+	defaultNetwork        = "tcp"
+	// first file descriptor passed with systemd socket activation
+	listenFDsStart = 3
+)
+
+// SetNetwork sets listener network: "tcp" (dual-stack), "tcp4" or "tcp6". By default addresses
+// with IP host are listened on its family, the unspecified IPv6 one on "tcp", while empty host
+// and hostnames are listened on "tcp4" with SO_REUSEPORT. Explicit "tcp" listens them dual-stack
+// without SO_REUSEPORT.
+func (s *Server) SetNetwork(network string) *Server {
+	s.network = network
+
+	return s
+}
+
+// DisableReusePort makes server listen with plain net.Listen instead of SO_REUSEPORT listener.
+// By default SO_REUSEPORT listener is used unless the network is dual-stack (see SetNetwork),
+// falling back to net.Listen when the system doesn't support SO_REUSEPORT.
+func (s *Server) DisableReusePort() *Server {
+	s.noReusePort = true
+
+	return s
+}
+
+// SetUnixSocketMode sets file permissions of the unix socket created for "unix://" listen address.
+func (s *Server) SetUnixSocketMode(mode os.FileMode) *Server {
+	s.unixSocketMode = mode
+
+	return s
+}
+
+// AddListener adds one more listen address served with the same router.
+// All listeners are started and gracefully stopped together.
+func (s *Server) AddListener(addr string) *Server {
+	s.extraAddrs = append(s.extraAddrs, addr)
+
+	return s
+}
+
+// SetListenerFile makes server serve the listening socket f instead of listening on the configured
+// listen address. The file is duplicated, so the caller may close f once the server is ready.
+func (s *Server) SetListenerFile(f *os.File) *Server {
+	s.listenerFile = f
+
+	return s
+}
+
+// listenAll creates listeners for the configured listen address and addresses added with AddListener.
+// The configured address is replaced by the file set with SetListenerFile or by sockets passed
+// with systemd socket activation (LISTEN_FDS/LISTEN_PID). All listeners are inherited
+// by the process started with the upgrade, see EnableUpgrade.
+func (s *Server) listenAll() ([]net.Listener, error) {
+	if lns, err := s.upgradeListeners(); err != nil || len(lns) > 0 {
+		return lns, err
+	}
+
+	lns, err := s.inheritedListeners()
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := s.extraAddrs
+	if len(lns) == 0 {
+		addrs = append([]string{s.config.GetListenAddress()}, s.extraAddrs...)
+	}
+
+	for _, addr := range addrs {
+		ln, err := s.listen(addr)
+		if err != nil {
+			for _, ln := range lns {
+				_ = ln.Close()
+			}
+
+			return nil, err
+		}
+
+		lns = append(lns, ln)
+	}
+
+	return lns, nil
+}
+
+// inheritedListeners returns listeners for the file set with SetListenerFile or socket activation fds.
+func (s *Server) inheritedListeners() ([]net.Listener, error) {
+	if s.listenerFile != nil {
+		ln, err := net.FileListener(s.listenerFile)
+		if err != nil {
+			return nil, fmt.Errorf("net.FileListener error: %w", err)
+		}
+
+		return []net.Listener{ln}, nil
+	}
+
+	return fileListeners(listenFDsStart, listenFDs(), "socket activation")
+}
+
+// fileListeners returns listeners for n sequential file descriptors starting from start.
+func fileListeners(start, n int, name string) ([]net.Listener, error) {
+	lns := make([]net.Listener, 0, n)
+
+	for fd := start; fd < start+n; fd++ {
+		f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+
+		if err != nil {
+			for _, ln := range lns {
+				_ = ln.Close()
+			}
+
+			return nil, fmt.Errorf("%s fd %d error: %w", name, fd, err)
+		}
+
+		lns = append(lns, ln)
+	}
+
+	return lns, nil
+}
+
+// listenFDs returns the number of sockets passed to the process with systemd socket activation.
+func listenFDs() int {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return 0
+	}
+
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 0 {
+		return 0
+	}
+
+	return n
+}
+
+// listen creates server listener for the listen address.
+// Addresses prefixed with "unix://" are served over unix domain socket,
+// the socket file is removed on listener close.
+func (s *Server) listen(addr string) (net.Listener, error) {
+	if strings.HasPrefix(addr, unixSocketPrefix) {
+		return s.listenUnix(strings.TrimPrefix(addr, unixSocketPrefix))
+	}
+
+	network := s.network
+	if network == "" {
+		network = defaultNetwork
+	}
+
+	// create a fast listener ;)
+	// NOTE: Package reuseport provides a TCP net.Listener with SO_REUSEPORT support.
+	// SO_REUSEPORT allows linear scaling server performance on multi-CPU servers.
+	if !s.noReusePort {
+		rpNetwork, ok := reusePortNetwork(s.network, addr)
+		if ok {
+			ln, err := reuseport.Listen(rpNetwork, addr)
+			if err == nil {
+				return ln, nil
+			}
+
+			var noReusePort *reuseport.ErrNoReusePort
+			if !errors.As(err, &noReusePort) {
+				return nil, fmt.Errorf("reuseport.Listen error: %w", err)
+			}
+		}
+
+		if s.log != nil {
+			s.log.Warn().Str("network", network).Str("address", addr).Msg("listening without SO_REUSEPORT")
+		}
+	}
+
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("net.Listen error: %w", err)
+	}
+
+	return ln, nil
+}
+
+// reusePortNetwork returns network suitable for reuseport listener, empty network means default.
+// reuseport supports only tcp4 and tcp6, so dual-stack "tcp" is narrowed by the host IP literal
+// when possible. By default empty host and hostnames are listened on tcp4.
+func reusePortNetwork(network, addr string) (string, bool) {
+	switch network {
+	case "tcp4", "tcp6":
+		return network, true
+	case "", "tcp":
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return "", false
+		}
+
+		ip := net.ParseIP(host)
+
+		switch {
+		case ip == nil && network == "":
+			return "tcp4", true
+		case ip == nil:
+			return "", false
+		case ip.To4() != nil:
+			return "tcp4", true
+		case ip.IsUnspecified():
+			// "[::]" means dual-stack
+			return "", false
+		default:
+			return "tcp6", true
+		}
+	default:
+		return "", false
+	}
+}
+
+func (s *Server) listenUnix(path string) (net.Listener, error) {
+	// remove stale socket left by previous process
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("stale unix socket remove error: %w", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("unix socket listen error: %w", err)
+	}
+
+	mode := s.unixSocketMode
+	if mode == 0 {
+		mode = defaultUnixSocketMode
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		_ = ln.Close()
+
+		return nil, fmt.Errorf("unix socket chmod error: %w", err)
+	}
+
+	return ln, nil
+}
@@ -0,0 +1,303 @@
+package fhserver
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestServer_RunUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.sock")
+
+	// leave stale socket file
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatalf("net.ListenUnix error: %v", err)
+	}
+
+	stale.SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	cfg := testConfig{listenAddress: unixSocketPrefix + path}
+	cfg.Timeouts.Shutdown = time.Second
+
+	s := New(cfg).SetLogger(testLogger(t, nil)).SetUnixSocketMode(0o600)
+	s.SetRouter(testRouter())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() { done <- s.RunContext(ctx, nil) }()
+
+	select {
+	case <-s.Ready():
+	case err := <-done:
+		t.Fatalf("RunContext error: %v", err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("os.Stat error: %v", err)
+	}
+
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %v, want %v", fi.Mode().Perm(), os.FileMode(0o600))
+	}
+
+	client := &fasthttp.Client{Dial: func(string) (net.Conn, error) { return net.Dial("unix", path) }}
+
+	code, _, err := client.Get(nil, "http://unix/ping")
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if code != fasthttp.StatusOK {
+		t.Errorf("status code = %d, want %d", code, fasthttp.StatusOK)
+	}
+
+	client.CloseIdleConnections()
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Errorf("RunContext error: %v", err)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("socket file must be removed on shutdown")
+	}
+}
+
+func TestServer_listen(t *testing.T) {
+	t.Parallel()
+
+	s := New(cfgstructs.WebServer{Host: "127.0.0.1", Port: 0})
+
+	ln, err := s.listen(s.config.GetListenAddress())
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+
+	defer ln.Close()
+
+	if _, ok := ln.Addr().(*net.TCPAddr); !ok {
+		t.Errorf("listener address = %T, want *net.TCPAddr", ln.Addr())
+	}
+}
+
+func Test_reusePortNetwork(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		network string
+		addr    string
+		want    string
+		wantOK  bool
+	}
+
+	tcs := []testCase{
+		{network: "tcp4", addr: "127.0.0.1:80", want: "tcp4", wantOK: true},
+		{network: "tcp6", addr: "[::1]:80", want: "tcp6", wantOK: true},
+		{network: "tcp", addr: "127.0.0.1:80", want: "tcp4", wantOK: true},
+		{network: "tcp", addr: "0.0.0.0:80", want: "tcp4", wantOK: true},
+		{network: "tcp", addr: "[::1]:80", want: "tcp6", wantOK: true},
+		{network: "tcp", addr: "[::]:80"},
+		{network: "tcp", addr: ":80"},
+		{network: "tcp", addr: "localhost:80"},
+		{network: "", addr: "127.0.0.1:80", want: "tcp4", wantOK: true},
+		{network: "", addr: "[::1]:80", want: "tcp6", wantOK: true},
+		{network: "", addr: ":80", want: "tcp4", wantOK: true},
+		{network: "", addr: "localhost:80", want: "tcp4", wantOK: true},
+		{network: "", addr: "[::]:80"},
+		{network: "unix", addr: "/tmp/app.sock"},
+	}
+
+	for _, tc := range tcs {
+		got, ok := reusePortNetwork(tc.network, tc.addr)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("reusePortNetwork(%q, %q) = %q, %v, want %q, %v", tc.network, tc.addr, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestServer_listenDefaultNetwork(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+
+	// empty host is listened on tcp4 with SO_REUSEPORT, so the port can be shared
+	s := New(cfgstructs.WebServer{Port: 0}).SetLogger(testLogger(t, buf))
+
+	ln, err := s.listen(s.config.GetListenAddress())
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+
+	defer ln.Close()
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	ln2, err := s.listen(":" + port)
+	if err != nil {
+		t.Fatalf("port of default listener must be shared: %v", err)
+	}
+
+	_ = ln2.Close()
+
+	if strings.Contains(buf.String(), "without SO_REUSEPORT") {
+		t.Errorf("unexpected warning: %s", buf.String())
+	}
+
+	// explicit dual-stack network can't be listened with SO_REUSEPORT
+	s = New(cfgstructs.WebServer{Port: 0}).SetLogger(testLogger(t, buf)).SetNetwork("tcp")
+
+	ln3, err := s.listen(s.config.GetListenAddress())
+	if err != nil {
+		t.Fatalf("listen tcp error: %v", err)
+	}
+
+	_ = ln3.Close()
+
+	if !strings.Contains(buf.String(), "listening without SO_REUSEPORT") {
+		t.Errorf("warning isn't logged: %s", buf.String())
+	}
+}
+
+func TestServer_listenIPv6(t *testing.T) {
+	t.Parallel()
+
+	probe, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback is not available: %v", err)
+	}
+
+	_ = probe.Close()
+
+	for _, network := range []string{"tcp", "tcp6"} {
+		s := New(testConfig{listenAddress: "[::1]:0"}).SetNetwork(network)
+
+		ln, err := s.listen(s.config.GetListenAddress())
+		if err != nil {
+			t.Fatalf("listen %s error: %v", network, err)
+		}
+
+		if ip := ln.Addr().(*net.TCPAddr).IP; !ip.Equal(net.IPv6loopback) {
+			t.Errorf("listen %s address = %v, want %v", network, ip, net.IPv6loopback)
+		}
+
+		_ = ln.Close()
+	}
+}
+
+func TestServer_DisableReusePort(t *testing.T) {
+	t.Parallel()
+
+	s := New(testConfig{listenAddress: "127.0.0.1:0"}).DisableReusePort()
+
+	ln, err := s.listen(s.config.GetListenAddress())
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+
+	defer ln.Close()
+
+	// plain listener doesn't set SO_REUSEPORT, so the port can't be shared
+	other := New(testConfig{listenAddress: ln.Addr().String()})
+	if ln2, err := other.listen(other.config.GetListenAddress()); err == nil {
+		_ = ln2.Close()
+
+		t.Error("port of plain listener must not be shared")
+	}
+}
+
+func TestServer_SetListenerFile(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen error: %v", err)
+	}
+
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("File error: %v", err)
+	}
+
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	cfg := testConfig{listenAddress: "unix:///nonexistent/dir/fhserver.sock"}
+	cfg.Timeouts.Shutdown = time.Second
+
+	s := New(cfg).SetLogger(testLogger(t, nil)).SetListenerFile(f)
+	s.SetRouter(testRouter())
+
+	done := make(chan error, 1)
+
+	go func() { done <- s.Run(nil) }()
+
+	<-s.Ready()
+
+	_ = f.Close()
+
+	if got := s.Addr().String(); got != addr {
+		t.Errorf("Addr = %s, want %s", got, addr)
+	}
+
+	req := newRequest("GET", "http://"+addr+"/ping")
+	req.SetConnectionClose()
+
+	resp := &fasthttp.Response{}
+	if err := fasthttp.Do(req, resp); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop error: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("Run error: %v", err)
+	}
+}
+
+func TestListenFDs(t *testing.T) {
+	type testCase struct {
+		name string
+		pid  string
+		fds  string
+		want int
+	}
+
+	tcs := []testCase{
+		{name: "not set", pid: "", fds: "", want: 0},
+		{name: "own pid", pid: strconv.Itoa(os.Getpid()), fds: "2", want: 2},
+		{name: "other pid", pid: strconv.Itoa(os.Getpid() + 1), fds: "2", want: 0},
+		{name: "bad fds", pid: strconv.Itoa(os.Getpid()), fds: "x", want: 0},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("LISTEN_PID", tc.pid)
+			t.Setenv("LISTEN_FDS", tc.fds)
+
+			if got := listenFDs(); got != tc.want {
+				t.Errorf("listenFDs = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
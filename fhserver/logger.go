@@ -1,33 +1,181 @@
 package fhserver
 
 import (
+	"bytes"
 	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
 	log "github.com/spacetab-io/logs-go/v3"
 	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap/zapcore"
 )
 
+// userValueLogger holds server logger for helpers logging after the handler returns, e.g. Websocket.
+const userValueLogger = "fhserver.logger"
+
+// requestLogger returns server logger or nil if it isn't set.
+func requestLogger(ctx *fasthttp.RequestCtx) *log.Logger {
+	logger, _ := ctx.UserValue(userValueLogger).(*log.Logger)
+
+	return logger
+}
+
+// AccessLogFields turns on optional access log fields.
+type AccessLogFields struct {
+	// response body size, "bytes", isn't logged for streamed responses
+	BytesWritten bool
+	// Referer header, "referer"
+	Referer bool
+	// matched route pattern, "route". Requires router.SaveMatchedRoutePath set before routes registration.
+	Route bool
+	// request Content-Length header, "content-length"
+	ContentLength bool
+}
+
+// SetAccessLogFields turns on optional access log fields. Must be called before SetRouter.
+func (s *Server) SetAccessLogFields(fields AccessLogFields) *Server {
+	s.accessLog.fields = fields
+
+	return s
+}
+
+// accessLogOptions configures which requests are logged by loggingMiddleware.
+type accessLogOptions struct {
+	fields AccessLogFields
+
+	// exact paths and path prefixes of successful requests which are not logged
+	skipPaths    map[string]struct{}
+	skipPrefixes []string
+
+	// every sampleEvery-th successful request is logged, slow ones are always logged
+	sampleEvery   uint32
+	slowThreshold time.Duration
+}
+
+// WithAccessLogSampling makes access log record only every n-th 2xx/3xx response.
+// 4xx/5xx responses and requests handled longer than slowThreshold (if not zero) are always logged.
+func WithAccessLogSampling(n int, slowThreshold time.Duration) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.accessLog.sampleEvery = uint32(n)
+		}
+
+		s.accessLog.slowThreshold = slowThreshold
+	}
+}
+
+// SetLogSkipPaths sets paths of successful requests not written to access log, e.g. health checks.
+// Path ending with "*" matches by prefix. Requests answered with 4xx/5xx are always logged.
+// Must be called before SetRouter.
+func (s *Server) SetLogSkipPaths(paths ...string) *Server {
+	s.accessLog.skipPaths = make(map[string]struct{}, len(paths))
+	s.accessLog.skipPrefixes = nil
+
+	for _, p := range paths {
+		if strings.HasSuffix(p, "*") {
+			s.accessLog.skipPrefixes = append(s.accessLog.skipPrefixes, strings.TrimSuffix(p, "*"))
+
+			continue
+		}
+
+		s.accessLog.skipPaths[p] = struct{}{}
+	}
+
+	return s
+}
+
+// skip reports whether request with path and statusCode mustn't be logged.
+func (o accessLogOptions) skip(path []byte, statusCode int) bool {
+	if statusCode >= http.StatusBadRequest {
+		return false
+	}
+
+	if _, ok := o.skipPaths[string(path)]; ok {
+		return true
+	}
+
+	for _, prefix := range o.skipPrefixes {
+		if bytes.HasPrefix(path, []byte(prefix)) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // loggingMiddleware is same as Combined but colored.
-func loggingMiddleware(req fasthttp.RequestHandler, logger *log.Logger) fasthttp.RequestHandler {
+func loggingMiddleware(req fasthttp.RequestHandler, logger *log.Logger, opts accessLogOptions) fasthttp.RequestHandler {
+	// successful requests counter for sampling
+	var sampled uint32
+
 	return func(ctx *fasthttp.RequestCtx) {
 		begin := time.Now()
 
+		ctx.SetUserValue(userValueLogger, logger)
+
 		req(ctx)
 
 		defer func() {
 			end := time.Now()
 			statusCode := ctx.Response.Header.StatusCode()
 
+			if opts.skip(ctx.Path(), statusCode) {
+				return
+			}
+
+			if opts.sampleEvery > 1 && statusCode < http.StatusBadRequest &&
+				(opts.slowThreshold == 0 || end.Sub(begin) < opts.slowThreshold) &&
+				atomic.AddUint32(&sampled, 1)%opts.sampleEvery != 0 {
+				return
+			}
+
 			event := logger.LogEvent().
 				Int("status", statusCode).
 				Bytes("method", ctx.Method()).
 				Bytes("path", ctx.RequestURI()).
-				Bytes("ip", ctx.RemoteIP()).
+				Str("ip", ClientIP(ctx).String()).
+				Str("local-addr", ctx.LocalAddr().String()).
 				Dur("latency", end.Sub(begin)).
 				Bytes("user-agent", ctx.UserAgent())
 
+			if id := RequestID(ctx); id != uuid.Nil {
+				event.Str("req_id", id.String())
+			}
+
+			if sc := trace.SpanContextFromContext(TraceContext(ctx)); sc.HasTraceID() {
+				event.Str("trace_id", sc.TraceID().String())
+			}
+
+			if subject, ok := ClientCertSubject(ctx); ok {
+				event.Str("client-cn", subject.CommonName)
+			}
+
+			if method := OriginalMethod(ctx); method != "" {
+				event.Str("original-method", method)
+			}
+
+			// reading streamed body would buffer it
+			if opts.fields.BytesWritten && !ctx.Response.IsBodyStream() {
+				event.Int("bytes", len(ctx.Response.Body()))
+			}
+
+			if opts.fields.Referer {
+				event.Bytes("referer", ctx.Referer())
+			}
+
+			if route, ok := ctx.UserValue(router.MatchedRoutePathParam).(string); ok && opts.fields.Route {
+				event.Str("route", route)
+			}
+
+			if opts.fields.ContentLength {
+				event.Int("content-length", ctx.Request.Header.ContentLength())
+			}
+
 			switch {
 			case statusCode >= http.StatusBadRequest && statusCode < http.StatusInternalServerError:
 				event.SetLogLevel(zapcore.WarnLevel).Send()
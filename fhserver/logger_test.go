@@ -0,0 +1,175 @@
+package fhserver
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestServer_SetLogSkipPaths(t *testing.T) {
+	t.Parallel()
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.QueryArgs().Peek("fail")) != "" {
+			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		}
+	}
+
+	r := router.New()
+	r.GET("/healthz", handler)
+	r.GET("/metrics/{name}", handler)
+	r.GET("/api", handler)
+
+	type testCase struct {
+		uri    string
+		logged bool
+	}
+
+	tcs := []testCase{
+		{uri: "/healthz", logged: false},
+		{uri: "/metrics/cpu", logged: false},
+		{uri: "/api", logged: true},
+		{uri: "/healthz?fail=1", logged: true},
+		{uri: "/metrics/cpu?fail=1", logged: true},
+	}
+
+	for _, tc := range tcs {
+		buf := &syncBuffer{}
+
+		s := New(cfgstructs.WebServer{}).SetLogger(testLogger(t, buf)).SetLogSkipPaths("/healthz", "/metrics/*")
+		s.SetRouter(r)
+
+		resp := doRequest(s.httpServer.Handler, newRequest("GET", "http://test"+tc.uri))
+
+		if got := strings.Contains(buf.String(), "latency"); got != tc.logged {
+			t.Errorf("%s: logged = %v, want %v, log:\n%s", tc.uri, got, tc.logged, buf.String())
+		}
+
+		if resp.StatusCode() == fasthttp.StatusNotFound {
+			t.Errorf("%s: skipped request must be routed", tc.uri)
+		}
+	}
+}
+
+func TestWithAccessLogSampling(t *testing.T) {
+	t.Parallel()
+
+	r := router.New()
+	r.GET("/ok", func(ctx *fasthttp.RequestCtx) {})
+	r.GET("/fail", func(ctx *fasthttp.RequestCtx) { ctx.SetStatusCode(fasthttp.StatusBadRequest) })
+	r.GET("/slow", func(ctx *fasthttp.RequestCtx) { time.Sleep(20 * time.Millisecond) })
+
+	buf := &syncBuffer{}
+
+	s := New(cfgstructs.WebServer{}, WithAccessLogSampling(10, 10*time.Millisecond)).SetLogger(testLogger(t, buf))
+	s.SetRouter(r)
+
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			doRequest(s.httpServer.Handler, newRequest("GET", "http://test/ok"))
+		}()
+	}
+
+	wg.Wait()
+
+	for i := 0; i < 5; i++ {
+		doRequest(s.httpServer.Handler, newRequest("GET", "http://test/fail"))
+	}
+
+	doRequest(s.httpServer.Handler, newRequest("GET", "http://test/slow"))
+
+	count := func(path string) int {
+		n := 0
+
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if strings.Contains(line, "latency") && strings.Contains(line, path) {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	if got := count("/ok"); got != 10 {
+		t.Errorf("sampled success events = %d, want 10", got)
+	}
+
+	if got := count("/fail"); got != 5 {
+		t.Errorf("error events = %d, want 5", got)
+	}
+
+	if got := count("/slow"); got != 1 {
+		t.Errorf("slow events = %d, want 1", got)
+	}
+}
+
+func TestServer_SetAccessLogFields(t *testing.T) {
+	t.Parallel()
+
+	r := router.New()
+	r.SaveMatchedRoutePath = true
+	r.POST("/users/{id}", func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString("0123456789") })
+
+	type testCase struct {
+		name   string
+		fields AccessLogFields
+		want   []string
+		absent []string
+	}
+
+	tcs := []testCase{
+		{
+			name:   "default",
+			absent: []string{"bytes", "referer", "route", "content-length"},
+		},
+		{
+			name:   "all",
+			fields: AccessLogFields{BytesWritten: true, Referer: true, Route: true, ContentLength: true},
+			want: []string{
+				`"bytes": 10`, `"referer": "https://example.com/"`, `"route": "/users/{id}"`, `"content-length": 4`,
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &syncBuffer{}
+
+			s := New(cfgstructs.WebServer{}).SetLogger(testLogger(t, buf)).SetAccessLogFields(tc.fields)
+			s.SetRouter(r)
+
+			req := newRequest("POST", "http://test/users/42")
+			req.Header.Set("Referer", "https://example.com/")
+			req.SetBodyString("body")
+			req.Header.SetContentLength(4)
+
+			doRequest(s.httpServer.Handler, req)
+
+			for _, field := range tc.want {
+				if !strings.Contains(buf.String(), field) {
+					t.Errorf("log must contain %s, got:\n%s", field, buf.String())
+				}
+			}
+
+			for _, field := range tc.absent {
+				if strings.Contains(buf.String(), `"`+field+`"`) {
+					t.Errorf("log mustn't contain %s, got:\n%s", field, buf.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package fhserver
+
+import (
+	"net/http"
+	"sync"
+
+	log "github.com/spacetab-io/logs-go/v3"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// logLevels holds runtime levels of loggers by their zap logger, so the level changed
+// by LogLevelHandler applies to copies of the logger, e.g. the one set with SetLogger.
+var logLevels sync.Map
+
+// LogLevel is the data of LogLevelHandler request and response.
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+// LogLevelHandler returns handler reporting (GET) and changing (PUT {"level":"debug"}) the level
+// of logger at runtime. The level is shared by the server logger set with SetLogger(*logger),
+// so access logs of the running server are affected at once. The handler isn't routed by the
+// server, the app routes it behind middlewares guarding it, e.g. BasicAuthMiddleware, which run
+// in the given order like Server.Use ones.
+func LogLevelHandler(logger *log.Logger, middlewares ...Middleware) fasthttp.RequestHandler {
+	level := atomicLevel(logger)
+
+	h := func(ctx *fasthttp.RequestCtx) {
+		switch string(ctx.Method()) {
+		case fasthttp.MethodGet:
+		case fasthttp.MethodPut:
+			var req LogLevel
+			if err := jsonEngine.Unmarshal(ctx.PostBody(), &req); err != nil {
+				ctx.SetStatusCode(http.StatusBadRequest)
+				JSON(ctx, "bad log level request body")
+
+				return
+			}
+
+			var lvl zapcore.Level
+			if err := lvl.UnmarshalText([]byte(req.Level)); err != nil {
+				ctx.SetStatusCode(http.StatusBadRequest)
+				JSON(ctx, err.Error())
+
+				return
+			}
+
+			if prev := level.Level(); prev != lvl {
+				level.SetLevel(lvl)
+				logger.Warn().Str("from", prev.String()).Str("to", lvl.String()).Msg("log level changed")
+			}
+		default:
+			ctx.Response.Header.Set(fasthttp.HeaderAllow, "GET, PUT")
+			ctx.SetStatusCode(http.StatusMethodNotAllowed)
+			JSON(ctx, fasthttp.StatusMessage(http.StatusMethodNotAllowed))
+
+			return
+		}
+
+		JSON(ctx, LogLevel{Level: level.Level().String()})
+	}
+
+	return chainMiddlewares(h, middlewares)
+}
+
+// withAtomicLevel returns logger dropping records below its runtime level, see LogLevelHandler.
+// The initial level is logger.Level.
+func withAtomicLevel(logger log.Logger) log.Logger {
+	if _, ok := logger.Core().(*levelCore); ok {
+		return logger
+	}
+
+	level := atomicLevel(&logger)
+
+	logger.Logger = logger.Logger.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return &levelCore{Core: c, level: level}
+	}))
+
+	return logger
+}
+
+// atomicLevel returns runtime level of logger.
+func atomicLevel(logger *log.Logger) zap.AtomicLevel {
+	if c, ok := logger.Core().(*levelCore); ok {
+		return c.level
+	}
+
+	level, _ := logLevels.LoadOrStore(logger.Logger, zap.NewAtomicLevelAt(logger.Level))
+
+	return level.(zap.AtomicLevel)
+}
+
+// levelCore drops records below the runtime level.
+type levelCore struct {
+	zapcore.Core
+	level zap.AtomicLevel
+}
+
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl) && c.Core.Enabled(lvl)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
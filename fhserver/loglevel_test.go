@@ -0,0 +1,121 @@
+package fhserver
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	t.Parallel()
+
+	buf := &syncBuffer{}
+	logger := testLogger(t, buf)
+
+	s := New(cfgstructs.WebServer{}).SetLogger(logger)
+
+	r := testRouter()
+	r.ANY("/log-level", LogLevelHandler(&logger, BasicAuthMiddleware("admin", "secret")))
+	s.SetRouter(r)
+
+	setLevel := func(body string) *fasthttp.Response {
+		req := newRequest("PUT", "http://test/log-level")
+		req.Header.Set("Authorization", "Basic YWRtaW46c2VjcmV0")
+		req.SetBodyString(body)
+
+		return doRequest(s.httpServer.Handler, req)
+	}
+
+	// debug access logs are written at debug level
+	doRequest(s.httpServer.Handler, newRequest("GET", "http://test/ping"))
+
+	if !strings.Contains(buf.String(), "/ping") {
+		t.Fatalf("access log isn't written at debug level, got:\n%s", buf.String())
+	}
+
+	if resp := setLevel(`{"level":"info"}`); resp.StatusCode() != fasthttp.StatusOK || !strings.Contains(string(resp.Body()), `"level":"info"`) {
+		t.Fatalf("PUT info = %d %s, want 200 with info level", resp.StatusCode(), resp.Body())
+	}
+
+	before := buf.String()
+
+	doRequest(s.httpServer.Handler, newRequest("GET", "http://test/ping"))
+
+	if logged := strings.TrimPrefix(buf.String(), before); strings.Contains(logged, "/ping") {
+		t.Errorf("debug access log is written at info level:\n%s", logged)
+	}
+
+	// errors are still logged
+	doRequest(s.httpServer.Handler, newRequest("GET", "http://test/missing"))
+
+	if logged := strings.TrimPrefix(buf.String(), before); !strings.Contains(logged, "/missing") {
+		t.Errorf("warn access log isn't written at info level:\n%s", logged)
+	}
+
+	req := newRequest("GET", "http://test/log-level")
+	req.Header.Set("Authorization", "Basic YWRtaW46c2VjcmV0")
+
+	if resp := doRequest(s.httpServer.Handler, req); !strings.Contains(string(resp.Body()), `"level":"info"`) {
+		t.Errorf("GET = %s, want info level", resp.Body())
+	}
+
+	setLevel(`{"level":"debug"}`)
+	before = buf.String()
+
+	doRequest(s.httpServer.Handler, newRequest("GET", "http://test/ping"))
+
+	if logged := strings.TrimPrefix(buf.String(), before); !strings.Contains(logged, "/ping") {
+		t.Errorf("access log isn't written after switching back to debug:\n%s", logged)
+	}
+
+	tcs := []struct {
+		name string
+		req  func() *fasthttp.Response
+		want int
+	}{
+		{name: "unknown level", req: func() *fasthttp.Response { return setLevel(`{"level":"loud"}`) }, want: 400},
+		{name: "bad body", req: func() *fasthttp.Response { return setLevel(`level`) }, want: 400},
+		{name: "no credentials", req: func() *fasthttp.Response {
+			return doRequest(s.httpServer.Handler, newRequest("PUT", "http://test/log-level"))
+		}, want: 401},
+		{name: "wrong method", req: func() *fasthttp.Response {
+			req := newRequest("DELETE", "http://test/log-level")
+			req.Header.Set("Authorization", "Basic YWRtaW46c2VjcmV0")
+
+			return doRequest(s.httpServer.Handler, req)
+		}, want: 405},
+	}
+
+	for _, tc := range tcs {
+		if resp := tc.req(); resp.StatusCode() != tc.want {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.want)
+		}
+	}
+}
+
+func TestLogLevelHandler_middlewareOrder(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+
+	marker := func(name string) Middleware {
+		return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+			return func(ctx *fasthttp.RequestCtx) {
+				got = append(got, name)
+				next(ctx)
+			}
+		}
+	}
+
+	logger := testLogger(t, nil)
+	h := LogLevelHandler(&logger, marker("first"), marker("second"))
+
+	doRequest(h, newRequest("GET", "http://test/log-level"))
+
+	if want := []string{"first", "second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("middlewares order = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package fhserver
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+)
+
+// defaultRetryAfter is the Retry-After value (in seconds) sent with 503 responses.
+const defaultRetryAfter = 60
+
+type maintenance struct {
+	on      bool
+	message string
+	exempt  map[string]struct{}
+}
+
+// SetMaintenance switches maintenance mode. While it's on every request except exempt paths
+// is answered with 503 and message in the standard JSON error envelope.
+// Safe to call concurrently with serving.
+func (s *Server) SetMaintenance(on bool, message string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := s.loadMaintenance()
+	s.maintenance.Store(&maintenance{on: on, message: message, exempt: m.exempt})
+
+	return s
+}
+
+// SetMaintenanceExemptPaths sets request paths (e.g. /health) served normally in maintenance mode.
+func (s *Server) SetMaintenanceExemptPaths(paths ...string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exempt := make(map[string]struct{}, len(paths))
+	for _, p := range paths {
+		exempt[p] = struct{}{}
+	}
+
+	m := s.loadMaintenance()
+	s.maintenance.Store(&maintenance{on: m.on, message: m.message, exempt: exempt})
+
+	return s
+}
+
+// InMaintenance reports whether maintenance mode is on.
+func (s *Server) InMaintenance() bool {
+	return s.loadMaintenance().on
+}
+
+func (s *Server) loadMaintenance() *maintenance {
+	if m, ok := s.maintenance.Load().(*maintenance); ok {
+		return m
+	}
+
+	return &maintenance{}
+}
+
+// maintenanceMiddleware short-circuits requests before routing while maintenance mode is on.
+func (s *Server) maintenanceMiddleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		m := s.loadMaintenance()
+		if !m.on {
+			next(ctx)
+
+			return
+		}
+
+		if _, ok := m.exempt[string(ctx.Path())]; ok {
+			next(ctx)
+
+			return
+		}
+
+		msg := m.message
+		if msg == "" {
+			msg = http.StatusText(http.StatusServiceUnavailable)
+		}
+
+		ctx.Response.Header.Set("Retry-After", strconv.Itoa(defaultRetryAfter))
+		ctx.SetStatusCode(http.StatusServiceUnavailable)
+		JSON(ctx, msg)
+	}
+}
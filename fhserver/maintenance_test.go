@@ -0,0 +1,105 @@
+package fhserver
+
+import (
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestServer_SetMaintenance(t *testing.T) {
+	t.Parallel()
+
+	r := testRouter()
+	r.GET("/health", func(ctx *fasthttp.RequestCtx) { JSON(ctx, "ok") })
+
+	s := testServer(t, cfgstructs.WebServer{}).SetMaintenanceExemptPaths("/health")
+	s.SetRouter(r)
+
+	client := serveInmemory(t, s)
+
+	get := func(path string) *fasthttp.Response {
+		t.Helper()
+
+		resp := &fasthttp.Response{}
+		if err := client.Do(newRequest("GET", "http://test"+path), resp); err != nil {
+			t.Fatalf("request error: %v", err)
+		}
+
+		return resp
+	}
+
+	if resp := get("/ping"); resp.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("maintenance off: status code = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+	}
+
+	s.SetMaintenance(true, "database migration")
+
+	if !s.InMaintenance() {
+		t.Error("InMaintenance must be true")
+	}
+
+	resp := get("/ping")
+	if resp.StatusCode() != fasthttp.StatusServiceUnavailable {
+		t.Errorf("maintenance on: status code = %d, want %d", resp.StatusCode(), fasthttp.StatusServiceUnavailable)
+	}
+
+	if len(resp.Header.Peek("Retry-After")) == 0 {
+		t.Error("Retry-After header must be set")
+	}
+
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(resp.Body(), &body); err != nil {
+		t.Fatalf("json.Unmarshal error: %v, body: %s", err, resp.Body())
+	}
+
+	if body.Error.Message != "database migration" {
+		t.Errorf("error.message = %q, want %q", body.Error.Message, "database migration")
+	}
+
+	if resp := get("/missing"); resp.StatusCode() != fasthttp.StatusServiceUnavailable {
+		t.Errorf("unknown route: status code = %d, want %d", resp.StatusCode(), fasthttp.StatusServiceUnavailable)
+	}
+
+	if resp := get("/health"); resp.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("exempt path: status code = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+	}
+
+	s.SetMaintenance(false, "")
+
+	if resp := get("/ping"); resp.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("maintenance off again: status code = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+	}
+}
+
+func TestServer_SetMaintenance_concurrent(t *testing.T) {
+	t.Parallel()
+
+	s := testServer(t, cfgstructs.WebServer{})
+	s.SetRouter(router.New())
+
+	client := serveInmemory(t, s)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			s.SetMaintenance(i%2 == 0, "")
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		if err := client.Do(newRequest("GET", "http://test/"), &fasthttp.Response{}); err != nil {
+			t.Fatalf("request error: %v", err)
+		}
+	}
+
+	<-done
+}
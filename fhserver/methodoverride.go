@@ -0,0 +1,60 @@
+package fhserver
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+)
+
+// HeaderMethodOverride is a header tunneling the real request method through POST.
+const HeaderMethodOverride = "X-HTTP-Method-Override"
+
+// userValueOriginalMethod holds request method replaced by MethodOverride.
+const userValueOriginalMethod = "fhserver.originalMethod"
+
+// MethodOverride returns middleware replacing method of POST requests with PUT, PATCH or DELETE
+// given in X-HTTP-Method-Override header before routing. Requests overriding other methods
+// or with other target methods are answered with 400. The original method is written to the
+// access log as "original-method". It's disabled unless added with Use.
+func MethodOverride() Middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			override := bytes.ToUpper(bytes.TrimSpace(ctx.Request.Header.Peek(HeaderMethodOverride)))
+			if len(override) == 0 {
+				next(ctx)
+
+				return
+			}
+
+			if !ctx.IsPost() || !methodOverridable(override) {
+				ctx.SetStatusCode(http.StatusBadRequest)
+				JSON(ctx, "method override from "+string(ctx.Method())+" to "+string(override)+" is not allowed")
+
+				return
+			}
+
+			ctx.SetUserValue(userValueOriginalMethod, string(ctx.Method()))
+			ctx.Request.Header.SetMethodBytes(override)
+
+			next(ctx)
+		}
+	}
+}
+
+// OriginalMethod returns request method replaced by MethodOverride or empty string.
+func OriginalMethod(ctx *fasthttp.RequestCtx) string {
+	method, _ := ctx.UserValue(userValueOriginalMethod).(string)
+
+	return method
+}
+
+// methodOverridable reports whether POST may be overridden with method.
+func methodOverridable(method []byte) bool {
+	switch string(method) {
+	case fasthttp.MethodPut, fasthttp.MethodPatch, fasthttp.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,70 @@
+package fhserver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestMethodOverride(t *testing.T) {
+	t.Parallel()
+
+	echo := func(ctx *fasthttp.RequestCtx) { ctx.SetBody(ctx.Method()) }
+
+	r := router.New()
+	r.POST("/items", echo)
+	r.PUT("/items", echo)
+	r.PATCH("/items", echo)
+	r.DELETE("/items", echo)
+	r.GET("/items", echo)
+
+	buf := &syncBuffer{}
+
+	s := New(cfgstructs.WebServer{}).SetLogger(testLogger(t, buf)).Use(MethodOverride())
+	s.SetRouter(r)
+
+	disabled := testServer(t, cfgstructs.WebServer{})
+	disabled.SetRouter(r)
+
+	tcs := []struct {
+		name     string
+		handler  fasthttp.RequestHandler
+		method   string
+		override string
+		want     int
+		wantBody string
+	}{
+		{name: "put", handler: s.httpServer.Handler, method: "POST", override: "PUT", want: 200, wantBody: "PUT"},
+		{name: "patch lower case", handler: s.httpServer.Handler, method: "POST", override: "patch", want: 200, wantBody: "PATCH"},
+		{name: "delete", handler: s.httpServer.Handler, method: "POST", override: "DELETE", want: 200, wantBody: "DELETE"},
+		{name: "no header", handler: s.httpServer.Handler, method: "POST", want: 200, wantBody: "POST"},
+		{name: "get to delete", handler: s.httpServer.Handler, method: "GET", override: "DELETE", want: 400},
+		{name: "post to get", handler: s.httpServer.Handler, method: "POST", override: "GET", want: 400},
+		{name: "disabled by default", handler: disabled.httpServer.Handler, method: "POST", override: "DELETE", want: 200, wantBody: "POST"},
+	}
+
+	for _, tc := range tcs {
+		req := newRequest(tc.method, "http://test/items")
+		if tc.override != "" {
+			req.Header.Set(HeaderMethodOverride, tc.override)
+		}
+
+		resp := doRequest(tc.handler, req)
+
+		if resp.StatusCode() != tc.want {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.want)
+		}
+
+		if tc.wantBody != "" && string(resp.Body()) != tc.wantBody {
+			t.Errorf("%s: body = %q, want %q", tc.name, resp.Body(), tc.wantBody)
+		}
+	}
+
+	logs := buf.String()
+	if !strings.Contains(logs, `"original-method": "POST"`) || !strings.Contains(logs, `"method": "DELETE"`) {
+		t.Errorf("access log doesn't contain original method, got:\n%s", logs)
+	}
+}
@@ -0,0 +1,29 @@
+package fhserver
+
+import "github.com/valyala/fasthttp"
+
+// Middleware wraps request handler.
+type Middleware func(next fasthttp.RequestHandler) fasthttp.RequestHandler
+
+// Use appends user middlewares composed by SetRouter, so it must be called before SetRouter.
+// User middlewares are executed in registration order after built-in panic recovery, logging and CORS
+// handling and before compression, maintenance mode check and routing.
+func (s *Server) Use(mw ...Middleware) *Server {
+	s.middlewares = append(s.middlewares, mw...)
+
+	return s
+}
+
+// useMiddlewares wraps h with user middlewares, the first registered one is executed first.
+func (s *Server) useMiddlewares(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return chainMiddlewares(h, s.middlewares)
+}
+
+// chainMiddlewares wraps h with middlewares, the first one is executed first.
+func chainMiddlewares(h fasthttp.RequestHandler, middlewares []Middleware) fasthttp.RequestHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+
+	return h
+}
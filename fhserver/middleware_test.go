@@ -0,0 +1,61 @@
+package fhserver
+
+import (
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestServer_Use(t *testing.T) {
+	t.Parallel()
+
+	marker := func(name string) Middleware {
+		return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+			return func(ctx *fasthttp.RequestCtx) {
+				ctx.Response.Header.Add("X-Order", name)
+				next(ctx)
+			}
+		}
+	}
+
+	r := router.New()
+	r.GET("/ping", func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Add("X-Order", "handler")
+	})
+	r.GET("/panic", func(ctx *fasthttp.RequestCtx) {
+		panic("boom")
+	})
+
+	s := testServer(t, cfgstructs.WebServer{}).Use(marker("first"), marker("second")).Use(marker("third"))
+	s.SetRouter(r)
+
+	resp := doRequest(s.httpServer.Handler, newRequest("GET", "http://test/ping"))
+
+	var got []string
+
+	resp.Header.VisitAll(func(key, value []byte) {
+		if string(key) == "X-Order" {
+			got = append(got, string(value))
+		}
+	})
+
+	want := []string{"first", "second", "third", "handler"}
+	if len(got) != len(want) {
+		t.Fatalf("X-Order = %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("X-Order = %v, want %v", got, want)
+
+			break
+		}
+	}
+
+	// panics in routes are still recovered
+	if resp := doRequest(s.httpServer.Handler, newRequest("GET", "http://test/panic")); resp.StatusCode() != fasthttp.StatusInternalServerError {
+		t.Errorf("panic status code = %d, want %d", resp.StatusCode(), fasthttp.StatusInternalServerError)
+	}
+}
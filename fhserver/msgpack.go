@@ -0,0 +1,114 @@
+package fhserver
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"github.com/vmihailenco/msgpack"
+)
+
+// ContentTypeMsgPack is Content-Type of MessagePack responses.
+const ContentTypeMsgPack = "application/msgpack"
+
+var msgpackEncoding = responseEncoding{
+	contentType: ContentTypeMsgPack,
+	marshal:     marshalMsgPack,
+	errorBody:   msgpackErrorBody,
+}
+
+// MsgPack makes common response in MessagePack the same way JSON does. The envelope is a map
+// with the same keys as the JSON one, struct fields are named by their json tags (msgpack tags
+// take precedence), so clients may decode both formats into the same structs. Payload types
+// customize encoding with msgpack.CustomEncoder, json.Marshaler isn't used.
+func MsgPack(ctx *fasthttp.RequestCtx, response interface{}) {
+	respond(ctx, response, msgpackEncoding)
+}
+
+// newMsgPackEncoder returns encoder naming fields by json tags with sorted map keys and integers
+// in the most compact format.
+func newMsgPackEncoder(buf *bytes.Buffer) *msgpack.Encoder {
+	return msgpack.NewEncoder(buf).UseJSONTag(true).SortMapKeys(true).UseCompactEncoding(true)
+}
+
+// marshalMsgPack encodes response to MessagePack.
+func marshalMsgPack(obj *Response) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := newMsgPackEncoder(&buf).Encode(obj); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// msgpackErrorBody returns body of the MessagePack error response, unlike marshalMsgPack it can't fail.
+func msgpackErrorBody(msg string, id uuid.UUID) []byte {
+	body := map[string]interface{}{
+		"error": map[string]string{"message": msg},
+	}
+
+	if id != uuid.Nil {
+		body["request_id"] = id.String()
+	}
+
+	var buf bytes.Buffer
+
+	// maps of strings are always encoded
+	_ = newMsgPackEncoder(&buf).Encode(body)
+
+	return buf.Bytes()
+}
+
+// EncodeMsgpack encodes nil data as nil.
+func (nullData) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return enc.EncodeNil()
+}
+
+// EncodeMsgpack transcodes p to MessagePack with integers kept as integers, empty p is encoded
+// as nil.
+func (p PreEncoded) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if len(p) == 0 {
+		return enc.EncodeNil()
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+
+	return enc.Encode(jsonNumbers(v))
+}
+
+// jsonNumbers replaces json.Number values of decoded JSON v with int64, uint64 or float64.
+func jsonNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case stdjson.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return u
+		}
+
+		f, _ := v.Float64()
+
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = jsonNumbers(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = jsonNumbers(v[k])
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,246 @@
+package fhserver
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	errs "github.com/spacetab-io/errors-go"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+	"github.com/vmihailenco/msgpack"
+)
+
+// unmarshalMsgPack decodes MessagePack data into v the way clients do: by json tags of v.
+func unmarshalMsgPack(data []byte, v interface{}) error {
+	return msgpack.NewDecoder(bytes.NewReader(data)).UseJSONTag(true).Decode(v)
+}
+
+func TestMsgPack(t *testing.T) {
+	t.Parallel()
+
+	type order struct {
+		ID     int64    `json:"id"`
+		Title  string   `json:"title"`
+		Price  float64  `json:"price"`
+		Paid   bool     `json:"paid"`
+		Tags   []string `json:"tags"`
+		Parent *int     `json:"parent"`
+	}
+
+	type item struct {
+		Name string `validate:"required"`
+	}
+
+	type envelope struct {
+		Error     *errs.ErrorObject `json:"error"`
+		Data      *order            `json:"data"`
+		RequestID string            `json:"request_id"`
+	}
+
+	type testCase struct {
+		name      string
+		response  interface{}
+		wantCode  int
+		wantData  *order
+		wantError *errs.ErrorObject
+	}
+
+	o := order{ID: -1 << 40, Title: "чай", Price: 1.5, Paid: true, Tags: []string{"a", "b"}}
+	code := errs.ErrorCode("ORDER_EXISTS")
+	validationCode := errs.ErrorCode(pkgErr.CodeValidationFailed)
+
+	tcs := []testCase{
+		{name: "struct", response: o, wantCode: fasthttp.StatusOK, wantData: &o},
+		{
+			name:      "error",
+			response:  pkgErr.WithCode(pkgErr.ErrConflict, string(code)),
+			wantCode:  fasthttp.StatusConflict,
+			wantError: &errs.ErrorObject{Message: "conflict", Code: &code},
+		},
+		{
+			name:     "validation",
+			response: validator.New().Struct(item{}),
+			wantCode: fasthttp.StatusUnprocessableEntity,
+			wantError: &errs.ErrorObject{
+				Message: "validation error",
+				Code:    &validationCode,
+				Validation: map[errs.FieldName][]errs.ValidationError{
+					"Name": {"Свойство `Name` обязательно для заполнения"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+
+		MsgPack(ctx, tc.response)
+
+		if ctx.Response.StatusCode() != tc.wantCode {
+			t.Errorf("%s: status code = %d, want %d", tc.name, ctx.Response.StatusCode(), tc.wantCode)
+		}
+
+		if got := string(ctx.Response.Header.ContentType()); got != ContentTypeMsgPack {
+			t.Errorf("%s: Content-Type = %q, want %q", tc.name, got, ContentTypeMsgPack)
+		}
+
+		var got envelope
+		if err := unmarshalMsgPack(ctx.Response.Body(), &got); err != nil {
+			t.Fatalf("%s: body isn't MessagePack: %v", tc.name, err)
+		}
+
+		want := envelope{Error: tc.wantError, Data: tc.wantData}
+
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+
+		if !bytes.Equal(gotJSON, wantJSON) {
+			t.Errorf("%s: decoded response = %s, want %s", tc.name, gotJSON, wantJSON)
+		}
+	}
+}
+
+func TestMsgPack_preEncoded(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name     string
+		response interface{}
+		wantData interface{}
+	}
+
+	tcs := []testCase{
+		{
+			name:     "pre-encoded",
+			response: PreEncoded(`{"id":-1099511627776,"max":18446744073709551615,"price":1.5,"tags":["a",null]}`),
+			wantData: map[string]interface{}{
+				"id":    int64(-1 << 40),
+				"max":   uint64(math.MaxUint64),
+				"price": 1.5,
+				"tags":  []interface{}{"a", nil},
+			},
+		},
+		{name: "raw message", response: stdjson.RawMessage(`[1,"a"]`), wantData: []interface{}{int64(1), "a"}},
+		{name: "empty", response: PreEncoded(nil), wantData: nil},
+		{name: "always data", response: alwaysData{}, wantData: nil},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+
+		MsgPack(ctx, tc.response)
+
+		var got map[string]interface{}
+		if err := unmarshalMsgPack(ctx.Response.Body(), &got); err != nil {
+			t.Fatalf("%s: body isn't MessagePack: %v", tc.name, err)
+		}
+
+		data, ok := got["data"]
+		if !ok {
+			t.Errorf("%s: decoded response %v has no data", tc.name, got)
+
+			continue
+		}
+
+		if !reflect.DeepEqual(normalizeMsgPack(data), tc.wantData) {
+			t.Errorf("%s: decoded data = %#v, want %#v", tc.name, data, tc.wantData)
+		}
+	}
+
+	// integers are encoded in the most compact format
+	ctx := &fasthttp.RequestCtx{}
+	MsgPack(ctx, PreEncoded(`1`))
+
+	if got, want := ctx.Response.Body(), []byte{0x81, 0xa4, 'd', 'a', 't', 'a', 0x01}; !bytes.Equal(got, want) {
+		t.Errorf("body = % x, want % x", got, want)
+	}
+
+	ctx = &fasthttp.RequestCtx{}
+	MsgPack(ctx, PreEncoded(`{"a":`))
+
+	if ctx.Response.StatusCode() != fasthttp.StatusInternalServerError {
+		t.Errorf("invalid JSON: status code = %d, want %d", ctx.Response.StatusCode(), fasthttp.StatusInternalServerError)
+	}
+}
+
+// normalizeMsgPack converts integers decoded by msgpack into int64 or uint64 like the encoder
+// of PreEncoded produces them.
+func normalizeMsgPack(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int8, int16, int32:
+		return reflect.ValueOf(v).Int()
+	case uint8, uint16, uint32:
+		return int64(reflect.ValueOf(v).Uint())
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeMsgPack(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = normalizeMsgPack(v[k])
+		}
+	}
+
+	return v
+}
+
+func TestMsgPackErrorBody(t *testing.T) {
+	t.Parallel()
+
+	id := uuid.New()
+
+	var body struct {
+		Error     *errs.ErrorObject `json:"error"`
+		RequestID string            `json:"request_id"`
+	}
+
+	if err := unmarshalMsgPack(msgpackErrorBody("broken", id), &body); err != nil {
+		t.Fatalf("msgpackErrorBody isn't MessagePack: %v", err)
+	}
+
+	if body.Error == nil || body.Error.Message != "broken" || body.RequestID != id.String() {
+		t.Errorf("msgpackErrorBody decoded = %+v, want message %q and request ID %s", body, "broken", id)
+	}
+}
+
+func BenchmarkMsgPack(b *testing.B) {
+	type order struct {
+		ID     int64    `json:"id"`
+		Title  string   `json:"title"`
+		Amount int      `json:"amount"`
+		Price  float64  `json:"price"`
+		Paid   bool     `json:"paid"`
+		Tags   []string `json:"tags"`
+	}
+
+	orders := make([]order, 100)
+	for i := range orders {
+		orders[i] = order{ID: int64(i), Title: "order", Amount: i * 10, Price: 9.99, Paid: i%2 == 0, Tags: []string{"new"}}
+	}
+
+	for _, bc := range []struct {
+		name    string
+		respond func(ctx *fasthttp.RequestCtx, response interface{})
+	}{
+		{name: "json", respond: JSON},
+		{name: "msgpack", respond: MsgPack},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			ctx := &fasthttp.RequestCtx{}
+
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				ctx.Response.Reset()
+				bc.respond(ctx, orders)
+			}
+
+			b.ReportMetric(float64(len(ctx.Response.Body())), "bytes/response")
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package fhserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// UserValueClientCertSubject is a user value key holding verified client certificate subject (pkix.Name).
+const UserValueClientCertSubject = "fhserver.clientCertSubject"
+
+// SetMTLS turns on client certificates verification against CA certificates from caPEM.
+// If requireAndVerify is false, client certificate is verified only when given.
+// TLS must be set up before via SetTLS, SetTLSFromPEM or SetTLSConfig.
+func (s *Server) SetMTLS(caPEM []byte, requireAndVerify bool) error {
+	if s.tlsConfig == nil {
+		return pkgErr.ErrTLSNotConfigured
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return pkgErr.ErrBadCAPEM
+	}
+
+	s.tlsConfig.ClientCAs = pool
+	s.tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+
+	if requireAndVerify {
+		s.tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return nil
+}
+
+// ClientCertSubject returns verified client certificate subject if present.
+func ClientCertSubject(ctx *fasthttp.RequestCtx) (pkix.Name, bool) {
+	subject, ok := ctx.UserValue(UserValueClientCertSubject).(pkix.Name)
+
+	return subject, ok
+}
+
+// clientCertMiddleware stores verified client certificate subject in user values.
+func clientCertMiddleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if state := ctx.TLSConnectionState(); state != nil && len(state.VerifiedChains) > 0 {
+			ctx.SetUserValue(UserValueClientCertSubject, state.VerifiedChains[0][0].Subject)
+		}
+
+		next(ctx)
+	}
+}
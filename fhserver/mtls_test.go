@@ -0,0 +1,109 @@
+package fhserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestServer_SetMTLS(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCert(t, "ca", nil, true)
+	serverCert := newTestCert(t, "server", ca, false)
+	validClient := newTestCert(t, "valid-client", ca, false)
+	untrustedCA := newTestCert(t, "untrusted-ca", nil, true)
+	invalidClient := newTestCert(t, "invalid-client", untrustedCA, false)
+
+	s := testServer(t, cfgstructs.WebServer{})
+
+	if err := s.SetMTLS(ca.certPEM, true); err == nil {
+		t.Error("SetMTLS without TLS must return error")
+	}
+
+	if err := s.SetTLSFromPEM(serverCert.certPEM, serverCert.keyPEM); err != nil {
+		t.Fatalf("SetTLSFromPEM error: %v", err)
+	}
+
+	if err := s.SetMTLS([]byte("bad"), true); err == nil {
+		t.Error("SetMTLS with bad CA PEM must return error")
+	}
+
+	if err := s.SetMTLS(ca.certPEM, true); err != nil {
+		t.Fatalf("SetMTLS error: %v", err)
+	}
+
+	r := router.New()
+	r.GET("/cn", func(ctx *fasthttp.RequestCtx) {
+		subject, _ := ClientCertSubject(ctx)
+		ctx.SetBodyString(subject.CommonName)
+	})
+	s.SetRouter(r)
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen error: %v", err)
+	}
+
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() { _ = s.httpServer.Serve(tls.NewListener(ln, s.tlsConfig)) }()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	type testCase struct {
+		name    string
+		cert    *testCert
+		wantErr bool
+	}
+
+	tcs := []testCase{
+		{name: "valid client cert", cert: validClient},
+		{name: "untrusted client cert", cert: invalidClient, wantErr: true},
+		{name: "no client cert", wantErr: true},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+
+			if tc.cert != nil {
+				pair, err := tls.X509KeyPair(tc.cert.certPEM, tc.cert.keyPEM)
+				if err != nil {
+					t.Fatalf("tls.X509KeyPair error: %v", err)
+				}
+
+				cfg.Certificates = []tls.Certificate{pair}
+			}
+
+			client := &fasthttp.Client{TLSConfig: cfg}
+
+			code, body, err := client.GetTimeout(nil, "https://"+ln.Addr().String()+"/cn", time.Second)
+			if tc.wantErr {
+				if err == nil && code == fasthttp.StatusOK {
+					t.Error("request must fail on handshake")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("request error: %v", err)
+			}
+
+			if string(body) != tc.cert.cert.Subject.CommonName {
+				t.Errorf("client CN = %q, want %q", body, tc.cert.cert.Subject.CommonName)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package fhserver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// sniffLen is the number of bytes used by http.DetectContentType.
+const sniffLen = 512
+
+var (
+	formFileType  = reflect.TypeOf((*FormFile)(nil))
+	formFilesType = reflect.TypeOf([]*FormFile(nil))
+)
+
+// MultipartOptions limits files bound by BindMultipart, zero values mean no limits.
+type MultipartOptions struct {
+	// MaxFileSize is the max size of a file
+	MaxFileSize int64
+	// MaxTotalSize is the max total size of bound files
+	MaxTotalSize int64
+	// AllowedTypes are allowed content types of files, e.g. "image/png" or "image/*"
+	AllowedTypes []string
+}
+
+// FormFile is uploaded file bound by BindMultipart. It's valid until the handler returns,
+// then fasthttp removes temporary files of the request.
+type FormFile struct {
+	Filename string
+	Size     int64
+	// ContentType is sniffed from the file content, declared content type isn't trusted
+	ContentType string
+
+	header *multipart.FileHeader
+}
+
+// Open opens the file content.
+func (f *FormFile) Open() (io.ReadCloser, error) {
+	return f.header.Open()
+}
+
+// FormFileError is returned by BindMultipart if file isn't allowed by MultipartOptions.
+// JSON answers it with 413 for too large files and 415 for files of not allowed types.
+type FormFileError struct {
+	Field    string
+	Filename string
+	Err      error
+}
+
+func (e *FormFileError) Error() string {
+	if e.Field == "" {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("file %s of field %s: %v", e.Filename, e.Field, e.Err)
+}
+
+func (e *FormFileError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FormFileError) HTTPStatus() int {
+	switch {
+	case errors.Is(e.Err, pkgErr.ErrFileTooLarge), errors.Is(e.Err, pkgErr.ErrFilesTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(e.Err, pkgErr.ErrUnsupportedFileType):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// BindMultipart sets fields of struct pointed by dst tagged with `form:"name"` to multipart
+// form of the request: *FormFile and []*FormFile fields to uploaded files, other fields to
+// form values like BindQuery sets them to query args.
+//
+// Bound files are checked against opts and *FormFileError is returned for the first file
+// violating them. Then dst is validated with v, nil v skips validation. Malformed forms,
+// conversion and validation errors are returned like BindQuery returns them, so the error
+// is just passed to JSON.
+func BindMultipart(ctx *fasthttp.RequestCtx, v *validator.Validate, dst interface{}, opts MultipartOptions) error {
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		if errors.Is(err, fasthttp.ErrBodyTooLarge) {
+			return &FormFileError{Err: pkgErr.ErrFilesTooLarge}
+		}
+
+		return fmt.Errorf("cannot parse multipart form: %w", err)
+	}
+
+	var total int64
+
+	bindFile := func(name string, header *multipart.FileHeader) (*FormFile, error) {
+		total += header.Size
+
+		f, err := formFile(header, opts, total)
+		if err != nil {
+			return nil, &FormFileError{Field: name, Filename: header.Filename, Err: err}
+		}
+
+		return f, nil
+	}
+
+	return bindParams(v, dst, "form", func(name string) []string {
+		return nonEmpty(form.Value[name])
+	}, func(v reflect.Value, name string) (bool, error) {
+		switch v.Type() {
+		case formFileType:
+			if len(form.File[name]) == 0 {
+				return true, nil
+			}
+
+			f, err := bindFile(name, form.File[name][0])
+			if err != nil {
+				return true, err
+			}
+
+			v.Set(reflect.ValueOf(f))
+
+			return true, nil
+		case formFilesType:
+			files := make([]*FormFile, 0, len(form.File[name]))
+
+			for _, header := range form.File[name] {
+				f, err := bindFile(name, header)
+				if err != nil {
+					return true, err
+				}
+
+				files = append(files, f)
+			}
+
+			if len(files) > 0 {
+				v.Set(reflect.ValueOf(files))
+			}
+
+			return true, nil
+		default:
+			return false, nil
+		}
+	})
+}
+
+// formFile checks file of header against opts, total is the size of bound files including it.
+func formFile(header *multipart.FileHeader, opts MultipartOptions, total int64) (*FormFile, error) {
+	if opts.MaxFileSize > 0 && header.Size > opts.MaxFileSize {
+		return nil, pkgErr.ErrFileTooLarge
+	}
+
+	if opts.MaxTotalSize > 0 && total > opts.MaxTotalSize {
+		return nil, pkgErr.ErrFilesTooLarge
+	}
+
+	contentType, err := sniffContentType(header)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.AllowedTypes) > 0 && !isAllowedType(contentType, opts.AllowedTypes) {
+		return nil, fmt.Errorf("%w %s", pkgErr.ErrUnsupportedFileType, contentType)
+	}
+
+	return &FormFile{Filename: header.Filename, Size: header.Size, ContentType: contentType, header: header}, nil
+}
+
+// sniffContentType returns media type of the file content without parameters.
+func sniffContentType(header *multipart.FileHeader) (string, error) {
+	f, err := header.Open()
+	if err != nil {
+		return "", fmt.Errorf("sniffContentType error: %w", err)
+	}
+
+	defer f.Close()
+
+	buf := make([]byte, sniffLen)
+
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("sniffContentType error: %w", err)
+	}
+
+	contentType := http.DetectContentType(buf[:n])
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+
+	return contentType, nil
+}
+
+// isAllowedType reports whether contentType matches one of allowed media ranges.
+func isAllowedType(contentType string, allowed []string) bool {
+	for _, mediaRange := range allowed {
+		if matchMediaRange(strings.ToLower(mediaRange), contentType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nonEmpty returns non-empty values.
+func nonEmpty(values []string) []string {
+	res := make([]string, 0, len(values))
+
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
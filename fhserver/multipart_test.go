@@ -0,0 +1,314 @@
+package fhserver
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/go-playground/validator/v10"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// pngHeader is the signature of PNG files sniffed as image/png.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+type multipartPart struct {
+	field    string
+	filename string
+	content  []byte
+}
+
+func multipartRequest(t *testing.T, uri string, parts ...multipartPart) *fasthttp.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+
+	w := multipart.NewWriter(&body)
+
+	for _, p := range parts {
+		var (
+			pw  io.Writer
+			err error
+		)
+
+		if p.filename == "" {
+			pw, err = w.CreateFormField(p.field)
+		} else {
+			pw, err = w.CreateFormFile(p.field, p.filename)
+		}
+
+		if err != nil {
+			t.Fatalf("multipart part error: %v", err)
+		}
+
+		_, _ = pw.Write(p.content)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("multipart close error: %v", err)
+	}
+
+	req := newRequest(fasthttp.MethodPost, uri)
+	req.Header.SetContentType(w.FormDataContentType())
+	req.SetBody(body.Bytes())
+
+	return req
+}
+
+type uploadForm struct {
+	Title       string      `form:"title" validate:"required"`
+	Public      bool        `form:"public"`
+	Avatar      *FormFile   `form:"avatar" validate:"required"`
+	Attachments []*FormFile `form:"attachments"`
+}
+
+func TestBindMultipart(t *testing.T) {
+	t.Parallel()
+
+	opts := MultipartOptions{
+		MaxFileSize:  1 << 10,
+		MaxTotalSize: 2 << 10,
+		AllowedTypes: []string{"image/*", "text/plain"},
+	}
+
+	v := validator.New()
+	UseParamFieldNames(v)
+
+	r := router.New()
+	r.POST("/upload", func(ctx *fasthttp.RequestCtx) {
+		var form uploadForm
+		if err := BindMultipart(ctx, v, &form, opts); err != nil {
+			JSON(ctx, err)
+
+			return
+		}
+
+		files := make([]string, 0)
+
+		for _, f := range append([]*FormFile{form.Avatar}, form.Attachments...) {
+			rc, err := f.Open()
+			if err != nil {
+				JSON(ctx, err)
+
+				return
+			}
+
+			b, _ := io.ReadAll(rc)
+			_ = rc.Close()
+
+			files = append(files, f.Filename+" "+f.ContentType+" "+strconv.Itoa(len(b)))
+		}
+
+		JSON(ctx, map[string]interface{}{"title": form.Title, "public": form.Public, "files": files})
+	})
+
+	s := New(cfgstructs.WebServer{}).SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	png := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 292)...)
+	text := bytes.Repeat([]byte("a"), 500)
+
+	type testCase struct {
+		name       string
+		parts      []multipartPart
+		wantStatus int
+		wantBody   string
+	}
+
+	tcs := []testCase{
+		{
+			name: "two files and fields",
+			parts: []multipartPart{
+				{field: "title", content: []byte("photos")},
+				{field: "public", content: []byte("true")},
+				{field: "avatar", filename: "me.png", content: png},
+				{field: "attachments", filename: "notes.txt", content: text},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":{"files":["me.png image/png 300","notes.txt text/plain 500"],"public":true,"title":"photos"}}`,
+		},
+		{
+			name: "oversized file",
+			parts: []multipartPart{
+				{field: "title", content: []byte("photos")},
+				{field: "avatar", filename: "big.png", content: append(png, make([]byte, 1<<10)...)},
+			},
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantBody:   `{"error":{"message":"file big.png of field avatar: file is too large"}`,
+		},
+		{
+			name: "oversized total",
+			parts: []multipartPart{
+				{field: "title", content: []byte("photos")},
+				{field: "avatar", filename: "me.png", content: png},
+				{field: "attachments", filename: "1.txt", content: text},
+				{field: "attachments", filename: "2.txt", content: text},
+				{field: "attachments", filename: "3.txt", content: text},
+				{field: "attachments", filename: "4.txt", content: text},
+			},
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantBody:   `{"error":{"message":"file 4.txt of field attachments: total size of files is too large"}`,
+		},
+		{
+			name: "not allowed type",
+			parts: []multipartPart{
+				{field: "title", content: []byte("photos")},
+				{field: "avatar", filename: "me.png", content: []byte("%PDF-1.4")},
+			},
+			wantStatus: http.StatusUnsupportedMediaType,
+			wantBody:   `{"error":{"message":"file me.png of field avatar: unsupported file type application/pdf"}`,
+		},
+		{
+			name: "invalid value",
+			parts: []multipartPart{
+				{field: "public", content: []byte("sure")},
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":{"message":"invalid parameter public: \"sure\" isn't bool","code":"INVALID_PARAMETER"}`,
+		},
+		{
+			name: "validation",
+			parts: []multipartPart{
+				{field: "attachments", filename: "notes.txt", content: text},
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `{"error":{"message":"validation error","code":"VALIDATION_FAILED","validation":{` +
+				"\"avatar\":[\"Свойство `avatar` обязательно для заполнения\"]," +
+				"\"title\":[\"Свойство `title` обязательно для заполнения\"]}}",
+		},
+	}
+
+	for _, tc := range tcs {
+		resp := doRequest(s.httpServer.Handler, multipartRequest(t, "/upload", tc.parts...))
+
+		if resp.StatusCode() != tc.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tc.name, resp.StatusCode(), tc.wantStatus)
+		}
+
+		if got := string(resp.Body()); !strings.HasPrefix(got, tc.wantBody) {
+			t.Errorf("%s: body = %s, want prefix %s", tc.name, got, tc.wantBody)
+		}
+	}
+
+	req := newRequest(fasthttp.MethodPost, "/upload")
+	req.SetBodyString("title=photos")
+	req.Header.SetContentType("application/x-www-form-urlencoded")
+
+	if resp := doRequest(s.httpServer.Handler, req); resp.StatusCode() != http.StatusBadRequest {
+		t.Errorf("not multipart: status = %d, want %d", resp.StatusCode(), http.StatusBadRequest)
+	}
+}
+
+func TestBindMultipart_tempFiles(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu   sync.Mutex
+		name string
+	)
+
+	r := router.New()
+	r.POST("/upload", func(ctx *fasthttp.RequestCtx) {
+		var form uploadForm
+		if err := BindMultipart(ctx, nil, &form, MultipartOptions{}); err != nil {
+			JSON(ctx, err)
+
+			return
+		}
+
+		rc, err := form.Avatar.Open()
+		if err != nil {
+			JSON(ctx, err)
+
+			return
+		}
+
+		defer rc.Close()
+
+		if f, ok := rc.(*os.File); ok {
+			mu.Lock()
+			name = f.Name()
+			mu.Unlock()
+		}
+
+		JSON(ctx, form.Avatar.Size)
+	})
+
+	// streamed multipart bodies are read into temporary files
+	s := New(cfgstructs.WebServer{}, WithStreamRequestBody(true)).SetLogger(testLogger(t, nil))
+	s.httpServer.DisablePreParseMultipartForm = true
+	s.SetRouter(r)
+
+	c := serveInmemory(t, s)
+
+	req := multipartRequest(t, "http://example.com/upload",
+		multipartPart{field: "title", content: []byte("photos")},
+		multipartPart{field: "avatar", filename: "big.png", content: append(append([]byte{}, pngHeader...), make([]byte, 64<<10)...)},
+	)
+
+	resp := &fasthttp.Response{}
+	if err := c.Do(req, resp); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if got := string(resp.Body()); got != `{"data":65544}` {
+		t.Fatalf("body = %s", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if name == "" {
+		t.Fatal("file isn't stored in temporary file")
+	}
+
+	// the server resets the request once the response is written
+	deadline := time.Now().Add(time.Second)
+
+	for {
+		_, err := os.Stat(name)
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("temporary file %s isn't removed: %v", name, err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFormFileError(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[error]int{
+		pkgErr.ErrFileTooLarge:        http.StatusRequestEntityTooLarge,
+		pkgErr.ErrFilesTooLarge:       http.StatusRequestEntityTooLarge,
+		pkgErr.ErrUnsupportedFileType: http.StatusUnsupportedMediaType,
+		io.ErrUnexpectedEOF:           http.StatusInternalServerError,
+	}
+
+	for err, want := range tcs {
+		e := &FormFileError{Field: "avatar", Filename: "me.png", Err: err}
+
+		if got := e.HTTPStatus(); got != want {
+			t.Errorf("%v: status = %d, want %d", err, got, want)
+		}
+
+		if !strings.HasPrefix(e.Error(), "file me.png of field avatar: ") {
+			t.Errorf("message = %s", e.Error())
+		}
+	}
+}
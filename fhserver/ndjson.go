@@ -0,0 +1,74 @@
+package fhserver
+
+import (
+	"bufio"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ContentTypeNDJSON is Content-Type of newline delimited JSON responses.
+const ContentTypeNDJSON = "application/x-ndjson"
+
+// ndjsonFlushLines is the number of documents sent to the client at once.
+const ndjsonFlushLines = 100
+
+// NDJSON streams documents passed by produce to emit as newline delimited JSON, one document
+// per line, so big collections aren't held in memory. emit returns error once encoding fails
+// or the client is disconnected, produce should return it.
+//
+// produce runs until its first emit while the handler waits, so its error returned before
+// any document is emitted makes common JSON error response. Later errors can't change the
+// status already sent: the stream is terminated and the error is logged with server logger.
+func NDJSON(ctx *fasthttp.RequestCtx, produce func(emit func(v interface{}) error) error) {
+	ctx.SetContentType(ContentTypeNDJSON)
+
+	// request context isn't available while streaming
+	logger := requestLogger(ctx)
+	path := string(ctx.Path())
+
+	// receives producer error returned before the first document, nil once streaming has started
+	started := make(chan error, 1)
+
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		lines := 0
+
+		err := produce(func(v interface{}) error {
+			b, err := jsonEngine.Marshal(v)
+			if err != nil {
+				return err
+			}
+
+			if lines == 0 {
+				started <- nil
+			}
+
+			lines++
+
+			_, _ = w.Write(b)
+
+			if err := w.WriteByte('\n'); err != nil {
+				return err
+			}
+
+			if lines%ndjsonFlushLines == 0 {
+				return w.Flush()
+			}
+
+			return nil
+		})
+
+		if lines == 0 {
+			started <- err
+
+			return
+		}
+
+		if err != nil && logger != nil {
+			logger.Warn().Err(err).Str("path", path).Int("lines", lines).Msg("ndjson stream error")
+		}
+	})
+
+	if err := <-started; err != nil {
+		JSON(ctx, err)
+	}
+}
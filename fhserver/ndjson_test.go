@@ -0,0 +1,153 @@
+package fhserver
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+func TestNDJSON(t *testing.T) {
+	t.Parallel()
+
+	const total = 10000
+
+	type row struct {
+		ID    int    `json:"id"`
+		Title string `json:"title"`
+	}
+
+	errExport := errors.New("export failed")
+
+	r := router.New()
+	r.GET("/rows", func(ctx *fasthttp.RequestCtx) {
+		NDJSON(ctx, func(emit func(v interface{}) error) error {
+			for i := 0; i < total; i++ {
+				if err := emit(row{ID: i, Title: "row"}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+	r.GET("/missing", func(ctx *fasthttp.RequestCtx) {
+		NDJSON(ctx, func(emit func(v interface{}) error) error {
+			return pkgErr.ErrNotFound
+		})
+	})
+	r.GET("/broken", func(ctx *fasthttp.RequestCtx) {
+		NDJSON(ctx, func(emit func(v interface{}) error) error {
+			for i := 0; i < 3; i++ {
+				if err := emit(row{ID: i}); err != nil {
+					return err
+				}
+			}
+
+			return errExport
+		})
+	})
+
+	buf := &syncBuffer{}
+
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Compress: true}
+	cfg.Timeouts.Shutdown = time.Second
+
+	s := New(cfg, WithCompressMinSize(0)).SetLogger(testLogger(t, buf))
+	s.SetRouter(r)
+
+	go func() { _ = s.Run(nil) }()
+
+	t.Cleanup(func() { _ = s.Stop() })
+
+	<-s.Ready()
+
+	base := "http://" + s.Addr().String()
+
+	// transport asks for gzip and decompresses the response
+	resp, err := http.Get(base + "/rows")
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status code = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); ct != ContentTypeNDJSON {
+		t.Errorf("Content-Type = %q, want %q", ct, ContentTypeNDJSON)
+	}
+
+	if !resp.Uncompressed {
+		t.Error("response isn't compressed")
+	}
+
+	lines := 0
+	sc := bufio.NewScanner(resp.Body)
+
+	for sc.Scan() {
+		var got row
+		if err := json.Unmarshal(sc.Bytes(), &got); err != nil {
+			t.Fatalf("line %d isn't JSON: %v", lines, err)
+		}
+
+		if got.ID != lines {
+			t.Fatalf("line %d has id %d", lines, got.ID)
+		}
+
+		lines++
+	}
+
+	if err := sc.Err(); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	if lines != total {
+		t.Errorf("got %d lines, want %d", lines, total)
+	}
+
+	// error before the first document makes error response
+	resp, err = http.Get(base + "/missing")
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("missing: status code = %d, Content-Type = %q, want %d and application/json",
+			resp.StatusCode, resp.Header.Get("Content-Type"), http.StatusNotFound)
+	}
+
+	// later error terminates the stream
+	resp, err = http.Get(base + "/broken")
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	sc = bufio.NewScanner(resp.Body)
+	lines = 0
+
+	for sc.Scan() {
+		lines++
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || lines != 3 {
+		t.Errorf("broken: status code = %d, lines = %d, want %d and 3", resp.StatusCode, lines, http.StatusOK)
+	}
+
+	if !strings.Contains(buf.String(), "ndjson stream error") || !strings.Contains(buf.String(), errExport.Error()) {
+		t.Errorf("stream error isn't logged, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,134 @@
+package fhserver
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// userValueNegotiationRequired marks requests answered with 406 by Negotiate if Accept header
+// has no supported type, see WithNegotiationRequired.
+const userValueNegotiationRequired = "fhserver.negotiationRequired"
+
+// negotiated response formats in order of preference on equal quality
+var negotiatedTypes = []struct {
+	mediaType string
+	respond   func(ctx *fasthttp.RequestCtx, response interface{})
+}{
+	{mediaType: "application/json", respond: JSON},
+	{mediaType: "application/xml", respond: XML},
+	{mediaType: "text/xml", respond: XML},
+	{mediaType: ContentTypeMsgPack, respond: MsgPack},
+	{mediaType: "application/x-msgpack", respond: MsgPack},
+}
+
+// WithNegotiationRequired makes Negotiate answer 406 Not Acceptable to requests whose Accept header
+// has no supported type instead of falling back to JSON. Requests without Accept header
+// are still answered with JSON.
+func WithNegotiationRequired(enabled bool) Option {
+	return func(s *Server) {
+		s.negotiationRequired = enabled
+	}
+}
+
+// Negotiate makes common response in the format chosen by the request Accept header: JSON,
+// XML or MessagePack, JSON by default. See WithNegotiationRequired for unsupported types.
+// Vary: Accept is added, so caches don't mix the formats up.
+func Negotiate(ctx *fasthttp.RequestCtx, response interface{}) {
+	addVary(&ctx.Response.Header, fasthttp.HeaderAccept)
+
+	accept := string(ctx.Request.Header.Peek(fasthttp.HeaderAccept))
+	if strings.TrimSpace(accept) == "" {
+		JSON(ctx, response)
+
+		return
+	}
+
+	if respond := negotiate(accept); respond != nil {
+		respond(ctx, response)
+
+		return
+	}
+
+	if ctx.UserValue(userValueNegotiationRequired) != nil {
+		ctx.SetStatusCode(http.StatusNotAcceptable)
+		JSON(ctx, fasthttp.StatusMessage(http.StatusNotAcceptable))
+
+		return
+	}
+
+	JSON(ctx, response)
+}
+
+// negotiate returns response function of the most preferred supported type of Accept header,
+// nil if there is none.
+func negotiate(accept string) func(ctx *fasthttp.RequestCtx, response interface{}) {
+	var (
+		best     func(ctx *fasthttp.RequestCtx, response interface{})
+		bestQ    float64
+		bestRank int
+	)
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, q := parseMediaRange(part)
+		if q <= 0 {
+			continue
+		}
+
+		for rank, t := range negotiatedTypes {
+			if !matchMediaRange(mediaType, t.mediaType) {
+				continue
+			}
+
+			if best == nil || q > bestQ || (q == bestQ && rank < bestRank) {
+				best, bestQ, bestRank = t.respond, q, rank
+			}
+
+			break
+		}
+	}
+
+	return best
+}
+
+// parseMediaRange returns lower case media range of Accept header part and its quality.
+func parseMediaRange(part string) (mediaType string, q float64) {
+	params := strings.Split(part, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(params[0]))
+	q = 1
+
+	for _, p := range params[1:] {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) != "q" {
+			continue
+		}
+
+		if f, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64); err == nil {
+			q = f
+		}
+	}
+
+	return mediaType, q
+}
+
+// matchMediaRange reports whether media range (possibly with wildcards) matches mediaType.
+func matchMediaRange(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+
+	prefix := strings.TrimSuffix(mediaRange, "*")
+
+	return prefix != mediaRange && strings.HasSuffix(prefix, "/") && strings.HasPrefix(mediaType, prefix)
+}
+
+// negotiationRequiredMiddleware marks requests for Negotiate, see WithNegotiationRequired.
+func negotiationRequiredMiddleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.SetUserValue(userValueNegotiationRequired, true)
+
+		next(ctx)
+	}
+}
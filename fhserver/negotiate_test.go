@@ -0,0 +1,65 @@
+package fhserver
+
+import (
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name            string
+		accept          string
+		required        bool
+		wantCode        int
+		wantContentType string
+	}
+
+	tcs := []testCase{
+		{name: "no accept", wantCode: 200, wantContentType: "application/json"},
+		{name: "json", accept: "application/json", wantCode: 200, wantContentType: "application/json"},
+		{name: "xml", accept: "application/xml", wantCode: 200, wantContentType: ContentTypeXML},
+		{name: "text xml", accept: "text/xml", wantCode: 200, wantContentType: ContentTypeXML},
+		{name: "quality", accept: "application/json;q=0.5, application/xml", wantCode: 200, wantContentType: ContentTypeXML},
+		{name: "excluded", accept: "application/xml;q=0, */*;q=0.1", wantCode: 200, wantContentType: "application/json"},
+		{name: "msgpack", accept: "application/msgpack, application/json;q=0.9", wantCode: 200, wantContentType: ContentTypeMsgPack},
+		{name: "wildcard", accept: "*/*", wantCode: 200, wantContentType: "application/json"},
+		{name: "text wildcard", accept: "text/html, text/*;q=0.8", wantCode: 200, wantContentType: ContentTypeXML},
+		{name: "unsupported fallback", accept: "text/html", wantCode: 200, wantContentType: "application/json"},
+		{name: "unsupported required", accept: "text/html", required: true, wantCode: 406, wantContentType: "application/json"},
+		{name: "no accept required", required: true, wantCode: 200, wantContentType: "application/json"},
+	}
+
+	r := router.New()
+	r.GET("/orders", func(ctx *fasthttp.RequestCtx) {
+		Negotiate(ctx, map[string]int{"total": 1})
+	})
+
+	for _, tc := range tcs {
+		s := New(cfgstructs.WebServer{}, WithNegotiationRequired(tc.required)).SetLogger(testLogger(t, nil))
+		s.SetRouter(r)
+
+		req := newRequest("GET", "/orders")
+		if tc.accept != "" {
+			req.Header.Set(fasthttp.HeaderAccept, tc.accept)
+		}
+
+		resp := doRequest(s.httpServer.Handler, req)
+
+		if resp.StatusCode() != tc.wantCode {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.wantCode)
+		}
+
+		if got := string(resp.Header.ContentType()); got != tc.wantContentType {
+			t.Errorf("%s: Content-Type = %q, want %q", tc.name, got, tc.wantContentType)
+		}
+
+		if got := string(resp.Header.Peek(fasthttp.HeaderVary)); got != fasthttp.HeaderAccept {
+			t.Errorf("%s: Vary = %q, want %q", tc.name, got, fasthttp.HeaderAccept)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package fhserver
+
+import "time"
+
+// Option configures the server in New: fasthttp server settings, built-in middlewares and
+// response behaviour.
+type Option func(s *Server)
+
+// WithReadBufferSize sets per-connection buffer size for requests' reading.
+// This also limits the maximum header size.
+func WithReadBufferSize(n int) Option {
+	return func(s *Server) {
+		s.httpServer.ReadBufferSize = n
+	}
+}
+
+// WithWriteBufferSize sets per-connection buffer size for responses' writing.
+func WithWriteBufferSize(n int) Option {
+	return func(s *Server) {
+		s.httpServer.WriteBufferSize = n
+	}
+}
+
+// WithMaxRequestBodySize sets maximum request body size.
+func WithMaxRequestBodySize(n int) Option {
+	return func(s *Server) {
+		s.httpServer.MaxRequestBodySize = n
+	}
+}
+
+// WithConcurrency sets the maximum number of concurrent connections the server may serve.
+func WithConcurrency(n int) Option {
+	return func(s *Server) {
+		s.httpServer.Concurrency = n
+	}
+}
+
+// WithTCPKeepalive turns on TCP keep-alive on accepted TCP connections, so connections
+// of half-dead clients (e.g. behind NAT) are detected and don't delay graceful shutdown.
+func WithTCPKeepalive(enabled bool) Option {
+	return func(s *Server) {
+		s.httpServer.TCPKeepalive = enabled
+	}
+}
+
+// WithReduceMemoryUsage aggressively reduces memory usage at the cost of higher CPU usage.
+func WithReduceMemoryUsage(enabled bool) Option {
+	return func(s *Server) {
+		s.httpServer.ReduceMemoryUsage = enabled
+	}
+}
+
+// WithStreamRequestBody turns on request body streaming. Request body bigger than
+// MaxRequestBodySize is not rejected but streamed, use RequestBodyReader to read it.
+func WithStreamRequestBody(enabled bool) Option {
+	return func(s *Server) {
+		s.httpServer.StreamRequestBody = enabled
+	}
+}
+
+// WithTCPKeepalivePeriod sets the period between TCP keep-alive probes turned on with WithTCPKeepalive.
+// OS default is used if not set.
+func WithTCPKeepalivePeriod(d time.Duration) Option {
+	return func(s *Server) {
+		s.httpServer.TCPKeepalivePeriod = d
+	}
+}
+
+// WithMaxConnAge closes keep-alive connections older than d after the current response, so clients
+// reconnect periodically and are rebalanced between instances. Idle connections are closed
+// by the server idle timeout.
+func WithMaxConnAge(d time.Duration) Option {
+	return func(s *Server) {
+		s.maxConnAge = d
+	}
+}
+
+// WithDisableKeepalive closes the connection after each response (Connection: close).
+// Graceful shutdown never re-enables keep-alive disabled this way.
+func WithDisableKeepalive(disabled bool) Option {
+	return func(s *Server) {
+		s.httpServer.DisableKeepalive = disabled
+	}
+}
+
+// WithMaxIdleWorkerDuration sets the maximum time an idle worker goroutine is kept alive.
+func WithMaxIdleWorkerDuration(d time.Duration) Option {
+	return func(s *Server) {
+		s.httpServer.MaxIdleWorkerDuration = d
+	}
+}
@@ -0,0 +1,133 @@
+package fhserver
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestNew_options(t *testing.T) {
+	t.Parallel()
+
+	s := New(cfgstructs.WebServer{},
+		WithReadBufferSize(1024),
+		WithWriteBufferSize(2048),
+		WithMaxRequestBodySize(16),
+		WithConcurrency(10),
+		WithTCPKeepalive(true),
+		WithReduceMemoryUsage(true),
+		WithTCPKeepalivePeriod(time.Minute),
+		WithDisableKeepalive(true),
+		WithMaxIdleWorkerDuration(time.Second),
+	)
+
+	srv := &s.httpServer
+	if srv.ReadBufferSize != 1024 || srv.WriteBufferSize != 2048 || srv.MaxRequestBodySize != 16 ||
+		srv.Concurrency != 10 || !srv.TCPKeepalive || !srv.ReduceMemoryUsage ||
+		srv.TCPKeepalivePeriod != time.Minute || !srv.DisableKeepalive || srv.MaxIdleWorkerDuration != time.Second {
+		t.Error("options are not applied")
+	}
+}
+
+func TestNew_limits(t *testing.T) {
+	t.Parallel()
+
+	s := New(cfgstructs.WebServer{}, WithReadBufferSize(1024), WithMaxRequestBodySize(16)).
+		SetLogger(testLogger(t, nil))
+	s.SetRouter(testRouter())
+
+	client := serveInmemory(t, s)
+
+	type testCase struct {
+		name string
+		req  func() *fasthttp.Request
+		want int
+	}
+
+	tcs := []testCase{
+		{name: "large header", want: fasthttp.StatusRequestHeaderFieldsTooLarge, req: func() *fasthttp.Request {
+			req := newRequest("GET", "http://test/ping")
+			req.Header.Set("X-Big", strings.Repeat("a", 2048))
+
+			return req
+		}},
+		{name: "large body", want: fasthttp.StatusRequestEntityTooLarge, req: func() *fasthttp.Request {
+			req := newRequest("POST", "http://test/ping")
+			req.SetBody(bytes.Repeat([]byte("a"), 32))
+
+			return req
+		}},
+		{name: "within limits", want: fasthttp.StatusOK, req: func() *fasthttp.Request {
+			return newRequest("GET", "http://test/ping")
+		}},
+	}
+
+	for _, tc := range tcs {
+		resp := &fasthttp.Response{}
+		if err := client.Do(tc.req(), resp); err != nil {
+			t.Fatalf("%s: request error: %v", tc.name, err)
+		}
+
+		if resp.StatusCode() != tc.want {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.want)
+		}
+	}
+}
+
+func TestWithDisableKeepalive(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name     string
+		disabled bool
+		want     bool
+	}
+
+	tcs := []testCase{
+		{name: "enabled", disabled: false, want: false},
+		{name: "disabled", disabled: true, want: true},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := New(cfgstructs.WebServer{}, WithDisableKeepalive(tc.disabled)).SetLogger(testLogger(t, nil))
+			s.SetRouter(testRouter())
+
+			client := serveInmemory(t, s)
+
+			resp := &fasthttp.Response{}
+			if err := client.Do(newRequest("GET", "http://test/ping"), resp); err != nil {
+				t.Fatalf("request error: %v", err)
+			}
+
+			if got := resp.ConnectionClose(); got != tc.want {
+				t.Errorf("Connection: close = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWithMaxConnAge(t *testing.T) {
+	t.Parallel()
+
+	for _, age := range []time.Duration{0, time.Nanosecond} {
+		s := New(cfgstructs.WebServer{}, WithMaxConnAge(age)).SetLogger(testLogger(t, nil))
+		s.SetRouter(testRouter())
+
+		resp := &fasthttp.Response{}
+		if err := serveInmemory(t, s).Do(newRequest("GET", "http://test/ping"), resp); err != nil {
+			t.Fatalf("request error: %v", err)
+		}
+
+		if got, want := resp.ConnectionClose(), age > 0; got != want {
+			t.Errorf("max age %s: connection close = %v, want %v", age, got, want)
+		}
+	}
+}
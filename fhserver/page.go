@@ -0,0 +1,81 @@
+package fhserver
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// PageMeta is pagination metadata of list responses, see JSONPage.
+type PageMeta struct {
+	Total      int64  `json:"total" xml:"total"`
+	Page       int    `json:"page,omitempty" xml:"page,omitempty"`
+	PerPage    int    `json:"per_page,omitempty" xml:"per_page,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty" xml:"next_cursor,omitempty"`
+	// BaseURL is URL of the list, if set Link header with next and prev page URLs is added.
+	BaseURL string `json:"-" xml:"-"`
+}
+
+// pageResponse is list response payload with pagination metadata.
+type pageResponse struct {
+	items interface{}
+	meta  PageMeta
+}
+
+// JSONPage makes common response in json with items as data and page as meta. If page BaseURL
+// is set, RFC 5988 Link header refers to the next and previous pages: by page and per_page
+// query arguments or, for cursor pagination, by cursor argument set to NextCursor.
+func JSONPage(ctx *fasthttp.RequestCtx, items interface{}, page PageMeta) {
+	if link := page.link(); link != "" {
+		ctx.Response.Header.Set(fasthttp.HeaderLink, link)
+	}
+
+	JSON(ctx, pageResponse{items: items, meta: page})
+}
+
+// link returns Link header value of the page, empty if BaseURL isn't set or has no neighbour pages.
+func (p PageMeta) link() string {
+	if p.BaseURL == "" {
+		return ""
+	}
+
+	base, err := url.Parse(p.BaseURL)
+	if err != nil {
+		return ""
+	}
+
+	links := make([]string, 0, 2)
+
+	switch {
+	case p.NextCursor != "":
+		links = append(links, pageLink(base, "next", map[string]string{"cursor": p.NextCursor}))
+	case p.Page > 0 && p.PerPage > 0 && int64(p.Page)*int64(p.PerPage) < p.Total:
+		links = append(links, pageLink(base, "next", p.pageArgs(p.Page+1)))
+	}
+
+	if p.Page > 1 && p.PerPage > 0 {
+		links = append(links, pageLink(base, "prev", p.pageArgs(p.Page-1)))
+	}
+
+	return strings.Join(links, ", ")
+}
+
+func (p PageMeta) pageArgs(page int) map[string]string {
+	return map[string]string{"page": strconv.Itoa(page), "per_page": strconv.Itoa(p.PerPage)}
+}
+
+// pageLink returns Link header entry of base URL with query args replaced by args.
+func pageLink(base *url.URL, rel string, args map[string]string) string {
+	u := *base
+	q := u.Query()
+
+	for k, v := range args {
+		q.Set(k, v)
+	}
+
+	u.RawQuery = q.Encode()
+
+	return "<" + u.String() + `>; rel="` + rel + `"`
+}
@@ -0,0 +1,83 @@
+package fhserver
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestJSONPage(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name     string
+		page     PageMeta
+		wantBody string
+		wantLink string
+	}
+
+	const base = "https://api.example.com/orders?status=new"
+
+	tcs := []testCase{
+		{
+			name:     "without base URL",
+			page:     PageMeta{Total: 25, Page: 2, PerPage: 10},
+			wantBody: `{"data":[1,2],"meta":{"total":25,"page":2,"per_page":10}}`,
+		},
+		{
+			name:     "first",
+			page:     PageMeta{Total: 25, Page: 1, PerPage: 10, BaseURL: base},
+			wantBody: `{"data":[1,2],"meta":{"total":25,"page":1,"per_page":10}}`,
+			wantLink: `<https://api.example.com/orders?page=2&per_page=10&status=new>; rel="next"`,
+		},
+		{
+			name:     "middle",
+			page:     PageMeta{Total: 25, Page: 2, PerPage: 10, BaseURL: base},
+			wantBody: `{"data":[1,2],"meta":{"total":25,"page":2,"per_page":10}}`,
+			wantLink: `<https://api.example.com/orders?page=3&per_page=10&status=new>; rel="next", ` +
+				`<https://api.example.com/orders?page=1&per_page=10&status=new>; rel="prev"`,
+		},
+		{
+			name:     "last",
+			page:     PageMeta{Total: 25, Page: 3, PerPage: 10, BaseURL: base},
+			wantBody: `{"data":[1,2],"meta":{"total":25,"page":3,"per_page":10}}`,
+			wantLink: `<https://api.example.com/orders?page=2&per_page=10&status=new>; rel="prev"`,
+		},
+		{
+			name:     "cursor",
+			page:     PageMeta{Total: 25, NextCursor: "b2Zmc2V0PTEw", BaseURL: base},
+			wantBody: `{"data":[1,2],"meta":{"total":25,"next_cursor":"b2Zmc2V0PTEw"}}`,
+			wantLink: `<https://api.example.com/orders?cursor=b2Zmc2V0PTEw&status=new>; rel="next"`,
+		},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+
+		JSONPage(ctx, []int{1, 2}, tc.page)
+
+		if ctx.Response.StatusCode() != fasthttp.StatusOK {
+			t.Errorf("%s: status code = %d, want %d", tc.name, ctx.Response.StatusCode(), fasthttp.StatusOK)
+		}
+
+		if got := string(ctx.Response.Body()); got != tc.wantBody {
+			t.Errorf("%s: body = %s, want %s", tc.name, got, tc.wantBody)
+		}
+
+		if got := string(ctx.Response.Header.Peek(fasthttp.HeaderLink)); got != tc.wantLink {
+			t.Errorf("%s: Link = %q, want %q", tc.name, got, tc.wantLink)
+		}
+	}
+}
+
+func TestJSON_noMeta(t *testing.T) {
+	t.Parallel()
+
+	ctx := &fasthttp.RequestCtx{}
+
+	JSON(ctx, []int{1})
+
+	if got, want := string(ctx.Response.Body()), `{"data":[1]}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
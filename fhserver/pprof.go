@@ -0,0 +1,47 @@
+package fhserver
+
+import (
+	"net/http/pprof"
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+const defaultPprofPrefix = "/debug/pprof"
+
+// RegisterPprof adds net/http/pprof handlers to r under prefix ("/debug/pprof" if empty).
+// Handlers are wrapped with middlewares, e.g. BasicAuthMiddleware, executed in the given order.
+// Profiling endpoints are never registered implicitly.
+func RegisterPprof(r *router.Router, prefix string, middlewares ...Middleware) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		prefix = defaultPprofPrefix
+	}
+
+	special := map[string]fasthttp.RequestHandler{
+		"cmdline": fasthttpadaptor.NewFastHTTPHandlerFunc(pprof.Cmdline),
+		"profile": fasthttpadaptor.NewFastHTTPHandlerFunc(pprof.Profile),
+		"symbol":  fasthttpadaptor.NewFastHTTPHandlerFunc(pprof.Symbol),
+		"trace":   fasthttpadaptor.NewFastHTTPHandlerFunc(pprof.Trace),
+	}
+
+	// pprof.Index serves named profiles only under /debug/pprof/, so they are routed explicitly
+	profile := chainMiddlewares(func(ctx *fasthttp.RequestCtx) {
+		name, _ := ctx.UserValue("name").(string)
+		if h, ok := special[name]; ok {
+			h(ctx)
+
+			return
+		}
+
+		fasthttpadaptor.NewFastHTTPHandler(pprof.Handler(name))(ctx)
+	}, middlewares)
+
+	index := chainMiddlewares(fasthttpadaptor.NewFastHTTPHandlerFunc(pprof.Index), middlewares)
+
+	r.GET(prefix+"/", index)
+	r.GET(prefix+"/{name}", profile)
+	r.POST(prefix+"/{name}", profile)
+}
@@ -0,0 +1,90 @@
+package fhserver
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRegisterPprof(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name        string
+		prefix      string
+		middlewares []Middleware
+		uri         string
+		auth        string
+		want        int
+	}
+
+	basicAuth := BasicAuthMiddleware("admin", "secret")
+	credentials := "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:secret"))
+
+	tcs := []testCase{
+		{name: "heap", uri: "/debug/pprof/heap", want: fasthttp.StatusOK},
+		{name: "index", uri: "/debug/pprof/", want: fasthttp.StatusOK},
+		{name: "custom prefix", prefix: "/internal/pprof/", uri: "/internal/pprof/goroutine", want: fasthttp.StatusOK},
+		{name: "unknown profile", uri: "/debug/pprof/unknown", want: fasthttp.StatusNotFound},
+		{
+			name: "no credentials", uri: "/debug/pprof/heap", want: fasthttp.StatusUnauthorized,
+			middlewares: []Middleware{basicAuth},
+		},
+		{
+			name: "with credentials", uri: "/debug/pprof/heap", auth: credentials, want: fasthttp.StatusOK,
+			middlewares: []Middleware{basicAuth},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := router.New()
+			RegisterPprof(r, tc.prefix, tc.middlewares...)
+
+			req := newRequest("GET", "http://test"+tc.uri)
+			if tc.auth != "" {
+				req.Header.Set("Authorization", tc.auth)
+			}
+
+			resp := doRequest(r.Handler, req)
+
+			if resp.StatusCode() != tc.want {
+				t.Fatalf("status code = %d, want %d", resp.StatusCode(), tc.want)
+			}
+
+			if tc.want == fasthttp.StatusOK && len(resp.Body()) == 0 {
+				t.Error("profile body is empty")
+			}
+		})
+	}
+}
+
+func TestRegisterPprof_middlewareOrder(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+
+	marker := func(name string) Middleware {
+		return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+			return func(ctx *fasthttp.RequestCtx) {
+				got = append(got, name)
+				next(ctx)
+			}
+		}
+	}
+
+	r := router.New()
+	RegisterPprof(r, "", marker("first"), marker("second"))
+
+	doRequest(r.Handler, newRequest("GET", "http://test/debug/pprof/heap"))
+
+	if want := []string{"first", "second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("middlewares order = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package fhserver
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// userValueDebug marks requests whose responses are checked in debug mode, see WithDebug.
+const userValueDebug = "fhserver.debug"
+
+// PreEncoded is JSON data already serialized by the handler, e.g. taken from a cache or another
+// service. JSON embeds it into the data field as is instead of encoding it as a string like
+// []byte, json.RawMessage data is embedded the same way. Other formats get it as text.
+type PreEncoded []byte
+
+// WithDebug turns on debug mode checks which are too costly for production: pre-encoded JSON
+// data (see PreEncoded) is validated and invalid one makes 500 error response instead of
+// malformed response body.
+func WithDebug(enabled bool) Option {
+	return func(s *Server) {
+		s.debug = enabled
+	}
+}
+
+// debugMiddleware marks requests for response helpers, see WithDebug.
+func debugMiddleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.SetUserValue(userValueDebug, true)
+
+		next(ctx)
+	}
+}
+
+// validatePreEncoded returns error if p isn't valid JSON in debug mode.
+func validatePreEncoded(ctx *fasthttp.RequestCtx, p PreEncoded) error {
+	if ctx.UserValue(userValueDebug) == nil || len(p) == 0 || stdjson.Valid(p) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %v", pkgErr.ErrServerError, pkgErr.ErrInvalidPreEncoded)
+}
+
+// MarshalJSON returns p as is, empty p is encoded as null.
+func (p PreEncoded) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+
+	return p, nil
+}
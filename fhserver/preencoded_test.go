@@ -0,0 +1,104 @@
+package fhserver
+
+import (
+	stdjson "encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestJSON_preEncoded(t *testing.T) {
+	t.Parallel()
+
+	// whitespace, key order and escapes are kept byte for byte
+	raw := `{ "b": [1, 2.50, "é"],
+	"a": null }`
+
+	type testCase struct {
+		name     string
+		response interface{}
+		wantBody string
+	}
+
+	tcs := []testCase{
+		{name: "pre-encoded", response: PreEncoded(raw), wantBody: `{"data":` + raw + `}`},
+		{name: "raw message", response: stdjson.RawMessage(raw), wantBody: `{"data":` + raw + `}`},
+		{name: "scalar", response: PreEncoded(`"text"`), wantBody: `{"data":"text"}`},
+		{name: "empty", response: PreEncoded(nil), wantBody: `{"data":null}`},
+		{name: "bytes", response: []byte(`{"a":1}`), wantBody: `{"data":"{\"a\":1}"}`},
+		{name: "nested", response: map[string]PreEncoded{"a": PreEncoded(`[1, 2]`)}, wantBody: `{"data":{"a":[1, 2]}}`},
+		// invalid JSON isn't checked out of debug mode
+		{name: "invalid", response: PreEncoded(`{"a":`), wantBody: `{"data":{"a":}`},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+		JSON(ctx, tc.response)
+
+		if ctx.Response.StatusCode() != http.StatusOK {
+			t.Errorf("%s: status = %d, want %d", tc.name, ctx.Response.StatusCode(), http.StatusOK)
+		}
+
+		if got := string(ctx.Response.Body()); got != tc.wantBody {
+			t.Errorf("%s: body = %s, want %s", tc.name, got, tc.wantBody)
+		}
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	Created(ctx, PreEncoded(`{"id":1}`))
+
+	if got := string(ctx.Response.Body()); ctx.Response.StatusCode() != http.StatusCreated || got != `{"data":{"id":1}}` {
+		t.Errorf("Created: status = %d, body = %s", ctx.Response.StatusCode(), got)
+	}
+}
+
+func TestWithDebug(t *testing.T) {
+	t.Parallel()
+
+	r := router.New()
+	r.GET("/{raw}", func(ctx *fasthttp.RequestCtx) {
+		JSON(ctx, stdjson.RawMessage(ctx.UserValue("raw").(string)))
+	})
+
+	s := New(cfgstructs.WebServer{}, WithDebug(true)).SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	type testCase struct {
+		raw        string
+		wantStatus int
+		wantBody   string
+	}
+
+	tcs := []testCase{
+		{raw: `[1,2]`, wantStatus: http.StatusOK, wantBody: `{"data":[1,2]}`},
+		{
+			raw:        `{"a":`,
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":{"message":"internal server error: invalid pre-encoded JSON"`,
+		},
+		{
+			raw:        `[1,]`,
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":{"message":"internal server error: invalid pre-encoded JSON"`,
+		},
+	}
+
+	for _, tc := range tcs {
+		req := newRequest(fasthttp.MethodGet, "/")
+		req.URI().SetPath("/" + tc.raw)
+
+		resp := doRequest(s.httpServer.Handler, req)
+
+		if resp.StatusCode() != tc.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tc.raw, resp.StatusCode(), tc.wantStatus)
+		}
+
+		if got := string(resp.Body()); !strings.HasPrefix(got, tc.wantBody) {
+			t.Errorf("%s: body = %s, want prefix %s", tc.raw, got, tc.wantBody)
+		}
+	}
+}
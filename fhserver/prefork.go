@@ -0,0 +1,224 @@
+package fhserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	pkgErr "github.com/spacetab-io/http-go/errors"
+)
+
+const (
+	preforkChildEnv = "FHSERVER_PREFORK_CHILD"
+	// extra time given to children to exit after the shutdown timeout before they are killed
+	preforkKillDelay = time.Second
+)
+
+// newPreforkCmd returns command starting a prefork child process.
+var newPreforkCmd = func() *exec.Cmd {
+	return exec.Command(os.Args[0], os.Args[1:]...) //nolint:gosec
+}
+
+type preforkExit struct {
+	index int
+	err   error
+}
+
+// EnablePrefork makes Run start the given number of child processes (GOMAXPROCS if children <= 0)
+// re-executing the current binary. Every child serves the listen address over SO_REUSEPORT listener,
+// while the parent process only forwards signals to children and waits for them to stop.
+// Prefork can't be used with unix sockets, additional listeners or disabled reuseport.
+func (s *Server) EnablePrefork(children int) *Server {
+	s.prefork = true
+	s.preforkChildren = children
+
+	return s
+}
+
+// IsPreforkChild reports whether current process is a prefork child started by Run.
+func IsPreforkChild() bool {
+	_, ok := preforkChildIndex()
+
+	return ok
+}
+
+func preforkChildIndex() (int, bool) {
+	v, ok := os.LookupEnv(preforkChildEnv)
+	if !ok {
+		return 0, false
+	}
+
+	idx, err := strconv.Atoi(v)
+
+	return idx, err == nil
+}
+
+// checkPrefork returns error if server configuration is incompatible with prefork.
+func (s *Server) checkPrefork() error {
+	addr := s.config.GetListenAddress()
+	_, reusePort := reusePortNetwork(s.network, addr)
+
+	switch {
+	case len(s.extraAddrs) > 0:
+		return fmt.Errorf("%w: multiple listeners", pkgErr.ErrPreforkNotAllowed)
+	case strings.HasPrefix(addr, unixSocketPrefix):
+		return fmt.Errorf("%w: unix socket", pkgErr.ErrPreforkNotAllowed)
+	case s.noReusePort || !reusePort:
+		return fmt.Errorf("%w: reuseport is unavailable for %s", pkgErr.ErrPreforkNotAllowed, addr)
+	}
+
+	return nil
+}
+
+// runPrefork starts children processes and waits for them to exit. SIGINT/SIGTERM and ctx cancellation
+// are turned into SIGTERM for every child, custom signals are forwarded as is.
+func (s *Server) runPrefork(ctx context.Context) error {
+	if err := s.checkPrefork(); err != nil {
+		if s.log != nil {
+			s.log.Error().Err(err).Send()
+		}
+
+		return err
+	}
+
+	n := s.preforkChildren
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+
+	osSignals := make(chan os.Signal, 1)
+	signal.Notify(osSignals, s.signals()...)
+
+	defer signal.Stop(osSignals)
+
+	children := make([]*exec.Cmd, 0, n)
+	exited := make(chan preforkExit, n)
+
+	for i := 0; i < n; i++ {
+		cmd := newPreforkCmd()
+		cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%d", preforkChildEnv, i))
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Start(); err != nil {
+			if s.log != nil {
+				s.log.Error().Err(err).Int("prefork-child", i).Msg("prefork child start error")
+			}
+
+			signalChildren(children, os.Kill)
+
+			for range children {
+				<-exited
+			}
+
+			return pkgErr.WrappedError("Server.Run", "prefork", err)
+		}
+
+		if s.log != nil {
+			s.log.Debug().Int("prefork-child", i).Int("pid", cmd.Process.Pid).Msg("prefork child started")
+		}
+
+		children = append(children, cmd)
+
+		go func(i int, cmd *exec.Cmd) {
+			exited <- preforkExit{index: i, err: cmd.Wait()}
+		}(i, cmd)
+	}
+
+	s.readyOnce.Do(func() { close(s.ready) })
+
+	var (
+		runErr   error
+		stopping bool
+		kill     <-chan time.Time
+	)
+
+	stop := func() {
+		if stopping {
+			return
+		}
+
+		stopping = true
+
+		signalChildren(children, syscall.SIGTERM)
+
+		kill = time.After(s.preShutdownDelay + s.config.GetShutdownTimeout() + preforkKillDelay)
+	}
+
+	ctxDone := ctx.Done()
+
+	for running := len(children); running > 0; {
+		select {
+		case e := <-exited:
+			running--
+
+			if e.err == nil || stopping {
+				if s.log != nil {
+					s.log.Debug().Err(e.err).Int("prefork-child", e.index).Msg("prefork child exited")
+				}
+
+				continue
+			}
+
+			if s.log != nil {
+				s.log.Error().Err(e.err).Int("prefork-child", e.index).Msg("prefork child failed")
+			}
+
+			runErr = pkgErr.WrappedError("Server.Run", "prefork", fmt.Errorf("child %d: %w", e.index, e.err))
+
+			// failure of any child stops the whole server
+			stop()
+		case sig := <-osSignals:
+			if _, ok := s.signalHandlers[sig]; ok {
+				signalChildren(children, sig)
+
+				continue
+			}
+
+			if s.log != nil {
+				s.log.Debug().Str("signal", sig.String()).Msg("Shutdown signal received.")
+			}
+
+			// the second signal makes children skip pre-shutdown delay
+			if stopping {
+				signalChildren(children, syscall.SIGTERM)
+
+				continue
+			}
+
+			stop()
+		case <-ctxDone:
+			ctxDone = nil
+
+			stop()
+		case <-kill:
+			kill = nil
+
+			signalChildren(children, os.Kill)
+
+			if runErr == nil {
+				runErr = fmt.Errorf("%w: prefork children killed", pkgErr.ErrFHServerShutdown)
+			}
+		}
+	}
+
+	if s.log != nil && runErr == nil {
+		s.log.Debug().Msg("Server gracefully stopped.")
+	}
+
+	return runErr
+}
+
+// signalChildren sends sig to every child, exited ones are ignored.
+func signalChildren(children []*exec.Cmd, sig os.Signal) {
+	for _, cmd := range children {
+		_ = cmd.Process.Signal(sig)
+	}
+}
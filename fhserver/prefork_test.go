@@ -0,0 +1,179 @@
+package fhserver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+const preforkTestPortEnv = "FHSERVER_TEST_PREFORK_PORT"
+
+func preforkTestServer(t *testing.T, port int) *Server {
+	t.Helper()
+
+	// empty host is the default config, it's listened on all interfaces
+	cfg := cfgstructs.WebServer{Port: port}
+	cfg.Timeouts.Shutdown = time.Second
+
+	r := router.New()
+	r.GET("/pid", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBodyString(strconv.Itoa(os.Getpid()))
+	})
+
+	s := testServer(t, cfg).EnablePrefork(2)
+	s.SetRouter(r)
+
+	return s
+}
+
+func TestServer_prefork(t *testing.T) {
+	if IsPreforkChild() {
+		// child process started by the parent test below
+		port, err := strconv.Atoi(os.Getenv(preforkTestPortEnv))
+		if err != nil {
+			t.Fatalf("bad port: %v", err)
+		}
+
+		if err := preforkTestServer(t, port).Run(nil); err != nil {
+			t.Fatalf("child Run error: %v", err)
+		}
+
+		return
+	}
+
+	if testing.Short() {
+		t.Skip("prefork test starts child processes")
+	}
+
+	port := freePort(t)
+	t.Setenv(preforkTestPortEnv, strconv.Itoa(port))
+
+	newCmd := newPreforkCmd
+	newPreforkCmd = func() *exec.Cmd {
+		return exec.Command(os.Args[0], "-test.run=^TestServer_prefork$") //nolint:gosec
+	}
+
+	t.Cleanup(func() { newPreforkCmd = newCmd })
+
+	s := preforkTestServer(t, port)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() { done <- s.RunContext(ctx, nil) }()
+
+	<-s.Ready()
+
+	pids := make(map[string]struct{})
+	deadline := time.Now().Add(10 * time.Second)
+
+	for len(pids) < 2 && time.Now().Before(deadline) {
+		req := newRequest("GET", "http://127.0.0.1:"+strconv.Itoa(port)+"/pid")
+		req.SetConnectionClose()
+
+		resp := &fasthttp.Response{}
+		if err := fasthttp.Do(req, resp); err != nil {
+			// children may be not listening yet
+			time.Sleep(10 * time.Millisecond)
+
+			continue
+		}
+
+		pids[string(resp.Body())] = struct{}{}
+	}
+
+	if len(pids) < 2 {
+		t.Errorf("requests were handled by %d children, want 2", len(pids))
+	}
+
+	if _, ok := pids[strconv.Itoa(os.Getpid())]; ok {
+		t.Error("parent process must not handle requests")
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("RunContext error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("RunContext did not return after context cancel")
+	}
+}
+
+func TestServer_prefork_notAllowed(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name  string
+		cfg   testConfig
+		setup func(s *Server)
+	}
+
+	tcs := []testCase{
+		{
+			name:  "multiple listeners",
+			cfg:   testConfig{listenAddress: "127.0.0.1:0"},
+			setup: func(s *Server) { s.AddListener("127.0.0.1:0") },
+		},
+		{
+			name:  "unix socket",
+			cfg:   testConfig{listenAddress: "unix:///tmp/fhserver-prefork.sock"},
+			setup: func(s *Server) {},
+		},
+		{
+			name:  "reuseport disabled",
+			cfg:   testConfig{listenAddress: "127.0.0.1:0"},
+			setup: func(s *Server) { s.DisableReusePort() },
+		},
+		{
+			name:  "dual-stack network",
+			cfg:   testConfig{listenAddress: "localhost:0"},
+			setup: func(s *Server) { s.SetNetwork("tcp") },
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := New(tc.cfg).SetLogger(testLogger(t, nil)).EnablePrefork(2)
+			tc.setup(s)
+			s.SetRouter(testRouter())
+
+			if err := s.Run(nil); !errors.Is(err, pkgErr.ErrPreforkNotAllowed) {
+				t.Errorf("Run error = %v, want %v", err, pkgErr.ErrPreforkNotAllowed)
+			}
+		})
+	}
+}
+
+func TestServer_checkPrefork(t *testing.T) {
+	t.Parallel()
+
+	tcs := []cfgstructs.WebServer{
+		{Port: 8080},
+		{Host: "localhost", Port: 8080},
+		{Host: "0.0.0.0", Port: 8080},
+		{Host: "127.0.0.1", Port: 8080},
+	}
+
+	for _, cfg := range tcs {
+		if err := New(cfg).EnablePrefork(2).checkPrefork(); err != nil {
+			t.Errorf("%s: checkPrefork error = %v", cfg.GetListenAddress(), err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package fhserver
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// preShutdownDelayConfig is implemented by configs that delay graceful shutdown.
+type preShutdownDelayConfig interface {
+	GetPreShutdownDelay() time.Duration
+}
+
+// WithPreShutdownDelay makes the server keep serving for d after SIGINT/SIGTERM or ctx cancellation
+// before the graceful shutdown starts. Readiness fails and keep-alive connections are closed during
+// the delay, so load balancers (e.g. Kubernetes endpoints) stop sending traffic to the instance
+// before its listener is closed. The second signal skips the delay. Stop isn't delayed.
+// Overrides GetPreShutdownDelay of the config.
+func WithPreShutdownDelay(d time.Duration) Option {
+	return func(s *Server) {
+		s.preShutdownDelay = d
+	}
+}
+
+// startPreShutdownDelay turns readiness to failing and returns channel fired when the delay is over.
+// It returns nil if the delay isn't set.
+func (s *Server) startPreShutdownDelay(hostname string) <-chan time.Time {
+	if s.preShutdownDelay <= 0 {
+		return nil
+	}
+
+	atomic.StoreUint32(&s.draining, 1)
+
+	if s.log != nil {
+		s.log.Debug().Str("hostname", hostname).Dur("delay", s.preShutdownDelay).Msg("Pre-shutdown delay started.")
+	}
+
+	return time.After(s.preShutdownDelay)
+}
+
+// isDraining reports whether pre-shutdown delay or graceful shutdown has started.
+func (s *Server) isDraining() bool {
+	return atomic.LoadUint32(&s.draining) != 0 || atomic.LoadUint32(&s.shuttingDown) != 0
+}
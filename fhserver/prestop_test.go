@@ -0,0 +1,157 @@
+package fhserver
+
+import (
+	"context"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+type preShutdownDelayTestConfig struct {
+	cfgstructs.WebServer
+	delay time.Duration
+}
+
+func (c preShutdownDelayTestConfig) GetPreShutdownDelay() time.Duration {
+	return c.delay
+}
+
+// getStatus requests uri over a new connection and returns response status code.
+func getStatus(uri string) (int, error) {
+	req := newRequest("GET", uri)
+	req.SetConnectionClose()
+
+	resp := &fasthttp.Response{}
+	if err := fasthttp.DoTimeout(req, resp, time.Second); err != nil {
+		return 0, err
+	}
+
+	return resp.StatusCode(), nil
+}
+
+func TestServer_preShutdownDelay(t *testing.T) {
+	t.Parallel()
+
+	const delay = 500 * time.Millisecond
+
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Port: 0}
+	cfg.Timeouts.Shutdown = time.Second
+
+	buf := &syncBuffer{}
+
+	r := testRouter()
+
+	s := New(preShutdownDelayTestConfig{WebServer: cfg, delay: delay}).SetLogger(testLogger(t, buf))
+	s.RegisterHealth(r)
+	s.SetRouter(r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() { done <- s.RunContext(ctx, nil) }()
+
+	<-s.Ready()
+
+	base := "http://" + s.Addr().String()
+
+	if code, err := getStatus(base + ReadinessPath); err != nil || code != fasthttp.StatusOK {
+		t.Fatalf("readiness before shutdown = %d, error: %v, want %d", code, err, fasthttp.StatusOK)
+	}
+
+	cancel()
+
+	start := time.Now()
+
+	// readiness fails, while requests are still served during the delay
+	for time.Since(start) < delay-100*time.Millisecond {
+		if code, err := getStatus(base + "/ping"); err != nil || code != fasthttp.StatusOK {
+			t.Fatalf("request during delay = %d, error: %v, want %d", code, err, fasthttp.StatusOK)
+		}
+
+		if code, err := getStatus(base + ReadinessPath); err == nil && code != fasthttp.StatusServiceUnavailable {
+			t.Errorf("readiness during delay = %d, want %d", code, fasthttp.StatusServiceUnavailable)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("RunContext error: %v", err)
+		}
+
+		if d := time.Since(start); d < delay-50*time.Millisecond {
+			t.Errorf("RunContext returned after %s, want at least %s", d, delay)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunContext did not return after the delay")
+	}
+
+	for _, msg := range []string{"Pre-shutdown delay started.", "Pre-shutdown delay finished.", "Server gracefully stopped."} {
+		if !strings.Contains(buf.String(), msg) {
+			t.Errorf("log doesn't contain %q, got:\n%s", msg, buf.String())
+		}
+	}
+}
+
+func TestServer_preShutdownDelay_secondSignal(t *testing.T) {
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Port: 0}
+	cfg.Timeouts.Shutdown = time.Second
+
+	buf := &syncBuffer{}
+
+	r := testRouter()
+
+	s := New(cfg, WithPreShutdownDelay(time.Minute)).SetLogger(testLogger(t, buf))
+	s.RegisterHealth(r)
+	s.SetRouter(r)
+
+	done := make(chan error, 1)
+
+	go func() { done <- s.Run(nil) }()
+
+	<-s.Ready()
+
+	base := "http://" + s.Addr().String()
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("syscall.Kill error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for code, _ := getStatus(base + ReadinessPath); code != fasthttp.StatusServiceUnavailable; code, _ = getStatus(base + ReadinessPath) {
+		if time.Now().After(deadline) {
+			t.Fatal("readiness doesn't fail after the first signal")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if code, err := getStatus(base + "/ping"); err != nil || code != fasthttp.StatusOK {
+		t.Fatalf("request during delay = %d, error: %v, want %d", code, err, fasthttp.StatusOK)
+	}
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("syscall.Kill error: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second signal didn't skip the delay")
+	}
+
+	if !strings.Contains(buf.String(), "Pre-shutdown delay skipped.") {
+		t.Errorf("skipped delay isn't logged, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,97 @@
+package fhserver
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+// ContentTypeProblemJSON is Content-Type of RFC 7807 problem details responses.
+const ContentTypeProblemJSON = "application/problem+json"
+
+// problemTypeBlank is the problem type of problems without additional semantics beyond the status code.
+const problemTypeBlank = "about:blank"
+
+type (
+	// ProblemDetails is RFC 7807 problem details object. Code and RequestID are extension members.
+	ProblemDetails struct {
+		Type          string         `json:"type"`
+		Title         string         `json:"title"`
+		Status        int            `json:"status"`
+		Detail        string         `json:"detail,omitempty"`
+		Instance      string         `json:"instance,omitempty"`
+		Code          string         `json:"code,omitempty"`
+		InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+		RequestID     string         `json:"request_id,omitempty"`
+	}
+	// InvalidParam describes invalid request parameter of the problem.
+	InvalidParam struct {
+		Name   string `json:"name"`
+		Reason string `json:"reason"`
+	}
+)
+
+// Problem makes RFC 7807 error response in application/problem+json. Status code is chosen the same
+// way JSON does for err, instance is the request URI. Validation errors are listed in invalid-params.
+// Nil err makes common JSON response.
+func Problem(ctx *fasthttp.RequestCtx, err error) {
+	if err == nil {
+		JSON(ctx, nil)
+
+		return
+	}
+
+	lang := ResolvedLang(ctx)
+	obj, code := data(ctx, err, lang)
+
+	problem := ProblemDetails{
+		Type:     problemTypeBlank,
+		Title:    http.StatusText(code),
+		Status:   code,
+		Instance: string(ctx.RequestURI()),
+	}
+
+	if msg, ok := obj.Error.Message.(string); ok {
+		problem.Detail = msg
+	}
+
+	if obj.Error.Code != nil {
+		problem.Code = string(*obj.Error.Code)
+	}
+
+	if len(obj.Error.Validation) > 0 {
+		ctx.Response.Header.Set(fasthttp.HeaderContentLanguage, lang)
+	}
+
+	for field, msgs := range obj.Error.Validation {
+		for _, msg := range msgs {
+			problem.InvalidParams = append(problem.InvalidParams, InvalidParam{Name: string(field), Reason: string(msg)})
+		}
+	}
+
+	// map iteration order is random
+	sort.SliceStable(problem.InvalidParams, func(i, j int) bool {
+		return problem.InvalidParams[i].Name < problem.InvalidParams[j].Name
+	})
+
+	if id := RequestID(ctx); id != uuid.Nil {
+		problem.RequestID = id.String()
+		ctx.Response.Header.Set(requestIDKey, problem.RequestID)
+	}
+
+	ctx.SetStatusCode(code)
+	ctx.SetContentType(ContentTypeProblemJSON)
+
+	res, err := jsonEngine.Marshal(problem)
+	if err != nil {
+		ctx.SetStatusCode(http.StatusInternalServerError)
+		ctx.SetContentType(jsonEncoding.contentType)
+		ctx.SetBody(errorBody(err.Error(), RequestID(ctx)))
+
+		return
+	}
+
+	ctx.SetBody(res)
+}
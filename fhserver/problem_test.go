@@ -0,0 +1,99 @@
+package fhserver
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+func TestProblem(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		Name  string `validate:"required"`
+		Email string `validate:"required"`
+	}
+
+	type testCase struct {
+		name string
+		err  error
+		want ProblemDetails
+	}
+
+	tcs := []testCase{
+		{
+			name: "sentinel",
+			err:  pkgErr.ErrNotFound,
+			want: ProblemDetails{Type: "about:blank", Title: "Not Found", Status: 404, Detail: "route not found", Instance: "/orders/1?full=1"},
+		},
+		{
+			name: "wrapped sentinel with code",
+			err:  pkgErr.WithCode(pkgErr.ErrTooManyRequests, "RATE_LIMITED"),
+			want: ProblemDetails{
+				Type: "about:blank", Title: "Too Many Requests", Status: 429, Detail: "too many requests",
+				Instance: "/orders/1?full=1", Code: "RATE_LIMITED",
+			},
+		},
+		{
+			name: "validation",
+			err:  validator.New().Struct(item{}),
+			want: ProblemDetails{
+				Type: "about:blank", Title: "Unprocessable Entity", Status: 422, Detail: "validation error",
+				Instance: "/orders/1?full=1", Code: pkgErr.CodeValidationFailed,
+				InvalidParams: []InvalidParam{
+					{Name: "Email", Reason: "Свойство `Email` обязательно для заполнения"},
+					{Name: "Name", Reason: "Свойство `Name` обязательно для заполнения"},
+				},
+			},
+		},
+		{
+			name: "unknown",
+			err:  errors.New("boom"),
+			want: ProblemDetails{Type: "about:blank", Title: "Bad Request", Status: 400, Detail: "boom", Instance: "/orders/1?full=1"},
+		},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("/orders/1?full=1")
+
+		Problem(ctx, tc.err)
+
+		if ctx.Response.StatusCode() != tc.want.Status {
+			t.Errorf("%s: status code = %d, want %d", tc.name, ctx.Response.StatusCode(), tc.want.Status)
+		}
+
+		if got := string(ctx.Response.Header.ContentType()); got != ContentTypeProblemJSON {
+			t.Errorf("%s: Content-Type = %q, want %q", tc.name, got, ContentTypeProblemJSON)
+		}
+
+		var got ProblemDetails
+		if err := json.Unmarshal(ctx.Response.Body(), &got); err != nil {
+			t.Fatalf("%s: body isn't JSON: %v", tc.name, err)
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: problem = %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestProblem_success(t *testing.T) {
+	t.Parallel()
+
+	ctx := &fasthttp.RequestCtx{}
+
+	Problem(ctx, nil)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("status code = %d, want %d", ctx.Response.StatusCode(), fasthttp.StatusOK)
+	}
+
+	if got := string(ctx.Response.Header.ContentType()); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+}
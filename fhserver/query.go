@@ -0,0 +1,251 @@
+package fhserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+
+	errUnsupportedParamType = fmt.Errorf("%w: unsupported parameter type", pkgErr.ErrServerError)
+)
+
+// QueryParamError is returned by BindQuery and BindMultipart if parameter can't be converted
+// to the field type. JSON answers it with 400 naming the parameter.
+type QueryParamError struct {
+	Param string
+	Err   error
+}
+
+func (e *QueryParamError) Error() string {
+	return fmt.Sprintf("invalid parameter %s: %v", e.Param, e.Err)
+}
+
+func (e *QueryParamError) Unwrap() error {
+	return e.Err
+}
+
+func (e *QueryParamError) HTTPStatus() int {
+	return http.StatusBadRequest
+}
+
+func (e *QueryParamError) ErrorCode() string {
+	return pkgErr.CodeInvalidParameter
+}
+
+// BindQuery sets fields of struct pointed by dst tagged with `query:"name"` to query args of
+// the request, path parameters of the router with the same name take precedence. Supported
+// field types are string, bool, int, uint and float types, time.Time (RFC3339), time.Duration,
+// pointers to them for optional parameters and slices of them filled by repeated and comma
+// separated parameters, e.g. ?id=1&id=2,3. Missing and empty parameters keep field values
+// unless `default:"value"` tag is set.
+//
+// Conversion errors are returned as *QueryParamError. Then dst is validated with v, nil v
+// skips validation, and validator.ValidationErrors are returned (see UseParamFieldNames to name
+// fields by query tags), so the error is just passed to JSON.
+func BindQuery(ctx *fasthttp.RequestCtx, v *validator.Validate, dst interface{}) error {
+	return bindParams(v, dst, "query", func(name string) []string {
+		return paramValues(ctx, name)
+	}, nil)
+}
+
+// bindParams sets fields of struct pointed by dst tagged with tag to parameters returned by
+// values and validates it with v, see BindQuery. bindField sets fields of other types, e.g.
+// files, it returns false for fields it doesn't bind.
+func bindParams(
+	v *validator.Validate,
+	dst interface{},
+	tag string,
+	values func(name string) []string,
+	bindField func(v reflect.Value, name string) (bool, error),
+) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bindParams error: %w: dst isn't a pointer to struct", pkgErr.ErrServerError)
+	}
+
+	rv = rv.Elem()
+	t := rv.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		fld := t.Field(i)
+		if fld.PkgPath != "" {
+			continue
+		}
+
+		name := paramName(fld, tag)
+		if name == "" {
+			continue
+		}
+
+		if bindField != nil {
+			ok, err := bindField(rv.Field(i), name)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				continue
+			}
+		}
+
+		params := values(name)
+		if len(params) == 0 {
+			def, ok := fld.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+
+			params = []string{def}
+		}
+
+		if err := setParam(rv.Field(i), params); err != nil {
+			if errors.Is(err, errUnsupportedParamType) {
+				return fmt.Errorf("bindParams error: field %s: %w", fld.Name, err)
+			}
+
+			return &QueryParamError{Param: name, Err: err}
+		}
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	return v.Struct(dst)
+}
+
+// paramName returns name of fld in tag, empty one if there is none.
+func paramName(fld reflect.StructField, tag string) string {
+	name := strings.SplitN(fld.Tag.Get(tag), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+// paramValues returns non-empty values of path or query parameter name.
+func paramValues(ctx *fasthttp.RequestCtx, name string) []string {
+	if v, ok := ctx.UserValue(name).(string); ok && v != "" {
+		return []string{v}
+	}
+
+	args := ctx.QueryArgs().PeekMulti(name)
+	values := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		if len(arg) > 0 {
+			values = append(values, string(arg))
+		}
+	}
+
+	return values
+}
+
+// setParam sets v to parameter values: slices get all of comma separated values, other types
+// the first one.
+func setParam(v reflect.Value, values []string) error {
+	if v.Kind() != reflect.Slice {
+		return setParamValue(v, values[0])
+	}
+
+	items := reflect.MakeSlice(v.Type(), 0, len(values))
+
+	for _, value := range values {
+		for _, s := range strings.Split(value, ",") {
+			item := reflect.New(v.Type().Elem()).Elem()
+			if err := setParamValue(item, strings.TrimSpace(s)); err != nil {
+				return err
+			}
+
+			items = reflect.Append(items, item)
+		}
+	}
+
+	v.Set(items)
+
+	return nil
+}
+
+// setParamValue converts s to the type of v and sets it.
+func setParamValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		p := reflect.New(v.Type().Elem())
+		if err := setParamValue(p.Elem(), s); err != nil {
+			return err
+		}
+
+		v.Set(p)
+
+		return nil
+	}
+
+	var err error
+
+	switch v.Type() {
+	case timeType:
+		var tm time.Time
+		if tm, err = time.Parse(time.RFC3339, s); err == nil {
+			v.Set(reflect.ValueOf(tm))
+		}
+
+		return paramValueError(s, v.Type(), err)
+	case durationType:
+		var d time.Duration
+		if d, err = time.ParseDuration(s); err == nil {
+			v.SetInt(int64(d))
+		}
+
+		return paramValueError(s, v.Type(), err)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			v.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(s, 10, v.Type().Bits()); err == nil {
+			v.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		if n, err = strconv.ParseUint(s, 10, v.Type().Bits()); err == nil {
+			v.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(s, v.Type().Bits()); err == nil {
+			v.SetFloat(f)
+		}
+	default:
+		return fmt.Errorf("%w %s", errUnsupportedParamType, v.Type())
+	}
+
+	return paramValueError(s, v.Type(), err)
+}
+
+// paramValueError returns error of s conversion to t, nil if err is nil.
+func paramValueError(s string, t reflect.Type, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%q isn't %s", s, t)
+}
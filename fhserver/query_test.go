@@ -0,0 +1,248 @@
+package fhserver
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/go-playground/validator/v10"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+type queryParams struct {
+	Search   string        `query:"q"`
+	Active   bool          `query:"active"`
+	Limit    int           `query:"limit" default:"20"`
+	Offset   int64         `query:"offset"`
+	Port     uint16        `query:"port"`
+	Ratio    float64       `query:"ratio"`
+	Since    time.Time     `query:"since"`
+	Timeout  time.Duration `query:"timeout"`
+	Page     *int          `query:"page"`
+	Archived *bool         `query:"archived"`
+	IDs      []int         `query:"id"`
+	Tags     []string      `query:"tag"`
+	Ignored  string        `query:"-"`
+	NoTag    string
+}
+
+func TestBindQuery(t *testing.T) {
+	t.Parallel()
+
+	page := 2
+	archived := false
+	since := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	type testCase struct {
+		name  string
+		query string
+		want  queryParams
+	}
+
+	tcs := []testCase{
+		{name: "defaults", query: "", want: queryParams{Limit: 20}},
+		{name: "empty values", query: "q=&limit=&page=", want: queryParams{Limit: 20}},
+		{name: "string", query: "q=red+shoes", want: queryParams{Search: "red shoes", Limit: 20}},
+		{name: "bool", query: "active=true", want: queryParams{Active: true, Limit: 20}},
+		{name: "int", query: "limit=5&offset=-10", want: queryParams{Limit: 5, Offset: -10}},
+		{name: "uint", query: "port=8080", want: queryParams{Port: 8080, Limit: 20}},
+		{name: "float", query: "ratio=0.75", want: queryParams{Ratio: 0.75, Limit: 20}},
+		{name: "time", query: "since=2026-10-01T15:00:00%2B03:00", want: queryParams{Since: since, Limit: 20}},
+		{name: "duration", query: "timeout=1m30s", want: queryParams{Timeout: 90 * time.Second, Limit: 20}},
+		{name: "pointers", query: "page=2&archived=false", want: queryParams{Page: &page, Archived: &archived, Limit: 20}},
+		{name: "repeated", query: "id=1&id=2&tag=a", want: queryParams{IDs: []int{1, 2}, Tags: []string{"a"}, Limit: 20}},
+		{name: "comma separated", query: "id=1,2&id=3&tag=a,b", want: queryParams{IDs: []int{1, 2, 3}, Tags: []string{"a", "b"}, Limit: 20}},
+		{name: "first of repeated", query: "limit=1&limit=2", want: queryParams{Limit: 1}},
+		{name: "not tagged", query: "Ignored=x&NoTag=y&-=z", want: queryParams{Limit: 20}},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("/?" + tc.query)
+
+		var got queryParams
+		if err := BindQuery(ctx, nil, &got); err != nil {
+			t.Errorf("%s: BindQuery error: %v", tc.name, err)
+
+			continue
+		}
+
+		if !got.Since.Equal(tc.want.Since) {
+			t.Errorf("%s: Since = %v, want %v", tc.name, got.Since, tc.want.Since)
+		}
+
+		got.Since = tc.want.Since
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: params = %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestBindQuery_errors(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		query     string
+		wantParam string
+		wantMsg   string
+	}
+
+	tcs := []testCase{
+		{query: "limit=ten", wantParam: "limit", wantMsg: `invalid parameter limit: "ten" isn't int`},
+		{query: "port=70000", wantParam: "port", wantMsg: `invalid parameter port: "70000" isn't uint16`},
+		{query: "active=maybe", wantParam: "active", wantMsg: `invalid parameter active: "maybe" isn't bool`},
+		{query: "ratio=1/2", wantParam: "ratio", wantMsg: `invalid parameter ratio: "1/2" isn't float64`},
+		{query: "since=2026-10-01", wantParam: "since", wantMsg: `invalid parameter since: "2026-10-01" isn't time.Time`},
+		{query: "timeout=1", wantParam: "timeout", wantMsg: `invalid parameter timeout: "1" isn't time.Duration`},
+		{query: "page=first", wantParam: "page", wantMsg: `invalid parameter page: "first" isn't int`},
+		{query: "id=1,x", wantParam: "id", wantMsg: `invalid parameter id: "x" isn't int`},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("/?" + tc.query)
+
+		var params queryParams
+
+		err := BindQuery(ctx, nil, &params)
+
+		var paramErr *QueryParamError
+		if !errors.As(err, &paramErr) {
+			t.Errorf("%s: error = %v, want *QueryParamError", tc.query, err)
+
+			continue
+		}
+
+		if paramErr.Param != tc.wantParam || err.Error() != tc.wantMsg {
+			t.Errorf("%s: error = %s (%s), want %s (%s)", tc.query, err, paramErr.Param, tc.wantMsg, tc.wantParam)
+		}
+	}
+
+	var unsupported struct {
+		Values map[string]string `query:"values"`
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/?values=a")
+
+	if err := BindQuery(ctx, nil, &unsupported); !errors.Is(err, pkgErr.ErrServerError) {
+		t.Errorf("unsupported type: error = %v, want ErrServerError", err)
+	}
+
+	if err := BindQuery(ctx, nil, queryParams{}); !errors.Is(err, pkgErr.ErrServerError) {
+		t.Errorf("not a pointer: error = %v, want ErrServerError", err)
+	}
+}
+
+func TestBindQuery_response(t *testing.T) {
+	t.Parallel()
+
+	type orderQuery struct {
+		OrderID int64  `query:"id" validate:"gt=0"`
+		Status  string `query:"status" validate:"omitempty,oneof=new paid"`
+		Limit   int    `query:"limit" default:"10" validate:"lte=100"`
+		Cursor  string `query:"cursor" validate:"omitempty,cursor"`
+	}
+
+	// custom rules and field names of the app validator are used
+	v := validator.New()
+	UseParamFieldNames(v)
+
+	if err := v.RegisterValidation("cursor", func(fl validator.FieldLevel) bool {
+		return strings.HasPrefix(fl.Field().String(), "c_")
+	}); err != nil {
+		t.Fatalf("RegisterValidation error: %v", err)
+	}
+
+	r := router.New()
+	r.GET("/orders/{id}", func(ctx *fasthttp.RequestCtx) {
+		var q orderQuery
+		if err := BindQuery(ctx, v, &q); err != nil {
+			JSON(ctx, err)
+
+			return
+		}
+
+		JSON(ctx, q)
+	})
+
+	s := New(cfgstructs.WebServer{}).SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	type testCase struct {
+		uri        string
+		wantStatus int
+		wantBody   string
+	}
+
+	tcs := []testCase{
+		{
+			uri:        "/orders/7?status=paid&id=8",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":{"OrderID":7,"Status":"paid","Limit":10,"Cursor":""}`,
+		},
+		{
+			uri:        "/orders/seven",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":{"message":"invalid parameter id: \"seven\" isn't int64","code":"INVALID_PARAMETER"}`,
+		},
+		{
+			uri:        "/orders/0?status=lost&limit=500&cursor=x",
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `{"error":{"message":"validation error","code":"VALIDATION_FAILED","validation":{` +
+				"\"cursor\":[\"Ошибка валидации для свойства `cursor` с правилом `cursor`\"]," +
+				"\"id\":[\"Свойство `id` должно содержать более `0` элементов\"]," +
+				"\"limit\":[\"Свойство `limit` должно быть не больше `100`\"]," +
+				"\"status\":[\"Свойство `status` должно быть одним из `new paid`\"]}}",
+		},
+	}
+
+	for _, tc := range tcs {
+		resp := doRequest(s.httpServer.Handler, newRequest(fasthttp.MethodGet, tc.uri))
+
+		if resp.StatusCode() != tc.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tc.uri, resp.StatusCode(), tc.wantStatus)
+		}
+
+		if got := string(resp.Body()); !strings.HasPrefix(got, tc.wantBody) {
+			t.Errorf("%s: body = %s, want prefix %s", tc.uri, got, tc.wantBody)
+		}
+	}
+}
+
+func TestUseParamFieldNames(t *testing.T) {
+	t.Parallel()
+
+	v := validator.New()
+	UseParamFieldNames(v)
+
+	type form struct {
+		Limit  int    `query:"limit,omitempty" validate:"required"`
+		Title  string `form:"title" json:"name" validate:"required"`
+		Name   string `json:"full_name" validate:"required"`
+		Hidden string `query:"-" validate:"required"`
+	}
+
+	var validationErrs validator.ValidationErrors
+	if err := v.Struct(form{}); !errors.As(err, &validationErrs) {
+		t.Fatalf("validation error = %v, want validator.ValidationErrors", err)
+	}
+
+	want := []string{"limit", "title", "full_name", "Hidden"}
+	got := make([]string, 0, len(validationErrs))
+
+	for _, e := range validationErrs {
+		got = append(got, e.Field())
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
@@ -1,16 +1,87 @@
 package fhserver
 
 import (
-	"net/http"
+	"fmt"
+	"runtime/debug"
 
+	"github.com/google/uuid"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	log "github.com/spacetab-io/logs-go/v3"
 	"github.com/valyala/fasthttp"
 )
 
-func recoveryMiddleware(next func(ctx *fasthttp.RequestCtx)) func(ctx *fasthttp.RequestCtx) {
+// PanicHandler is called with recovered panic value and stack trace, e.g. to report it to Sentry.
+type PanicHandler func(ctx *fasthttp.RequestCtx, recovered interface{}, stack []byte)
+
+type panicHook struct {
+	fn     PanicHandler
+	before bool
+}
+
+// SetPanicHandler sets handler called for panics recovered in request handlers.
+// By default it's called after 500 response is written. Must be called before SetRouter.
+func (s *Server) SetPanicHandler(fn PanicHandler) *Server {
+	s.panicHook.fn = fn
+
+	return s
+}
+
+// CallPanicHandlerBeforeResponse makes panic handler be called before 500 response is written,
+// so the handler may write its own response.
+func (s *Server) CallPanicHandlerBeforeResponse() *Server {
+	s.panicHook.before = true
+
+	return s
+}
+
+// userValuePanicRecoverer holds panicRecoverer of the server for routers recovering panics themselves.
+const userValuePanicRecoverer = "fhserver.panicRecoverer"
+
+// panicRecoverer handles panic recovered in request handler.
+type panicRecoverer func(ctx *fasthttp.RequestCtx, recovered interface{}, stack []byte)
+
+// recoveryMiddleware recovers panics answering 500 with a generic message, so panic details
+// don't leak to clients. Panic value and stack trace are logged if logger is set.
+func recoveryMiddleware(next func(ctx *fasthttp.RequestCtx), logger *log.Logger, hook panicHook) func(ctx *fasthttp.RequestCtx) {
+	recoverer := panicRecoverer(func(ctx *fasthttp.RequestCtx, rvr interface{}, stack []byte) {
+		if logger != nil {
+			event := logger.Error().
+				Str("panic", fmt.Sprintf("%v", rvr)).
+				Str("stack", string(stack)).
+				Bytes("method", ctx.Method()).
+				Bytes("path", ctx.RequestURI())
+
+			if id := RequestID(ctx); id != uuid.Nil {
+				event.Str("req_id", id.String())
+			}
+
+			event.Msg("panic recovered")
+		}
+
+		if hook.fn != nil && hook.before {
+			ctx.Response.Reset()
+			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+
+			// handler wrote its own response
+			if ok := callPanicHandler(ctx, hook.fn, rvr, stack, logger); ok && len(ctx.Response.Body()) > 0 {
+				return
+			}
+		}
+
+		ctx.Response.Reset()
+		JSON(ctx, pkgErr.ErrServerError)
+
+		if hook.fn != nil && !hook.before {
+			callPanicHandler(ctx, hook.fn, rvr, stack, logger)
+		}
+	})
+
 	return func(ctx *fasthttp.RequestCtx) {
+		ctx.SetUserValue(userValuePanicRecoverer, recoverer)
+
 		defer func() {
 			if rvr := recover(); rvr != nil {
-				ctx.Error("recover", http.StatusInternalServerError)
+				recoverer(ctx, rvr, debug.Stack())
 			}
 		}()
 
@@ -18,3 +89,33 @@ func recoveryMiddleware(next func(ctx *fasthttp.RequestCtx)) func(ctx *fasthttp.
 		next(ctx)
 	}
 }
+
+// recoverPanic handles panic recovered outside of recoveryMiddleware the same way it does.
+// Without the server it answers 500 only.
+func recoverPanic(ctx *fasthttp.RequestCtx, rvr interface{}, stack []byte) {
+	if recoverer, ok := ctx.UserValue(userValuePanicRecoverer).(panicRecoverer); ok {
+		recoverer(ctx, rvr, stack)
+
+		return
+	}
+
+	ctx.Response.Reset()
+	JSON(ctx, pkgErr.ErrServerError)
+}
+
+// callPanicHandler calls fn recovering its own panic. It returns false if fn panicked.
+func callPanicHandler(ctx *fasthttp.RequestCtx, fn PanicHandler, rvr interface{}, stack []byte, logger *log.Logger) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+
+			if logger != nil {
+				logger.Error().Str("panic", fmt.Sprintf("%v", r)).Msg("panic handler panicked")
+			}
+		}
+	}()
+
+	fn(ctx, rvr, stack)
+
+	return true
+}
@@ -0,0 +1,136 @@
+package fhserver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	r := router.New()
+	r.GET("/panic", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBodyString("partial")
+		panic("secret details")
+	})
+
+	buf := &syncBuffer{}
+
+	s := New(cfgstructs.WebServer{}).SetLogger(testLogger(t, buf))
+	s.SetRouter(r)
+
+	resp := doRequest(s.httpServer.Handler, newRequest("GET", "http://test/panic"))
+
+	if resp.StatusCode() != fasthttp.StatusInternalServerError {
+		t.Errorf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusInternalServerError)
+	}
+
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(resp.Body(), &body); err != nil {
+		t.Fatalf("json.Unmarshal error: %v, body: %s", err, resp.Body())
+	}
+
+	if body.Error.Message != "internal server error" {
+		t.Errorf("error.message = %q, want %q", body.Error.Message, "internal server error")
+	}
+
+	if strings.Contains(string(resp.Body()), "secret") {
+		t.Errorf("panic details leaked to response: %s", resp.Body())
+	}
+
+	logs := buf.String()
+	for _, want := range []string{"panic recovered", "secret details", "recovery_test.go", "/panic", "req_id"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("log must contain %q, got:\n%s", want, logs)
+		}
+	}
+}
+
+func TestServer_SetPanicHandler(t *testing.T) {
+	t.Parallel()
+
+	r := router.New()
+	r.GET("/panic", func(ctx *fasthttp.RequestCtx) { panic("boom") })
+
+	type testCase struct {
+		name     string
+		before   bool
+		handler  func(ctx *fasthttp.RequestCtx)
+		wantBody string
+	}
+
+	tcs := []testCase{
+		{name: "after response", wantBody: "internal server error"},
+		{name: "panicking handler", handler: func(ctx *fasthttp.RequestCtx) { panic("hook boom") }, wantBody: "internal server error"},
+		{name: "before response", before: true, wantBody: "internal server error"},
+		{
+			name: "before response with own body", before: true, wantBody: "custom",
+			handler: func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString("custom") },
+		},
+		{
+			name: "before response panicking", before: true, wantBody: "internal server error",
+			handler: func(ctx *fasthttp.RequestCtx) {
+				ctx.SetBodyString("partial")
+				panic("hook boom")
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				called    bool
+				recovered interface{}
+				stack     []byte
+			)
+
+			s := testServer(t, cfgstructs.WebServer{}).SetPanicHandler(func(ctx *fasthttp.RequestCtx, rvr interface{}, st []byte) {
+				called, recovered, stack = true, rvr, st
+
+				if tc.handler != nil {
+					tc.handler(ctx)
+				}
+			})
+
+			if tc.before {
+				s.CallPanicHandlerBeforeResponse()
+			}
+
+			s.SetRouter(r)
+
+			resp := doRequest(s.httpServer.Handler, newRequest("GET", "http://test/panic"))
+
+			if !called {
+				t.Fatal("panic handler wasn't called")
+			}
+
+			if recovered != "boom" {
+				t.Errorf("recovered = %v, want boom", recovered)
+			}
+
+			if !strings.Contains(string(stack), "recovery_test.go") {
+				t.Errorf("stack must point to the panic, got:\n%s", stack)
+			}
+
+			if resp.StatusCode() != fasthttp.StatusInternalServerError {
+				t.Errorf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusInternalServerError)
+			}
+
+			if !strings.Contains(string(resp.Body()), tc.wantBody) {
+				t.Errorf("body = %s, want %s", resp.Body(), tc.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package fhserver
+
+import (
+	"github.com/google/uuid"
+	"github.com/spacetab-io/configuration-structs-go/v2/contracts"
+	"github.com/valyala/fasthttp"
+)
+
+// requestIDKey is both request ID header name (the same as fhclient sends) and user value key.
+var requestIDKey = contracts.ContextKeyRequestID.String()
+
+// userValueResponseRequestID marks requests whose successful JSON responses carry request ID too.
+const userValueResponseRequestID = "fhserver.responseRequestID"
+
+// responseRequestIDConfig is implemented by configs adding request ID to successful responses.
+type responseRequestIDConfig interface {
+	GetResponseRequestID() bool
+}
+
+// WithResponseRequestID makes successful JSON responses carry request_id like error ones do.
+// Overrides GetResponseRequestID of the config.
+func WithResponseRequestID(enabled bool) Option {
+	return func(s *Server) {
+		s.responseRequestID = enabled
+	}
+}
+
+// RequestID returns ID of the request set by the server, uuid.Nil if there is none.
+// The same value is available as ctx.Value(contracts.ContextKeyRequestID.String()).
+func RequestID(ctx *fasthttp.RequestCtx) uuid.UUID {
+	id, _ := ctx.UserValue(requestIDKey).(uuid.UUID)
+
+	return id
+}
+
+// requestIDMiddleware takes request ID from the request header or generates a new one
+// if the header is absent or isn't a valid UUID, and echoes it in the response header.
+func requestIDMiddleware(next fasthttp.RequestHandler, inResponses bool) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		id, err := uuid.ParseBytes(ctx.Request.Header.Peek(requestIDKey))
+		if err != nil || id == uuid.Nil {
+			id = uuid.New()
+		}
+
+		ctx.SetUserValue(requestIDKey, id)
+
+		if inResponses {
+			ctx.SetUserValue(userValueResponseRequestID, true)
+		}
+
+		next(ctx)
+
+		ctx.Response.Header.Set(requestIDKey, id.String())
+	}
+}
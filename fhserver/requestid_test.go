@@ -0,0 +1,150 @@
+package fhserver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/router"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	t.Parallel()
+
+	incoming := uuid.New()
+
+	type testCase struct {
+		name   string
+		header string
+		keep   bool
+	}
+
+	tcs := []testCase{
+		{name: "valid", header: incoming.String(), keep: true},
+		{name: "invalid", header: "not-a-uuid", keep: false},
+		{name: "absent", header: "", keep: false},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var inHandler uuid.UUID
+
+			r := router.New()
+			r.GET("/fail", func(ctx *fasthttp.RequestCtx) {
+				inHandler = RequestID(ctx)
+
+				ctx.SetStatusCode(fasthttp.StatusConflict)
+				JSON(ctx, errors.New("conflict"))
+			})
+
+			buf := &syncBuffer{}
+
+			s := New(cfgstructs.WebServer{}).SetLogger(testLogger(t, buf))
+			s.SetRouter(r)
+
+			req := newRequest("GET", "http://test/fail")
+			if tc.header != "" {
+				req.Header.Set("RequestID", tc.header)
+			}
+
+			resp := doRequest(s.httpServer.Handler, req)
+
+			got, err := uuid.ParseBytes(resp.Header.Peek("RequestID"))
+			if err != nil || got == uuid.Nil {
+				t.Fatalf("response RequestID header = %q is not a UUID", resp.Header.Peek("RequestID"))
+			}
+
+			if tc.keep && got != incoming {
+				t.Errorf("RequestID = %s, want %s", got, incoming)
+			}
+
+			if inHandler != got {
+				t.Errorf("RequestID in handler = %s, want %s", inHandler, got)
+			}
+
+			var body struct {
+				RequestID string `json:"request_id"`
+			}
+
+			if err := json.Unmarshal(resp.Body(), &body); err != nil {
+				t.Fatalf("json.Unmarshal error: %v, body: %s", err, resp.Body())
+			}
+
+			if body.RequestID != got.String() {
+				t.Errorf("request_id = %q, want %q", body.RequestID, got)
+			}
+
+			if !strings.Contains(buf.String(), got.String()) {
+				t.Errorf("log must contain req_id %s, got:\n%s", got, buf.String())
+			}
+		})
+	}
+}
+
+func TestJSON_requestID(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		Name string `validate:"required"`
+	}
+
+	r := router.New()
+	r.GET("/internal", func(ctx *fasthttp.RequestCtx) {
+		JSON(ctx, []error{errors.New("db is down"), errors.New("cache is down")})
+	})
+	r.GET("/validation", func(ctx *fasthttp.RequestCtx) {
+		JSON(ctx, validator.New().Struct(item{}))
+	})
+	r.GET("/ok", func(ctx *fasthttp.RequestCtx) {
+		JSON(ctx, "ok")
+	})
+
+	type testCase struct {
+		path        string
+		inResponses bool
+		wantCode    int
+		wantID      bool
+	}
+
+	tcs := []testCase{
+		{path: "/internal", wantCode: fasthttp.StatusInternalServerError, wantID: true},
+		{path: "/validation", wantCode: fasthttp.StatusUnprocessableEntity, wantID: true},
+		{path: "/ok", wantCode: fasthttp.StatusOK},
+		{path: "/ok", inResponses: true, wantCode: fasthttp.StatusOK, wantID: true},
+	}
+
+	for _, tc := range tcs {
+		s := New(cfgstructs.WebServer{}, WithResponseRequestID(tc.inResponses)).SetLogger(testLogger(t, nil))
+		s.SetRouter(r)
+
+		resp := doRequest(s.httpServer.Handler, newRequest("GET", tc.path))
+
+		if resp.StatusCode() != tc.wantCode {
+			t.Errorf("%s: status code = %d, want %d", tc.path, resp.StatusCode(), tc.wantCode)
+		}
+
+		var body struct {
+			RequestID string `json:"request_id"`
+		}
+
+		if err := json.Unmarshal(resp.Body(), &body); err != nil {
+			t.Fatalf("%s: json.Unmarshal error: %v, body: %s", tc.path, err, resp.Body())
+		}
+
+		header := string(resp.Header.Peek(requestIDKey))
+
+		switch {
+		case tc.wantID && (body.RequestID == "" || body.RequestID != header):
+			t.Errorf("%s: request_id = %q, want header value %q", tc.path, body.RequestID, header)
+		case !tc.wantID && body.RequestID != "":
+			t.Errorf("%s: request_id = %q, want none", tc.path, body.RequestID)
+		}
+	}
+}
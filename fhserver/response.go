@@ -1,13 +1,15 @@
 package fhserver
 
 import (
+	"context"
 	"database/sql"
+	stdjson "encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/savsgio/gotils/strconv"
 	errs "github.com/spacetab-io/errors-go"
@@ -17,55 +19,264 @@ import (
 
 type (
 	Response struct {
-		Error *errs.ErrorObject `json:"error,omitempty"`
-		Data  interface{}       `json:"data,omitempty"`
+		Error     *errs.ErrorObject `json:"error,omitempty"`
+		Data      interface{}       `json:"data,omitempty"`
+		Meta      interface{}       `json:"meta,omitempty"`
+		RequestID string            `json:"request_id,omitempty"`
 	}
 	validationRule   string
 	errorPattern     string
 	validationErrors map[validationRule]errorPattern
 )
 
+const (
+	// maxStatusCode is the greatest valid HTTP status code.
+	maxStatusCode = 599
+	// statusClientClosedRequest is nginx status of requests cancelled by the client.
+	statusClientClosedRequest = 499
+)
+
 var (
 	json = jsoniter.ConfigCompatibleWithStandardLibrary
 
-	defaultLang            = "ru"
+	defaultLang = "ru"
+	// CommonValidationErrors are validation messages by language and validator tag (rule). Patterns
+	// get field name and rule param (if the pattern has the second "%s"), "ek" pattern of rules
+	// without own messages gets field name and rule. See also RegisterValidationMessages.
 	CommonValidationErrors = map[string]validationErrors{
 		"ru": {
 			"ek":       "Ошибка валидации для свойства `%s` с правилом `%s`",
 			"required": "Свойство `%s` обязательно для заполнения",
 			"gt":       "Свойство `%s` должно содержать более `%s` элементов",
+			"gte":      "Свойство `%s` должно быть не меньше `%s`",
+			"lte":      "Свойство `%s` должно быть не больше `%s`",
+			"min":      "Свойство `%s` должно быть не меньше `%s`",
+			"max":      "Свойство `%s` должно быть не больше `%s`",
+			"len":      "Длина свойства `%s` должна быть равна `%s`",
+			"email":    "Свойство `%s` должно быть email адресом",
+			"oneof":    "Свойство `%s` должно быть одним из `%s`",
+			"uuid":     "Свойство `%s` должно быть UUID",
+			"url":      "Свойство `%s` должно быть URL",
+			"numeric":  "Свойство `%s` должно быть числом",
+			"alphanum": "Свойство `%s` должно содержать только буквы и цифры",
+			"datetime": "Свойство `%s` должно быть датой в формате `%s`",
+		},
+		"en": {
+			"ek":       "Validation error of property `%s` with rule `%s`",
+			"required": "Property `%s` is required",
+			"gt":       "Property `%s` must contain more than `%s` elements",
+			"gte":      "Property `%s` must be at least `%s`",
+			"lte":      "Property `%s` must be at most `%s`",
+			"min":      "Property `%s` must be at least `%s`",
+			"max":      "Property `%s` must be at most `%s`",
+			"len":      "Length of property `%s` must be `%s`",
+			"email":    "Property `%s` must be an email address",
+			"oneof":    "Property `%s` must be one of `%s`",
+			"uuid":     "Property `%s` must be a UUID",
+			"url":      "Property `%s` must be a URL",
+			"numeric":  "Property `%s` must be a number",
+			"alphanum": "Property `%s` must contain only letters and digits",
+			"datetime": "Property `%s` must be a date in `%s` format",
 		},
 	}
 )
 
-// JSON makes common response in json.
+// responseEncoding encodes response envelope in some format.
+type responseEncoding struct {
+	contentType string
+	marshal     func(obj *Response) ([]byte, error)
+	// write encodes response into the body instead of marshal if set
+	write func(ctx *fasthttp.RequestCtx, obj *Response) error
+	// errorBody returns body of the error response, it can't fail
+	errorBody func(msg string, id uuid.UUID) []byte
+}
+
+var jsonEncoding = responseEncoding{
+	contentType: "application/json",
+	marshal: func(obj *Response) ([]byte, error) {
+		return jsonEngine.Marshal(obj)
+	},
+	write:     writeJSON,
+	errorBody: errorBody,
+}
+
+// JSON makes common response in json. Status code is taken from the response payload unless
+// the handler has set a different one before: error status (4xx, 5xx) for error payloads
+// or any other status, e.g. 201, for the rest. Body isn't written for 204 No Content.
+// HEAD requests get the same status and headers with Content-Length of the body, but no body.
+// PreEncoded and json.RawMessage payloads are embedded into data field without re-encoding.
 func JSON(ctx *fasthttp.RequestCtx, response interface{}) {
-	ctx.SetContentType("application/json")
+	respond(ctx, response, jsonEncoding)
+}
+
+// respond makes common response encoded with enc, see JSON.
+func respond(ctx *fasthttp.RequestCtx, response interface{}, enc responseEncoding) {
+	lang := ResolvedLang(ctx)
 
-	lang := getLang(ctx)
+	if _, ok := response.(alwaysData); !ok && ctx.UserValue(userValueAlwaysData) != nil {
+		response = alwaysData{v: response}
+	}
 
 	obj, code := data(ctx, response, lang)
 
-	res, err := json.Marshal(&obj)
-	// We are now no longer need the buffer so we pool it.
-	if err != nil {
-		ctx.SetStatusCode(http.StatusInternalServerError)
-		ctx.SetBody(strconv.S2B(fmt.Sprintf(`{"error":"%v"}`, err.Error())))
+	if obj.Error != nil && len(obj.Error.Validation) > 0 {
+		ctx.Response.Header.Set(fasthttp.HeaderContentLanguage, lang)
 	}
 
-	if ctx.Response.Header.StatusCode() == http.StatusOK {
+	status := ctx.Response.Header.StatusCode()
+
+	switch {
+	case obj.Error != nil && status < http.StatusBadRequest, obj.Error == nil && status == http.StatusOK:
 		ctx.SetStatusCode(code)
+	case obj.Error == nil && status == http.StatusNoContent:
+		NoContent(ctx)
+
+		return
+	}
+
+	ctx.SetContentType(enc.contentType)
+
+	defer omitHeadBody(ctx)
+
+	// error responses carry request ID for correlation with logs, successful ones if asked
+	if id := RequestID(ctx); id != uuid.Nil && (obj.Error != nil || ctx.UserValue(userValueResponseRequestID) != nil) {
+		obj.RequestID = id.String()
+		ctx.Response.Header.Set(requestIDKey, obj.RequestID)
+	}
+
+	if enc.write != nil {
+		if err := enc.write(ctx, &obj); err != nil {
+			ctx.SetStatusCode(http.StatusInternalServerError)
+			ctx.SetBody(enc.errorBody(err.Error(), RequestID(ctx)))
+		}
+
+		return
+	}
+
+	res, err := enc.marshal(&obj)
+	if err != nil {
+		ctx.SetStatusCode(http.StatusInternalServerError)
+		ctx.SetBody(enc.errorBody(err.Error(), RequestID(ctx)))
+
+		return
 	}
 
 	ctx.SetBody(res)
 }
 
+// Created makes 201 Created response with data in json.
+func Created(ctx *fasthttp.RequestCtx, data interface{}) {
+	ctx.SetStatusCode(http.StatusCreated)
+	JSON(ctx, data)
+}
+
+// Accepted makes 202 Accepted response with data in json.
+func Accepted(ctx *fasthttp.RequestCtx, data interface{}) {
+	ctx.SetStatusCode(http.StatusAccepted)
+	JSON(ctx, data)
+}
+
+// NoContent makes 204 No Content response without body and Content-Type.
+func NoContent(ctx *fasthttp.RequestCtx) {
+	ctx.SetStatusCode(http.StatusNoContent)
+	ctx.ResetBody()
+	ctx.Response.Header.Del(fasthttp.HeaderContentType)
+	ctx.Response.Header.SetNoDefaultContentType(true)
+}
+
+// omitHeadBody drops body of response to HEAD request setting Content-Length to its size, so
+// headers and status are the same as GET response ones. Response isn't compressed then.
+func omitHeadBody(ctx *fasthttp.RequestCtx) {
+	if !ctx.IsHead() || ctx.Response.IsBodyStream() {
+		return
+	}
+
+	size := len(ctx.Response.Body())
+
+	skipCompression(ctx)
+	ctx.ResetBody()
+	ctx.Response.Header.SetContentLength(size)
+}
+
+// BadRequest makes 400 Bad Request error response with err message.
+func BadRequest(ctx *fasthttp.RequestCtx, err error) {
+	ctx.SetStatusCode(http.StatusBadRequest)
+	JSON(ctx, err)
+}
+
+// Unauthorized makes 401 Unauthorized error response with msg.
+func Unauthorized(ctx *fasthttp.RequestCtx, msg string) {
+	ctx.SetStatusCode(http.StatusUnauthorized)
+	JSON(ctx, msg)
+}
+
+// Raw writes body as is, without the response envelope, with statusCode and contentType.
+// Zero statusCode keeps the status set by the handler, 204 No Content is written without body.
+// Like other response helpers Raw writes only headers for HEAD requests.
+func Raw(ctx *fasthttp.RequestCtx, statusCode int, contentType string, body []byte) {
+	if statusCode != 0 {
+		ctx.SetStatusCode(statusCode)
+	}
+
+	if ctx.Response.StatusCode() == http.StatusNoContent {
+		NoContent(ctx)
+
+		return
+	}
+
+	ctx.SetContentType(contentType)
+	ctx.SetBody(body)
+	omitHeadBody(ctx)
+}
+
+// RawJSON writes v marshaled to json without the response envelope keeping the status set by
+// the handler, 200 by default. Marshaling failure makes 500 error response in the envelope.
+func RawJSON(ctx *fasthttp.RequestCtx, v interface{}) {
+	res, err := jsonEngine.Marshal(v)
+	if err != nil {
+		Raw(ctx, http.StatusInternalServerError, jsonEncoding.contentType, errorBody(err.Error(), RequestID(ctx)))
+
+		return
+	}
+
+	Raw(ctx, 0, jsonEncoding.contentType, res)
+}
+
+// errorBody returns body of the error response encoded without reflection, so unlike
+// json.Marshal of Response it can't fail.
+func errorBody(msg string, id uuid.UUID) []byte {
+	stream := json.BorrowStream(nil)
+	defer json.ReturnStream(stream)
+
+	stream.WriteObjectStart()
+	stream.WriteObjectField("error")
+	stream.WriteObjectStart()
+	stream.WriteObjectField("message")
+	stream.WriteString(msg)
+	stream.WriteObjectEnd()
+
+	if id != uuid.Nil {
+		stream.WriteMore()
+		stream.WriteObjectField("request_id")
+		stream.WriteString(id.String())
+	}
+
+	stream.WriteObjectEnd()
+
+	return append([]byte(nil), stream.Buffer()...)
+}
+
 func data(ctx *fasthttp.RequestCtx, item interface{}, lang string) (result Response, code int) {
 	errType := errs.ErrorTypeError
 
 	var obj Response
 
 	switch item := item.(type) {
+	case alwaysData:
+		obj, code = data(ctx, item.v, lang)
+		if obj.Error == nil {
+			obj.Data = explicitData(obj.Data)
+		}
 	case []error:
 		errObj := errs.ErrorObject{}
 		code = http.StatusInternalServerError
@@ -83,18 +294,37 @@ func data(ctx *fasthttp.RequestCtx, item interface{}, lang string) (result Respo
 		errObj := errs.ErrorObject{}
 
 		errObj.Message = "validation error"
+		errObj.Code = errorCode(pkgErr.CodeValidationFailed)
 		errObj.Validation = makeErrorsSlice(item, lang)
 		obj.Error = &errObj
 	case error:
 		errObj := errs.ErrorObject{}
 		code, errObj.Message = getErrCode(item)
+
+		var coder pkgErr.Coder
+		if errors.As(item, &coder) {
+			errObj.Code = errorCode(coder.ErrorCode())
+		}
+
 		obj.Error = &errObj
 	case map[string]error:
 		errObj := errs.ErrorObject{}
+		code = http.StatusBadRequest
 
 		msgs := make(map[string]string)
 		for k, e := range item {
-			msgs[k] = e.Error()
+			// the most severe error code is used
+			errCode, msg := getErrCode(e)
+			if errCode > code {
+				code = errCode
+			}
+
+			msgs[k] = msg
+		}
+
+		// status set by the caller wins
+		if sc := ctx.Response.Header.StatusCode(); sc >= http.StatusBadRequest {
+			code = sc
 		}
 
 		errObj.Message = msgs
@@ -109,6 +339,19 @@ func data(ctx *fasthttp.RequestCtx, item interface{}, lang string) (result Respo
 			obj.Data = item
 			code = http.StatusOK
 		}
+	case pageResponse:
+		code = http.StatusOK
+		obj.Data = item.items
+		obj.Meta = item.meta
+	case stdjson.RawMessage:
+		return data(ctx, PreEncoded(item), lang)
+	case PreEncoded:
+		if err := validatePreEncoded(ctx, item); err != nil {
+			return data(ctx, err, lang)
+		}
+
+		code = http.StatusOK
+		obj.Data = item
 	case []byte:
 		if ctx.Response.Header.StatusCode() >= http.StatusBadRequest {
 			errObj := errs.ErrorObject{}
@@ -128,17 +371,19 @@ func data(ctx *fasthttp.RequestCtx, item interface{}, lang string) (result Respo
 	return obj, code
 }
 
-func (ve errorPattern) string() string {
-	return string(ve)
-}
-
-func getLang(c *fasthttp.RequestCtx) string {
-	lang := c.Request.Header.Peek("Content-Language")
-	if len(lang) == 0 {
-		return defaultLang
+// errorCode returns code of the error response, nil for empty code.
+func errorCode(code string) *errs.ErrorCode {
+	if code == "" {
+		return nil
 	}
 
-	return string(lang)
+	c := errs.ErrorCode(code)
+
+	return &c
+}
+
+func (ve errorPattern) string() string {
+	return string(ve)
 }
 
 // validationErrors Формирование массива ошибок.
@@ -152,6 +397,12 @@ func makeErrorsSlice(err validator.ValidationErrors, lang string) map[errs.Field
 			ve[field] = make([]errs.ValidationError, 0)
 		}
 
+		if msg, ok := translateValidationError(e, lang); ok {
+			ve[field] = append(ve[field], errs.ValidationError(msg))
+
+			continue
+		}
+
 		ve[field] = append(
 			ve[field],
 			getErrMessage(validationRule(e.ActualTag()), field, e.Param(), lang),
@@ -165,32 +416,40 @@ func getFieldName(namespace, field string) errs.FieldName {
 	namespace = strings.ReplaceAll(namespace, "]", "")
 	namespace = strings.ReplaceAll(namespace, "[", ".")
 	namespaceSlice := strings.Split(namespace, ".")
-	fieldName := field
+	fieldName := strings.TrimPrefix(field, embeddedFieldPrefix)
 
 	if len(namespaceSlice) > 2 { //nolint: gomnd // жёстко проверяется на наличие более чем 2х элементов слайса
-		fieldName = strings.Join([]string{strings.Join(namespaceSlice[1:len(namespaceSlice)-1], "."), field}, ".")
+		path := make([]string, 0, len(namespaceSlice)-1)
+
+		// embedded structs are flattened, see UseJSONFieldNames
+		for _, name := range namespaceSlice[1 : len(namespaceSlice)-1] {
+			if !strings.HasPrefix(name, embeddedFieldPrefix) {
+				path = append(path, name)
+			}
+		}
+
+		fieldName = strings.Join(append(path, fieldName), ".")
 	}
 
 	return errs.FieldName(fieldName)
 }
 
-func getErrMessage(errorType validationRule, field errs.FieldName, param, lang string) errs.ValidationError {
-	errKey := errorType
-
-	_, ok := CommonValidationErrors[lang][errorType]
-	if !ok {
-		errKey = "ek"
+func getErrCode(err error) (errCode int, msg string) {
+	if status, msg, ok := registeredErrorStatus(err); ok {
+		return status, msg
 	}
 
-	if param != "" && errKey == "ek" {
-		return errs.ValidationError(fmt.Sprintf(CommonValidationErrors[lang][errKey].string(), field, errorType))
-	}
+	msg = err.Error()
 
-	return errs.ValidationError(fmt.Sprintf(CommonValidationErrors[lang][errKey].string(), field))
-}
+	var pm pkgErr.PublicMessager
+	if errors.As(err, &pm) {
+		msg = pm.PublicMessage()
+	}
 
-func getErrCode(err error) (errCode int, msg string) {
-	msg = err.Error()
+	var hs pkgErr.HTTPStatuser
+	if errors.As(err, &hs) && hs.HTTPStatus() >= http.StatusContinue && hs.HTTPStatus() <= maxStatusCode {
+		return hs.HTTPStatus(), msg
+	}
 
 	switch {
 	case errors.Is(err, pkgErr.ErrNotFound):
@@ -206,6 +465,25 @@ func getErrCode(err error) (errCode int, msg string) {
 	case errors.Is(err, sql.ErrNoRows):
 		errCode = http.StatusNotFound
 		msg = pkgErr.ErrRecordNotFound.Error()
+	case errors.Is(err, pkgErr.ErrUnauthorized):
+		errCode = http.StatusUnauthorized
+	case errors.Is(err, pkgErr.ErrForbidden):
+		errCode = http.StatusForbidden
+	case errors.Is(err, pkgErr.ErrRequestTimeout):
+		errCode = http.StatusRequestTimeout
+	case errors.Is(err, pkgErr.ErrTooManyRequests):
+		errCode = http.StatusTooManyRequests
+	case errors.Is(err, pkgErr.ErrUnavailableForLegalReasons):
+		errCode = http.StatusUnavailableForLegalReasons
+	case errors.Is(err, pkgErr.ErrBadGateway):
+		errCode = http.StatusBadGateway
+	case errors.Is(err, pkgErr.ErrServiceUnavailable):
+		errCode = http.StatusServiceUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		errCode = http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		// the client has gone, the status is only logged
+		errCode = statusClientClosedRequest
 	default:
 		errCode = http.StatusBadRequest
 	}
@@ -0,0 +1,623 @@
+package fhserver
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/router"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// errorResponse is the envelope of error responses decoded in tests.
+type errorResponse struct {
+	Error *struct {
+		Message interface{} `json:"message"`
+	} `json:"error"`
+	RequestID string `json:"request_id"`
+}
+
+func TestJSON_marshalError(t *testing.T) {
+	t.Parallel()
+
+	// cyclic values aren't detected by jsoniter, they overflow the stack
+	for name, response := range map[string]interface{}{
+		"channel":  map[string]interface{}{"ch": make(chan int)},
+		"function": []interface{}{func() {}},
+		"infinity": math.Inf(1),
+	} {
+		id := uuid.New()
+
+		resp := doRequest(func(ctx *fasthttp.RequestCtx) {
+			ctx.SetUserValue(requestIDKey, id)
+			JSON(ctx, response)
+		}, newRequest("GET", "/"))
+
+		if resp.StatusCode() != fasthttp.StatusInternalServerError {
+			t.Errorf("%s: status code = %d, want %d", name, resp.StatusCode(), fasthttp.StatusInternalServerError)
+		}
+
+		if got := string(resp.Header.ContentType()); got != "application/json" {
+			t.Errorf("%s: Content-Type = %q, want %q", name, got, "application/json")
+		}
+
+		var body errorResponse
+		if err := json.Unmarshal(resp.Body(), &body); err != nil {
+			t.Fatalf("%s: body %q isn't JSON: %v", name, resp.Body(), err)
+		}
+
+		if body.Error == nil || body.Error.Message == "" {
+			t.Errorf("%s: error message is empty, body %s", name, resp.Body())
+		}
+
+		if body.RequestID != id.String() {
+			t.Errorf("%s: request_id = %q, want %q", name, body.RequestID, id)
+		}
+	}
+}
+
+func TestErrorBody(t *testing.T) {
+	t.Parallel()
+
+	msg := "bad \"value\"\n\x00 <tag> юникод"
+
+	var body errorResponse
+	if err := json.Unmarshal(errorBody(msg, uuid.Nil), &body); err != nil {
+		t.Fatalf("errorBody isn't JSON: %v", err)
+	}
+
+	if body.Error == nil || body.Error.Message != msg {
+		t.Errorf("error message = %v, want %q", body.Error, msg)
+	}
+
+	if got := string(errorBody(msg, uuid.Nil)); strings.Contains(got, "request_id") {
+		t.Errorf("errorBody without request ID = %s, want no request_id", got)
+	}
+}
+
+func TestJSON_errorsMap(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name     string
+		status   int
+		errs     map[string]error
+		wantCode int
+	}
+
+	tcs := []testCase{
+		{
+			name:     "bad request",
+			errs:     map[string]error{"name": errors.New("name is empty"), "age": errors.New("age is negative")},
+			wantCode: fasthttp.StatusBadRequest,
+		},
+		{
+			name:     "most severe code",
+			errs:     map[string]error{"name": errors.New("name is empty"), "user": pkgErr.ErrConflict},
+			wantCode: fasthttp.StatusConflict,
+		},
+		{
+			name:     "status set by caller",
+			status:   fasthttp.StatusUnprocessableEntity,
+			errs:     map[string]error{"name": errors.New("name is empty"), "user": pkgErr.ErrConflict},
+			wantCode: fasthttp.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := doRequest(func(ctx *fasthttp.RequestCtx) {
+				if tc.status != 0 {
+					ctx.SetStatusCode(tc.status)
+				}
+
+				JSON(ctx, tc.errs)
+			}, newRequest("GET", "/"))
+
+			if resp.StatusCode() != tc.wantCode {
+				t.Errorf("status code = %d, want %d", resp.StatusCode(), tc.wantCode)
+			}
+
+			var body errorResponse
+			if err := json.Unmarshal(resp.Body(), &body); err != nil {
+				t.Fatalf("body %q isn't JSON: %v", resp.Body(), err)
+			}
+
+			if body.Error == nil {
+				t.Fatalf("error is absent, body %s", resp.Body())
+			}
+
+			msgs, ok := body.Error.Message.(map[string]interface{})
+			if !ok || len(msgs) != len(tc.errs) {
+				t.Fatalf("error message = %s, want object with keys of %v", resp.Body(), tc.errs)
+			}
+
+			for k, e := range tc.errs {
+				if msgs[k] != e.Error() {
+					t.Errorf("error message %q = %v, want %q", k, msgs[k], e.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestJSON_status(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name     string
+		status   int
+		response interface{}
+		wantCode int
+		wantBody string
+	}
+
+	tcs := []testCase{
+		{name: "default", response: "ok", wantCode: fasthttp.StatusOK, wantBody: `{"data":"ok"}`},
+		{name: "created", status: fasthttp.StatusCreated, response: map[string]int{"id": 1}, wantCode: fasthttp.StatusCreated, wantBody: `{"data":{"id":1}}`},
+		{name: "no content", status: fasthttp.StatusNoContent, response: nil, wantCode: fasthttp.StatusNoContent},
+		{name: "no content with data", status: fasthttp.StatusNoContent, response: "ignored", wantCode: fasthttp.StatusNoContent},
+		{name: "error after success status", status: fasthttp.StatusCreated, response: pkgErr.ErrConflict, wantCode: fasthttp.StatusConflict},
+	}
+
+	r := router.New()
+
+	for _, tc := range tcs {
+		tc := tc
+		r.GET("/"+strings.ReplaceAll(tc.name, " ", "-"), func(ctx *fasthttp.RequestCtx) {
+			if tc.status != 0 {
+				ctx.SetStatusCode(tc.status)
+			}
+
+			JSON(ctx, tc.response)
+		})
+	}
+
+	s := testServer(t, cfgstructs.WebServer{})
+	s.SetRouter(r)
+
+	for _, tc := range tcs {
+		resp := doRequest(s.httpServer.Handler, newRequest("GET", "/"+strings.ReplaceAll(tc.name, " ", "-")))
+
+		if resp.StatusCode() != tc.wantCode {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.wantCode)
+		}
+
+		if tc.wantCode == fasthttp.StatusNoContent {
+			// Peek returns default Content-Type, headers are checked as written
+			if len(resp.Body()) != 0 || strings.Contains(resp.Header.String(), fasthttp.HeaderContentType) {
+				t.Errorf("%s: body = %q, headers:\n%s, want neither body nor Content-Type", tc.name, resp.Body(), resp.Header.String())
+			}
+
+			continue
+		}
+
+		if tc.wantBody != "" && string(resp.Body()) != tc.wantBody {
+			t.Errorf("%s: body = %s, want %s", tc.name, resp.Body(), tc.wantBody)
+		}
+	}
+}
+
+func TestResponseHelpers(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name            string
+		handler         fasthttp.RequestHandler
+		wantCode        int
+		wantBody        string
+		wantContentType string
+	}
+
+	tcs := []testCase{
+		{
+			name:            "created",
+			handler:         func(ctx *fasthttp.RequestCtx) { Created(ctx, map[string]int{"id": 1}) },
+			wantCode:        fasthttp.StatusCreated,
+			wantBody:        `{"data":{"id":1}}`,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "accepted",
+			handler:         func(ctx *fasthttp.RequestCtx) { Accepted(ctx, "queued") },
+			wantCode:        fasthttp.StatusAccepted,
+			wantBody:        `{"data":"queued"}`,
+			wantContentType: "application/json",
+		},
+		{
+			name: "no content",
+			handler: func(ctx *fasthttp.RequestCtx) {
+				ctx.SetContentType("application/json")
+				NoContent(ctx)
+			},
+			wantCode: fasthttp.StatusNoContent,
+		},
+		{
+			name:            "bad request",
+			handler:         func(ctx *fasthttp.RequestCtx) { BadRequest(ctx, pkgErr.ErrConflict) },
+			wantCode:        fasthttp.StatusBadRequest,
+			wantBody:        `{"error":{"message":"` + pkgErr.ErrConflict.Error() + `"}}`,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "unauthorized",
+			handler:         func(ctx *fasthttp.RequestCtx) { Unauthorized(ctx, "token expired") },
+			wantCode:        fasthttp.StatusUnauthorized,
+			wantBody:        `{"error":{"message":"token expired"}}`,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "created error",
+			handler:         func(ctx *fasthttp.RequestCtx) { Created(ctx, pkgErr.ErrNotFound) },
+			wantCode:        fasthttp.StatusNotFound,
+			wantBody:        `{"error":{"message":"` + pkgErr.ErrNotFound.Error() + `"}}`,
+			wantContentType: "application/json",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := doRequest(tc.handler, newRequest("GET", "/"))
+
+			if resp.StatusCode() != tc.wantCode {
+				t.Errorf("status code = %d, want %d", resp.StatusCode(), tc.wantCode)
+			}
+
+			if got := string(resp.Body()); got != tc.wantBody {
+				t.Errorf("body = %s, want %s", got, tc.wantBody)
+			}
+
+			// headers are checked as written, Peek returns default Content-Type
+			header := resp.Header.String()
+			if tc.wantContentType == "" && strings.Contains(header, fasthttp.HeaderContentType) {
+				t.Errorf("headers:\n%s\nwant no Content-Type", header)
+			}
+
+			if tc.wantContentType != "" && !strings.Contains(header, "Content-Type: "+tc.wantContentType) {
+				t.Errorf("headers:\n%s\nwant Content-Type %s", header, tc.wantContentType)
+			}
+		})
+	}
+}
+
+func TestJSON_errorCode(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		Name string `validate:"required"`
+	}
+
+	type testCase struct {
+		name     string
+		err      error
+		wantCode int
+		wantBody string
+	}
+
+	tcs := []testCase{
+		{
+			name:     "coded",
+			err:      pkgErr.NewCoded("ORDER_INVALID", "order is invalid"),
+			wantCode: fasthttp.StatusBadRequest,
+			wantBody: `{"error":{"message":"order is invalid","code":"ORDER_INVALID"}}`,
+		},
+		{
+			name:     "plain",
+			err:      errors.New("order is invalid"),
+			wantCode: fasthttp.StatusBadRequest,
+			wantBody: `{"error":{"message":"order is invalid"}}`,
+		},
+		{
+			name:     "nested",
+			err:      fmt.Errorf("load order: %w", pkgErr.WithCode(pkgErr.ErrRecordNotFound, "ORDER_NOT_FOUND")),
+			wantCode: fasthttp.StatusNotFound,
+			wantBody: `{"error":{"message":"load order: record not found","code":"ORDER_NOT_FOUND"}}`,
+		},
+		{
+			name:     "validation",
+			err:      validator.New().Struct(item{}),
+			wantCode: fasthttp.StatusUnprocessableEntity,
+			wantBody: `{"error":{"message":"validation error","code":"VALIDATION_FAILED","validation":{"Name":["Свойство ` + "`Name`" + ` обязательно для заполнения"]}}}`,
+		},
+	}
+
+	for _, tc := range tcs {
+		resp := doRequest(func(ctx *fasthttp.RequestCtx) { JSON(ctx, tc.err) }, newRequest("GET", "/"))
+
+		if resp.StatusCode() != tc.wantCode {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.wantCode)
+		}
+
+		if got := string(resp.Body()); got != tc.wantBody {
+			t.Errorf("%s: body = %s, want %s", tc.name, got, tc.wantBody)
+		}
+	}
+}
+
+// statusError dictates response status.
+type statusError struct {
+	status int
+}
+
+func (e statusError) Error() string   { return fmt.Sprintf("status %d: internal details", e.status) }
+func (e statusError) HTTPStatus() int { return e.status }
+
+// publicStatusError exposes message safe for clients.
+type publicStatusError struct {
+	statusError
+	public string
+}
+
+func (e publicStatusError) PublicMessage() string { return e.public }
+
+func TestJSON_errorStatus(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name     string
+		err      error
+		wantCode int
+		wantMsg  string
+	}
+
+	tcs := []testCase{
+		{name: "conflict", err: statusError{status: 409}, wantCode: 409, wantMsg: "status 409: internal details"},
+		{name: "unavailable", err: statusError{status: 503}, wantCode: 503, wantMsg: "status 503: internal details"},
+		{name: "wrapped", err: fmt.Errorf("save: %w", statusError{status: 409}), wantCode: 409, wantMsg: "save: status 409: internal details"},
+		{
+			name:     "public message",
+			err:      fmt.Errorf("check: %w", publicStatusError{statusError: statusError{status: 503}, public: "try again later"}),
+			wantCode: 503,
+			wantMsg:  "try again later",
+		},
+		{name: "invalid status", err: statusError{status: 1000}, wantCode: 400, wantMsg: "status 1000: internal details"},
+	}
+
+	for _, tc := range tcs {
+		resp := doRequest(func(ctx *fasthttp.RequestCtx) { JSON(ctx, tc.err) }, newRequest("GET", "/"))
+
+		if resp.StatusCode() != tc.wantCode {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.wantCode)
+		}
+
+		var body errorResponse
+		if err := json.Unmarshal(resp.Body(), &body); err != nil || body.Error == nil {
+			t.Fatalf("%s: body %s isn't error response: %v", tc.name, resp.Body(), err)
+		}
+
+		if body.Error.Message != tc.wantMsg {
+			t.Errorf("%s: error message = %v, want %q", tc.name, body.Error.Message, tc.wantMsg)
+		}
+	}
+}
+
+func TestGetErrCode(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[error]int{
+		pkgErr.ErrNotFound:                   http.StatusNotFound,
+		pkgErr.ErrNoMethod:                   http.StatusMethodNotAllowed,
+		pkgErr.ErrServerError:                http.StatusInternalServerError,
+		pkgErr.ErrRecordNotFound:             http.StatusNotFound,
+		pkgErr.ErrConflict:                   http.StatusConflict,
+		pkgErr.ErrUnauthorized:               http.StatusUnauthorized,
+		pkgErr.ErrForbidden:                  http.StatusForbidden,
+		pkgErr.ErrRequestTimeout:             http.StatusRequestTimeout,
+		pkgErr.ErrTooManyRequests:            http.StatusTooManyRequests,
+		pkgErr.ErrUnavailableForLegalReasons: http.StatusUnavailableForLegalReasons,
+		pkgErr.ErrBadGateway:                 http.StatusBadGateway,
+		pkgErr.ErrServiceUnavailable:         http.StatusServiceUnavailable,
+		context.DeadlineExceeded:             http.StatusGatewayTimeout,
+		context.Canceled:                     statusClientClosedRequest,
+		sql.ErrNoRows:                        http.StatusNotFound,
+		errors.New("unknown"):                http.StatusBadRequest,
+	}
+
+	for err, want := range tcs {
+		for _, e := range []error{err, fmt.Errorf("call: %w", err)} {
+			if got, msg := getErrCode(e); got != want || msg == "" {
+				t.Errorf("getErrCode(%q) = %d, %q, want %d", e, got, msg, want)
+			}
+		}
+	}
+}
+
+func TestRaw(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name            string
+		path            string
+		handler         fasthttp.RequestHandler
+		wantCode        int
+		wantBody        string
+		wantContentType string
+		// request ID is appended to error bodies
+		wantBodyPrefix bool
+	}
+
+	tcs := []testCase{
+		{
+			name:            "raw",
+			path:            "/raw",
+			handler:         func(ctx *fasthttp.RequestCtx) { Raw(ctx, fasthttp.StatusAccepted, "text/csv", []byte("id\n1\n")) },
+			wantCode:        fasthttp.StatusAccepted,
+			wantBody:        "id\n1\n",
+			wantContentType: "text/csv",
+		},
+		{
+			name:     "raw no content",
+			path:     "/raw-empty",
+			handler:  func(ctx *fasthttp.RequestCtx) { Raw(ctx, fasthttp.StatusNoContent, "text/plain", []byte("ignored")) },
+			wantCode: fasthttp.StatusNoContent,
+		},
+		{
+			name:            "raw json",
+			path:            "/raw-json",
+			handler:         func(ctx *fasthttp.RequestCtx) { RawJSON(ctx, map[string]bool{"ok": true}) },
+			wantCode:        fasthttp.StatusOK,
+			wantBody:        `{"ok":true}`,
+			wantContentType: "application/json",
+		},
+		{
+			name: "raw json preset status",
+			path: "/raw-json-created",
+			handler: func(ctx *fasthttp.RequestCtx) {
+				ctx.SetStatusCode(fasthttp.StatusCreated)
+				RawJSON(ctx, []int{1, 2})
+			},
+			wantCode:        fasthttp.StatusCreated,
+			wantBody:        `[1,2]`,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "raw json marshal error",
+			path:            "/raw-json-error",
+			handler:         func(ctx *fasthttp.RequestCtx) { RawJSON(ctx, math.Inf(1)) },
+			wantCode:        fasthttp.StatusInternalServerError,
+			wantBody:        `{"error":{"message":"unsupported value: +Inf"},"request_id":`,
+			wantContentType: "application/json",
+			wantBodyPrefix:  true,
+		},
+	}
+
+	r := router.New()
+	for _, tc := range tcs {
+		r.GET(tc.path, tc.handler)
+	}
+
+	s := testServer(t, cfgstructs.WebServer{})
+	s.SetRouter(r)
+
+	client := serveInmemory(t, s)
+
+	for _, tc := range tcs {
+		req := newRequest("GET", "http://localhost"+tc.path)
+		resp := &fasthttp.Response{}
+
+		if err := client.Do(req, resp); err != nil {
+			t.Fatalf("%s: request error: %v", tc.name, err)
+		}
+
+		if resp.StatusCode() != tc.wantCode {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.wantCode)
+		}
+
+		if got := string(resp.Body()); got != tc.wantBody && !(tc.wantBodyPrefix && strings.HasPrefix(got, tc.wantBody)) {
+			t.Errorf("%s: body = %q, want %q", tc.name, got, tc.wantBody)
+		}
+
+		if tc.wantContentType != "" && string(resp.Header.ContentType()) != tc.wantContentType {
+			t.Errorf("%s: Content-Type = %q, want %q", tc.name, resp.Header.ContentType(), tc.wantContentType)
+		}
+	}
+}
+
+func TestResponseHelpers_head(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("id,title\n"), 100)
+
+	handlers := map[string]fasthttp.RequestHandler{
+		"/json":     func(ctx *fasthttp.RequestCtx) { JSON(ctx, map[string]string{"status": "ok"}) },
+		"/created":  func(ctx *fasthttp.RequestCtx) { Created(ctx, []int{1, 2, 3}) },
+		"/error":    func(ctx *fasthttp.RequestCtx) { JSON(ctx, pkgErr.ErrRecordNotFound) },
+		"/empty":    func(ctx *fasthttp.RequestCtx) { NoContent(ctx) },
+		"/streamed": func(ctx *fasthttp.RequestCtx) { JSON(ctx, make([]int, 1000)) },
+		"/xml":      func(ctx *fasthttp.RequestCtx) { XML(ctx, "ok") },
+		"/raw":      func(ctx *fasthttp.RequestCtx) { Raw(ctx, fasthttp.StatusAccepted, "text/csv", content) },
+		"/file":     func(ctx *fasthttp.RequestCtx) { FileBytes(ctx, "export.csv", "text/csv", content) },
+		"/range": func(ctx *fasthttp.RequestCtx) {
+			ctx.Request.Header.Set(fasthttp.HeaderRange, "bytes=0-8")
+			FileBytes(ctx, "export.csv", "text/csv", content)
+		},
+		"/chunked": func(ctx *fasthttp.RequestCtx) {
+			File(ctx, "export.csv", "text/csv", io.MultiReader(bytes.NewReader(content)), -1)
+		},
+	}
+
+	r := router.New()
+	for path, h := range handlers {
+		r.GET(path, h)
+		r.HEAD(path, h)
+	}
+
+	s := New(cfgstructs.WebServer{Compress: true}, WithJSONStreamThreshold(256)).SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	client := serveInmemory(t, s)
+
+	for path := range handlers {
+		get := &fasthttp.Response{}
+		if err := client.Do(newRequest(fasthttp.MethodGet, "http://localhost"+path), get); err != nil {
+			t.Fatalf("%s: GET error: %v", path, err)
+		}
+
+		head := &fasthttp.Response{}
+		if err := client.Do(newRequest(fasthttp.MethodHead, "http://localhost"+path), head); err != nil {
+			t.Fatalf("%s: HEAD error: %v", path, err)
+		}
+
+		if head.StatusCode() != get.StatusCode() {
+			t.Errorf("%s: HEAD status = %d, want %d", path, head.StatusCode(), get.StatusCode())
+		}
+
+		if got, want := string(head.Header.ContentType()), string(get.Header.ContentType()); got != want {
+			t.Errorf("%s: HEAD Content-Type = %q, want %q", path, got, want)
+		}
+
+		// streamed JSON is buffered to compute its length, file of unknown size isn't read
+		wantLength := len(get.Body())
+
+		switch {
+		case path == "/chunked":
+			wantLength = -1
+		case get.StatusCode() == fasthttp.StatusNoContent:
+			wantLength = get.Header.ContentLength()
+		}
+
+		if head.Header.ContentLength() != wantLength {
+			t.Errorf("%s: HEAD Content-Length = %d, want %d", path, head.Header.ContentLength(), wantLength)
+		}
+
+		if len(head.Body()) != 0 {
+			t.Errorf("%s: HEAD body = %q, want empty", path, head.Body())
+		}
+	}
+}
+
+func TestJSON_head(t *testing.T) {
+	t.Parallel()
+
+	req := newRequest(fasthttp.MethodHead, "/")
+	resp := doRequest(func(ctx *fasthttp.RequestCtx) { JSON(ctx, "ok") }, req)
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+	}
+
+	if len(resp.Body()) != 0 {
+		t.Errorf("body = %q, want empty", resp.Body())
+	}
+
+	if got, want := resp.Header.ContentLength(), len(`{"data":"ok"}`); got != want {
+		t.Errorf("Content-Length = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package fhserver
+
+import (
+	"runtime/debug"
+
+	"github.com/fasthttp/router"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// RouterOption configures router built by NewRouter.
+type RouterOption func(r *router.Router)
+
+// RouterRedirectTrailingSlash turns on or off redirect to the path with or without trailing slash
+// if only the other one is registered. It's on by default.
+func RouterRedirectTrailingSlash(enabled bool) RouterOption {
+	return func(r *router.Router) {
+		r.RedirectTrailingSlash = enabled
+	}
+}
+
+// RouterRedirectFixedPath turns on or off redirect to the cleaned and case-insensitive matching
+// registered path. It's on by default.
+func RouterRedirectFixedPath(enabled bool) RouterOption {
+	return func(r *router.Router) {
+		r.RedirectFixedPath = enabled
+	}
+}
+
+// RouterHandleOPTIONS turns on or off automatic answering OPTIONS requests to paths without
+// their own OPTIONS handler. It's on by default, so preflights aren't answered with 405
+// when CORS is handled by the router instead of the server.
+func RouterHandleOPTIONS(enabled bool) RouterOption {
+	return func(r *router.Router) {
+		r.HandleOPTIONS = enabled
+	}
+}
+
+// RouterGlobalOPTIONS sets handler of automatic OPTIONS responses. Allow header is set before
+// the handler call. By default the response is 204 No Content.
+func RouterGlobalOPTIONS(h fasthttp.RequestHandler) RouterOption {
+	return func(r *router.Router) {
+		r.GlobalOPTIONS = h
+	}
+}
+
+// NewRouter returns router answering unknown paths with 404, not allowed methods with 405 and
+// panics with 500 in the standard JSON error envelope. Panics are handled the same way as by
+// the server: logged and passed to the panic handler. OPTIONS requests are answered with
+// 204 and Allow header listing path methods.
+func NewRouter(opts ...RouterOption) *router.Router {
+	r := router.New()
+
+	r.NotFound = func(ctx *fasthttp.RequestCtx) {
+		JSON(ctx, pkgErr.ErrNotFound)
+	}
+
+	// Allow header is set by the router
+	r.MethodNotAllowed = func(ctx *fasthttp.RequestCtx) {
+		JSON(ctx, pkgErr.ErrNoMethod)
+	}
+
+	// Allow header is set by the router
+	r.GlobalOPTIONS = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+	}
+
+	// called in deferred function, so the stack has the panic origin
+	r.PanicHandler = func(ctx *fasthttp.RequestCtx, rvr interface{}) {
+		recoverPanic(ctx, rvr, debug.Stack())
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
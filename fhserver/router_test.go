@@ -0,0 +1,152 @@
+package fhserver
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestNewRouter(t *testing.T) {
+	t.Parallel()
+
+	r := NewRouter()
+	r.GET("/items", func(ctx *fasthttp.RequestCtx) { JSON(ctx, "items") })
+	r.POST("/items", func(ctx *fasthttp.RequestCtx) { JSON(ctx, "created") })
+	r.GET("/panic", func(ctx *fasthttp.RequestCtx) { panic("boom") })
+
+	var hookCalls int32
+
+	buf := &syncBuffer{}
+
+	s := New(cfgstructs.WebServer{}).SetLogger(testLogger(t, buf)).
+		SetPanicHandler(func(ctx *fasthttp.RequestCtx, recovered interface{}, stack []byte) {
+			atomic.AddInt32(&hookCalls, 1)
+		})
+	s.SetRouter(r)
+
+	tcs := []struct {
+		name    string
+		handler fasthttp.RequestHandler
+		method  string
+		uri     string
+		want    int
+		wantMsg string
+	}{
+		{name: "unknown path", handler: s.httpServer.Handler, method: "GET", uri: "/missing", want: 404, wantMsg: "route not found"},
+		{name: "wrong method", handler: s.httpServer.Handler, method: "DELETE", uri: "/items", want: 405, wantMsg: "method not allowed"},
+		{name: "panic", handler: s.httpServer.Handler, method: "GET", uri: "/panic", want: 500, wantMsg: "internal server error"},
+		{name: "panic without server", handler: r.Handler, method: "GET", uri: "/panic", want: 500, wantMsg: "internal server error"},
+	}
+
+	for _, tc := range tcs {
+		resp := doRequest(tc.handler, newRequest(tc.method, "http://test"+tc.uri))
+
+		if resp.StatusCode() != tc.want {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.want)
+		}
+
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+
+		if err := json.Unmarshal(resp.Body(), &body); err != nil || body.Error.Message != tc.wantMsg {
+			t.Errorf("%s: body = %s, want error message %q", tc.name, resp.Body(), tc.wantMsg)
+		}
+
+		if tc.want == 405 {
+			if allow := string(resp.Header.Peek("Allow")); allow != "GET, OPTIONS, POST" {
+				t.Errorf("%s: Allow = %q, want %q", tc.name, allow, "GET, OPTIONS, POST")
+			}
+		}
+	}
+
+	if n := atomic.LoadInt32(&hookCalls); n != 1 {
+		t.Errorf("panic handler called %d times, want 1", n)
+	}
+
+	if !strings.Contains(buf.String(), "panic recovered") || !strings.Contains(buf.String(), "router_test.go") {
+		t.Errorf("panic isn't logged with its origin, got:\n%s", buf.String())
+	}
+}
+
+func TestNewRouter_options(t *testing.T) {
+	t.Parallel()
+
+	routes := func(opts ...RouterOption) fasthttp.RequestHandler {
+		r := NewRouter(opts...)
+		r.GET("/items", func(ctx *fasthttp.RequestCtx) { JSON(ctx, "items") })
+		r.POST("/items", func(ctx *fasthttp.RequestCtx) { JSON(ctx, "created") })
+
+		s := testServer(t, cfgstructs.WebServer{})
+		s.SetRouter(r)
+
+		return s.httpServer.Handler
+	}
+
+	tcs := []struct {
+		name      string
+		handler   fasthttp.RequestHandler
+		method    string
+		uri       string
+		want      int
+		wantAllow string
+		wantLoc   string
+	}{
+		{name: "trailing slash redirect", handler: routes(), method: "GET", uri: "/items/", want: 301, wantLoc: "/items"},
+		{name: "trailing slash redirect keeps method", handler: routes(), method: "POST", uri: "/items/", want: 308, wantLoc: "/items"},
+		{name: "trailing slash redirect off", handler: routes(RouterRedirectTrailingSlash(false), RouterRedirectFixedPath(false)), method: "GET", uri: "/items/", want: 404},
+		{name: "fixed path redirect", handler: routes(), method: "GET", uri: "/ITEMS", want: 301, wantLoc: "/items"},
+		{name: "fixed path redirect off", handler: routes(RouterRedirectFixedPath(false)), method: "GET", uri: "/ITEMS", want: 404},
+		{name: "automatic OPTIONS", handler: routes(), method: "OPTIONS", uri: "/items", want: 204, wantAllow: "GET, OPTIONS, POST"},
+		{name: "custom global OPTIONS", handler: routes(RouterGlobalOPTIONS(func(ctx *fasthttp.RequestCtx) {})), method: "OPTIONS", uri: "/items", want: 200, wantAllow: "GET, OPTIONS, POST"},
+		{name: "automatic OPTIONS off", handler: routes(RouterHandleOPTIONS(false)), method: "OPTIONS", uri: "/items", want: 405, wantAllow: "GET, OPTIONS, POST"},
+	}
+
+	for _, tc := range tcs {
+		resp := doRequest(tc.handler, newRequest(tc.method, "http://test"+tc.uri))
+
+		if resp.StatusCode() != tc.want {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.want)
+		}
+
+		if allow := string(resp.Header.Peek("Allow")); allow != tc.wantAllow {
+			t.Errorf("%s: Allow = %q, want %q", tc.name, allow, tc.wantAllow)
+		}
+
+		// fasthttp makes redirect location absolute
+		if loc := strings.TrimPrefix(string(resp.Header.Peek("Location")), "http://test"); loc != tc.wantLoc {
+			t.Errorf("%s: Location = %q, want %q", tc.name, loc, tc.wantLoc)
+		}
+	}
+}
+
+func TestNewRouter_preflight(t *testing.T) {
+	t.Parallel()
+
+	r := NewRouter()
+	r.GET("/items", func(ctx *fasthttp.RequestCtx) { JSON(ctx, "items") })
+
+	for _, cors := range []bool{true, false} {
+		s := testServer(t, cfgstructs.WebServer{CORS: cfgstructs.CORSCfg{Enabled: cors}})
+		s.SetRouter(r)
+
+		req := newRequest("OPTIONS", "http://test/items")
+		req.Header.Set("Origin", "https://a.example")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+
+		resp := doRequest(s.httpServer.Handler, req)
+
+		if code := resp.StatusCode(); code != fasthttp.StatusOK && code != fasthttp.StatusNoContent {
+			t.Errorf("cors %v: preflight status code = %d, want 2xx", cors, code)
+		}
+
+		if got := string(resp.Header.Peek("Access-Control-Allow-Origin")); cors && got != "https://a.example" {
+			t.Errorf("cors %v: Access-Control-Allow-Origin = %q, want %q", cors, got, "https://a.example")
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package fhserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen error: %v", err)
+	}
+
+	defer ln.Close()
+
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestServer_RunContext(t *testing.T) {
+	t.Parallel()
+
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Port: freePort(t)}
+	cfg.Timeouts.Shutdown = 5 * time.Second
+	addr := cfg.GetListenAddress()
+
+	r := router.New()
+	r.GET("/slow", func(ctx *fasthttp.RequestCtx) {
+		time.Sleep(300 * time.Millisecond)
+		JSON(ctx, "done")
+	})
+
+	s := testServer(t, cfg)
+	s.SetRouter(r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	go s.RunContext(ctx, wg)
+
+	<-s.Ready()
+
+	inFlight := make(chan int, 1)
+
+	go func() {
+		code, _, err := fasthttp.Get(nil, "http://"+addr+"/slow")
+		if err != nil {
+			code = 0
+		}
+
+		inFlight <- code
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	stopped := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunContext did not return after context cancel")
+	}
+
+	if code := <-inFlight; code != fasthttp.StatusOK {
+		t.Errorf("in-flight request status = %d, want %d", code, fasthttp.StatusOK)
+	}
+
+	if c, err := net.Dial("tcp4", addr); err == nil {
+		_ = c.Close()
+
+		t.Error("listener must be closed after shutdown")
+	}
+}
+
+func TestServer_Stop(t *testing.T) {
+	t.Parallel()
+
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Port: freePort(t)}
+	cfg.Timeouts.Shutdown = time.Second
+
+	s := testServer(t, cfg)
+	s.SetRouter(testRouter())
+
+	if err := s.Stop(); !errors.Is(err, pkgErr.ErrServerNotRunning) {
+		t.Errorf("Stop before Run error = %v, want %v", err, pkgErr.ErrServerNotRunning)
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	go s.Run(wg)
+
+	errs := make(chan error, 3)
+
+	for i := 0; i < 3; i++ {
+		go func() {
+			<-s.Ready()
+			errs <- s.Stop()
+		}()
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("Stop error: %v", err)
+		}
+	}
+
+	wg.Wait()
+}
+
+func TestServer_Run_errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil router", func(t *testing.T) {
+		t.Parallel()
+
+		s := testServer(t, cfgstructs.WebServer{Host: "127.0.0.1", Port: freePort(t)})
+
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+
+		if err := s.Run(wg); !errors.Is(err, pkgErr.ErrNilRouter) {
+			t.Errorf("Run error = %v, want %v", err, pkgErr.ErrNilRouter)
+		}
+
+		wg.Wait()
+	})
+
+	t.Run("address in use", func(t *testing.T) {
+		t.Parallel()
+
+		ln, err := net.Listen("tcp4", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("net.Listen error: %v", err)
+		}
+
+		defer ln.Close()
+
+		s := testServer(t, cfgstructs.WebServer{Host: "127.0.0.1", Port: ln.Addr().(*net.TCPAddr).Port})
+		s.SetRouter(testRouter())
+
+		if err := s.Run(nil); err == nil {
+			t.Error("Run on busy address must return error")
+		}
+	})
+}
+
+func TestServer_Ready(t *testing.T) {
+	t.Parallel()
+
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Port: freePort(t)}
+	cfg.Timeouts.Shutdown = time.Second
+
+	s := testServer(t, cfg)
+	s.SetRouter(testRouter())
+
+	select {
+	case <-s.Ready():
+		t.Fatal("Ready must not be closed before Run")
+	default:
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go s.RunContext(ctx, nil)
+
+	select {
+	case <-s.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("server is not ready")
+	}
+
+	code, _, err := fasthttp.Get(nil, "http://"+cfg.GetListenAddress()+"/ping")
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if code != fasthttp.StatusOK {
+		t.Errorf("status code = %d, want %d", code, fasthttp.StatusOK)
+	}
+}
+
+func TestServer_Addr(t *testing.T) {
+	t.Parallel()
+
+	s := testServer(t, cfgstructs.WebServer{Host: "127.0.0.1", Port: 0})
+	s.SetRouter(testRouter())
+
+	if s.Addr() != nil {
+		t.Error("Addr must be nil before Run")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go s.RunContext(ctx, nil)
+
+	<-s.Ready()
+
+	addr, ok := s.Addr().(*net.TCPAddr)
+	if !ok || addr.Port == 0 {
+		t.Fatalf("Addr = %v, want bound tcp address", s.Addr())
+	}
+
+	code, _, err := fasthttp.Get(nil, "http://"+addr.String()+"/ping")
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if code != fasthttp.StatusOK {
+		t.Errorf("status code = %d, want %d", code, fasthttp.StatusOK)
+	}
+}
+
+func TestServer_AddListener(t *testing.T) {
+	t.Parallel()
+
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Port: 0}
+	cfg.Timeouts.Shutdown = time.Second
+
+	s := testServer(t, cfg).AddListener("127.0.0.1:0")
+	s.SetRouter(testRouter())
+
+	done := make(chan error, 1)
+
+	go func() { done <- s.Run(nil) }()
+
+	<-s.Ready()
+
+	addrs := s.Addrs()
+	if len(addrs) != 2 {
+		t.Fatalf("Addrs len = %d, want 2", len(addrs))
+	}
+
+	for _, addr := range addrs {
+		req := newRequest("GET", "http://"+addr.String()+"/ping")
+		req.SetConnectionClose()
+
+		resp := &fasthttp.Response{}
+		if err := fasthttp.Do(req, resp); err != nil {
+			t.Fatalf("request to %s error: %v", addr, err)
+		}
+
+		if resp.StatusCode() != fasthttp.StatusOK {
+			t.Errorf("%s status code = %d, want %d", addr, resp.StatusCode(), fasthttp.StatusOK)
+		}
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop error: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("Run error: %v", err)
+	}
+
+	for _, addr := range addrs {
+		if c, err := net.Dial("tcp4", addr.String()); err == nil {
+			_ = c.Close()
+
+			t.Errorf("listener %s must be closed", addr)
+		}
+	}
+}
+
+func TestServer_Stop_forceClose(t *testing.T) {
+	t.Parallel()
+
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Port: 0}
+	cfg.Timeouts.Shutdown = 100 * time.Millisecond
+
+	r := router.New()
+	r.GET("/sleep", func(ctx *fasthttp.RequestCtx) {
+		time.Sleep(time.Second)
+	})
+
+	s := testServer(t, cfg)
+	s.SetRouter(r)
+
+	done := make(chan error, 1)
+
+	go func() { done <- s.Run(nil) }()
+
+	<-s.Ready()
+
+	reqErr := make(chan error, 1)
+
+	go func() {
+		_, _, err := fasthttp.Get(nil, "http://"+s.Addr().String()+"/sleep")
+		reqErr <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	if err := s.Stop(); !errors.Is(err, pkgErr.ErrFHServerShutdown) {
+		t.Errorf("Stop error = %v, want %v", err, pkgErr.ErrFHServerShutdown)
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, pkgErr.ErrFHServerShutdown) {
+			t.Errorf("Run error = %v, want %v", err, pkgErr.ErrFHServerShutdown)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Run didn't return after force close")
+	}
+
+	if err := <-reqErr; err == nil {
+		t.Error("request on force closed connection must fail")
+	}
+}
+
+func TestServer_Stop_noErrorLogs(t *testing.T) {
+	t.Parallel()
+
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Port: 0}
+	cfg.Timeouts.Shutdown = time.Second
+
+	buf := &syncBuffer{}
+
+	s := New(cfg).SetLogger(testLogger(t, buf))
+	s.SetRouter(testRouter())
+
+	done := make(chan error, 1)
+
+	go func() { done <- s.Run(nil) }()
+
+	<-s.Ready()
+
+	req := newRequest("GET", "http://"+s.Addr().String()+"/ping")
+	req.SetConnectionClose()
+
+	if err := fasthttp.Do(req, &fasthttp.Response{}); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop error: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "ERROR") {
+		t.Errorf("graceful stop must not produce error logs, got:\n%s", buf.String())
+	}
+}
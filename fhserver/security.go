@@ -0,0 +1,70 @@
+package fhserver
+
+import "github.com/valyala/fasthttp"
+
+// SecurityHeaders holds values of security response headers, empty value disables the header.
+type SecurityHeaders struct {
+	StrictTransportSecurity string
+	ContentTypeOptions      string
+	FrameOptions            string
+	ReferrerPolicy          string
+	ContentSecurityPolicy   string
+}
+
+// DefaultSecurityHeaders returns commonly used security headers. Content-Security-Policy is
+// application specific, so it's disabled.
+func DefaultSecurityHeaders() SecurityHeaders {
+	return SecurityHeaders{
+		StrictTransportSecurity: "max-age=31536000; includeSubDomains",
+		ContentTypeOptions:      "nosniff",
+		FrameOptions:            "DENY",
+		ReferrerPolicy:          "strict-origin-when-cross-origin",
+	}
+}
+
+// WithSecurityHeaders adds security headers to every response. Handlers may override them.
+func WithSecurityHeaders(h SecurityHeaders) Option {
+	return func(s *Server) {
+		s.securityHeaders = &h
+	}
+}
+
+func (h SecurityHeaders) headers() [][2]string {
+	all := [][2]string{
+		{"Strict-Transport-Security", h.StrictTransportSecurity},
+		{"X-Content-Type-Options", h.ContentTypeOptions},
+		{"X-Frame-Options", h.FrameOptions},
+		{"Referrer-Policy", h.ReferrerPolicy},
+		{"Content-Security-Policy", h.ContentSecurityPolicy},
+	}
+
+	enabled := make([][2]string, 0, len(all))
+
+	for _, kv := range all {
+		if kv[1] != "" {
+			enabled = append(enabled, kv)
+		}
+	}
+
+	return enabled
+}
+
+// securityHeadersMiddleware sets headers before the handler, so handlers may override them.
+// Headers are set again if response was reset, e.g. by panic recovery.
+func securityHeadersMiddleware(next fasthttp.RequestHandler, h SecurityHeaders) fasthttp.RequestHandler {
+	headers := h.headers()
+
+	return func(ctx *fasthttp.RequestCtx) {
+		for _, kv := range headers {
+			ctx.Response.Header.Set(kv[0], kv[1])
+		}
+
+		next(ctx)
+
+		for _, kv := range headers {
+			if len(ctx.Response.Header.Peek(kv[0])) == 0 {
+				ctx.Response.Header.Set(kv[0], kv[1])
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package fhserver
+
+import (
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestWithSecurityHeaders(t *testing.T) {
+	t.Parallel()
+
+	r := router.New()
+	r.GET("/ok", func(ctx *fasthttp.RequestCtx) { JSON(ctx, "ok") })
+	r.GET("/fail", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		JSON(ctx, "bad request")
+	})
+	r.GET("/panic", func(ctx *fasthttp.RequestCtx) { panic("boom") })
+	r.GET("/override", func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Set("X-Frame-Options", "SAMEORIGIN")
+	})
+
+	headers := DefaultSecurityHeaders()
+	headers.ContentSecurityPolicy = "default-src 'self'"
+	headers.ReferrerPolicy = ""
+
+	s := New(cfgstructs.WebServer{}, WithSecurityHeaders(headers)).SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	type testCase struct {
+		uri  string
+		want map[string]string
+	}
+
+	defaults := map[string]string{
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Content-Security-Policy":   "default-src 'self'",
+		"Referrer-Policy":           "",
+	}
+
+	overridden := make(map[string]string, len(defaults))
+	for k, v := range defaults {
+		overridden[k] = v
+	}
+
+	overridden["X-Frame-Options"] = "SAMEORIGIN"
+
+	tcs := []testCase{
+		{uri: "/ok", want: defaults},
+		{uri: "/fail", want: defaults},
+		{uri: "/panic", want: defaults},
+		{uri: "/missing", want: defaults},
+		{uri: "/override", want: overridden},
+	}
+
+	for _, tc := range tcs {
+		resp := doRequest(s.httpServer.Handler, newRequest("GET", "http://test"+tc.uri))
+
+		for k, v := range tc.want {
+			if got := string(resp.Header.Peek(k)); got != v {
+				t.Errorf("%s: %s = %q, want %q", tc.uri, k, got, v)
+			}
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package fhserver
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -21,37 +23,97 @@ type gracefulListener struct {
 	maxWaitTime time.Duration
 
 	// this channel is closed during graceful shutdown on zero open connections.
-	done chan struct{}
+	done     chan struct{}
+	doneOnce sync.Once
+
+	// inner listener is closed only once
+	closeOnce sync.Once
+	closeErr  error
+
+	// open connections to force close after maxWaitTime
+	connsMu sync.Mutex
+	conns   map[*gracefulConn]struct{}
 
 	// the number of open connections
 	connsCount uint64
 	// becomes non-zero when graceful shutdown starts
 	shutdown uint64
+
+	// TCP keep-alive of accepted connections, see WithTCPKeepalive
+	keepAlive       bool
+	keepAlivePeriod time.Duration
 }
 
 // newGracefulListener wraps the given listener into 'graceful shutdown' listener.
-func newGracefulListener(ln net.Listener, maxWaitTime time.Duration, log *log.Logger) net.Listener {
+func newGracefulListener(ln net.Listener, maxWaitTime time.Duration, log *log.Logger) *gracefulListener {
 	return &gracefulListener{
 		log:         log,
 		ln:          ln,
 		maxWaitTime: maxWaitTime,
 		done:        make(chan struct{}),
+		conns:       make(map[*gracefulConn]struct{}),
 	}
 }
 
+// newGracefulListener wraps ln into graceful listener configured by the server options.
+func (s *Server) newGracefulListener(ln net.Listener) *gracefulListener {
+	graceful := newGracefulListener(ln, s.config.GetShutdownTimeout(), s.log)
+	graceful.keepAlive = s.httpServer.TCPKeepalive
+	graceful.keepAlivePeriod = s.httpServer.TCPKeepalivePeriod
+
+	return graceful
+}
+
 // Accept creates a conn.
 func (ln *gracefulListener) Accept() (net.Conn, error) {
 	c, err := ln.ln.Accept()
 	if err != nil {
+		// expected after Close, return as is to be recognized by the server
+		if isClosedConnError(err) {
+			return nil, net.ErrClosed
+		}
+
 		return nil, fmt.Errorf("gracefulListener accept error: %w", err)
 	}
 
+	ln.setKeepAlive(c)
+
 	atomic.AddUint64(&ln.connsCount, 1)
 
-	return &gracefulConn{
+	gc := &gracefulConn{
 		Conn: c,
 		ln:   ln,
-	}, nil
+	}
+
+	ln.connsMu.Lock()
+	ln.conns[gc] = struct{}{}
+	ln.connsMu.Unlock()
+
+	return gc, nil
+}
+
+// keepAliveConn is implemented by connections supporting TCP keep-alive, i.e. *net.TCPConn.
+type keepAliveConn interface {
+	SetKeepAlive(keepalive bool) error
+	SetKeepAlivePeriod(d time.Duration) error
+}
+
+// setKeepAlive turns on TCP keep-alive of the accepted connection if it's enabled,
+// so half-dead clients don't hold graceful shutdown. Other connections (unix) are skipped.
+func (ln *gracefulListener) setKeepAlive(c net.Conn) {
+	kc, ok := c.(keepAliveConn)
+	if !ok || !ln.keepAlive {
+		return
+	}
+
+	err := kc.SetKeepAlive(true)
+	if err == nil && ln.keepAlivePeriod > 0 {
+		err = kc.SetKeepAlivePeriod(ln.keepAlivePeriod)
+	}
+
+	if err != nil && ln.log != nil {
+		ln.log.Warn().Err(err).Msg("TCP keep-alive setup error")
+	}
 }
 
 // Addr returns the listen address.
@@ -62,18 +124,30 @@ func (ln *gracefulListener) Addr() net.Addr {
 // Close closes the inner listener and waits until all the pending
 // open connections are closed before returning.
 func (ln *gracefulListener) Close() error {
-	if err := ln.ln.Close(); err != nil {
-		return fmt.Errorf("gracefulListener close error: %w", err)
+	return ln.closeWithTimeout(ln.maxWaitTime)
+}
+
+// closeWithTimeout is the same as Close but waits for open connections up to maxWaitTime.
+// Repeated calls don't close the inner listener again but wait for open connections as well.
+func (ln *gracefulListener) closeWithTimeout(maxWaitTime time.Duration) error {
+	ln.closeOnce.Do(func() {
+		if err := ln.ln.Close(); err != nil {
+			ln.closeErr = fmt.Errorf("gracefulListener close error: %w", err)
+		}
+	})
+
+	if ln.closeErr != nil {
+		return ln.closeErr
 	}
 
-	return ln.waitForZeroConns()
+	return ln.waitForZeroConns(maxWaitTime)
 }
 
-func (ln *gracefulListener) waitForZeroConns() error {
-	atomic.AddUint64(&ln.shutdown, 1)
+func (ln *gracefulListener) waitForZeroConns(maxWaitTime time.Duration) error {
+	atomic.StoreUint64(&ln.shutdown, 1)
 
 	if atomic.LoadUint64(&ln.connsCount) == 0 {
-		close(ln.done)
+		ln.closeDone()
 
 		return nil
 	}
@@ -81,34 +155,97 @@ func (ln *gracefulListener) waitForZeroConns() error {
 	select {
 	case <-ln.done:
 		return nil
-	case <-time.After(ln.maxWaitTime):
+	case <-time.After(maxWaitTime):
+		forceClosed := ln.forceCloseConns()
+
 		if ln.log != nil {
-			ln.log.Error().Err(pkgErr.ErrFHServerShutdown).Dur("maxWaitTime", ln.maxWaitTime).Send()
+			ln.log.Error().Err(pkgErr.ErrFHServerShutdown).
+				Dur("maxWaitTime", maxWaitTime).
+				Int("forceClosed", forceClosed).
+				Send()
 		}
 
-		return pkgErr.ErrFHServerShutdown
+		return fmt.Errorf("%w: %d connections force closed", pkgErr.ErrFHServerShutdown, forceClosed)
 	}
 }
 
-func (ln *gracefulListener) closeConn() {
-	connsCount := atomic.AddUint64(&ln.connsCount, ^uint64(0))
+// forceCloseConns closes all connections still open and returns their count.
+func (ln *gracefulListener) forceCloseConns() int {
+	ln.connsMu.Lock()
+	conns := make([]*gracefulConn, 0, len(ln.conns))
 
-	if atomic.LoadUint64(&ln.shutdown) != 0 && connsCount == 0 {
+	for c := range ln.conns {
+		conns = append(conns, c)
+	}
+
+	ln.connsMu.Unlock()
+
+	for _, c := range conns {
+		// unblock pending reads and writes
+		_ = c.SetDeadline(time.Now())
+		_ = c.Close()
+	}
+
+	return len(conns)
+}
+
+// closeDone closes done channel. Both waitForZeroConns and closeConn may
+// observe zero connections concurrently, so the channel is closed only once.
+func (ln *gracefulListener) closeDone() {
+	ln.doneOnce.Do(func() {
 		close(ln.done)
+	})
+}
+
+func (ln *gracefulListener) closeConn(c *gracefulConn) {
+	ln.connsMu.Lock()
+	delete(ln.conns, c)
+	ln.connsMu.Unlock()
+
+	var connsCount uint64
+
+	// decrement without underflow
+	for {
+		current := atomic.LoadUint64(&ln.connsCount)
+		if current == 0 {
+			break
+		}
+
+		if atomic.CompareAndSwapUint64(&ln.connsCount, current, current-1) {
+			connsCount = current - 1
+
+			break
+		}
+	}
+
+	if atomic.LoadUint64(&ln.shutdown) != 0 && connsCount == 0 {
+		ln.closeDone()
 	}
 }
 
 type gracefulConn struct {
 	net.Conn
-	ln *gracefulListener
+	ln        *gracefulListener
+	closeOnce sync.Once
 }
 
+// Close closes the connection. Listener connections counter is decremented
+// exactly once even if Close is called several times or inner close fails.
 func (c *gracefulConn) Close() error {
-	if err := c.Conn.Close(); err != nil {
+	err := c.Conn.Close()
+
+	c.closeOnce.Do(func() {
+		c.ln.closeConn(c)
+	})
+
+	if err != nil {
 		return fmt.Errorf("gracefulConn close error: %w", err)
 	}
 
-	c.ln.closeConn()
-
 	return nil
 }
+
+// isClosedConnError reports whether err is caused by using closed listener or connection.
+func isClosedConnError(err error) bool {
+	return errors.Is(err, net.ErrClosed)
+}
@@ -0,0 +1,275 @@
+package fhserver
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+)
+
+func newTestGracefulListener(t *testing.T, maxWaitTime time.Duration) *gracefulListener {
+	t.Helper()
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen error: %v", err)
+	}
+
+	return newGracefulListener(ln, maxWaitTime, nil)
+}
+
+func TestGracefulListener_CloseTwice(t *testing.T) {
+	t.Parallel()
+
+	ln := newTestGracefulListener(t, time.Second)
+
+	if err := ln.Close(); err != nil {
+		t.Fatalf("first Close error: %v", err)
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Errorf("second Close error: %v", err)
+	}
+}
+
+func TestGracefulListener_CloseWhileDraining(t *testing.T) {
+	t.Parallel()
+
+	const connsCount = 50
+
+	ln := newTestGracefulListener(t, 5*time.Second)
+
+	accepted := make(chan net.Conn, connsCount)
+
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			accepted <- c
+		}
+	}()
+
+	clients := make([]net.Conn, 0, connsCount)
+
+	for i := 0; i < connsCount; i++ {
+		c, err := net.Dial("tcp4", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial error: %v", err)
+		}
+
+		clients = append(clients, c)
+	}
+
+	conns := make([]net.Conn, 0, connsCount)
+	for i := 0; i < connsCount; i++ {
+		conns = append(conns, <-accepted)
+	}
+
+	wg := &sync.WaitGroup{}
+
+	// close server side connections concurrently with listener shutdown, some of them twice
+	for i, c := range conns {
+		wg.Add(1)
+
+		go func(i int, c net.Conn) {
+			defer wg.Done()
+
+			_ = c.Close()
+
+			if i%2 == 0 {
+				_ = c.Close()
+			}
+		}(i, c)
+	}
+
+	closeErr := make(chan error, 1)
+
+	go func() { closeErr <- ln.Close() }()
+
+	wg.Wait()
+
+	select {
+	case err := <-closeErr:
+		if err != nil {
+			t.Errorf("Close error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close didn't return after all connections closed")
+	}
+
+	if n := atomic.LoadUint64(&ln.connsCount); n != 0 {
+		t.Errorf("connsCount = %d, want 0", n)
+	}
+
+	for _, c := range clients {
+		_ = c.Close()
+	}
+}
+
+func TestGracefulListener_CloseTimeout(t *testing.T) {
+	t.Parallel()
+
+	ln := newTestGracefulListener(t, 50*time.Millisecond)
+
+	go func() { _, _ = ln.Accept() }()
+
+	c, err := net.Dial("tcp4", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial error: %v", err)
+	}
+
+	defer c.Close()
+
+	// wait for connection to be accepted
+	for i := 0; i < 100; i++ {
+		if atomic.LoadUint64(&ln.connsCount) == 1 {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	err = ln.Close()
+	if !errors.Is(err, pkgErr.ErrFHServerShutdown) {
+		t.Errorf("Close error = %v, want %v", err, pkgErr.ErrFHServerShutdown)
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "1 connections force closed") {
+		t.Errorf("Close error = %v, want force closed connections count", err)
+	}
+
+	// server side of the connection is force closed
+	_ = c.SetReadDeadline(time.Now().Add(time.Second))
+
+	if _, err := c.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("client read error = %v, want %v", err, io.EOF)
+	}
+}
+
+// keepAliveTestConn records TCP keep-alive settings.
+type keepAliveTestConn struct {
+	net.Conn
+	keepAlive bool
+	period    time.Duration
+}
+
+func (c *keepAliveTestConn) SetKeepAlive(keepalive bool) error {
+	c.keepAlive = keepalive
+
+	return nil
+}
+
+func (c *keepAliveTestConn) SetKeepAlivePeriod(d time.Duration) error {
+	c.period = d
+
+	return nil
+}
+
+// connsTestListener accepts the given connections.
+type connsTestListener struct {
+	net.Listener
+	conns chan net.Conn
+}
+
+func (ln *connsTestListener) Accept() (net.Conn, error) {
+	c, ok := <-ln.conns
+	if !ok {
+		return nil, net.ErrClosed
+	}
+
+	return c, nil
+}
+
+func TestGracefulListener_keepAlive(t *testing.T) {
+	t.Parallel()
+
+	tcpConn, _ := net.Pipe()
+	unixConn, _ := net.Pipe()
+
+	kc := &keepAliveTestConn{Conn: tcpConn}
+
+	conns := make(chan net.Conn, 2)
+	conns <- kc
+	conns <- unixConn
+
+	s := New(cfgstructs.WebServer{}, WithTCPKeepalive(true), WithTCPKeepalivePeriod(time.Minute))
+	ln := s.newGracefulListener(&connsTestListener{conns: conns})
+
+	for i := 0; i < 2; i++ {
+		c, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("Accept error: %v", err)
+		}
+
+		_ = c.Close()
+	}
+
+	if !kc.keepAlive || kc.period != time.Minute {
+		t.Errorf("keep-alive = %v, period = %s, want true, %s", kc.keepAlive, kc.period, time.Minute)
+	}
+
+	// keep-alive is off by default
+	kc = &keepAliveTestConn{Conn: tcpConn}
+	conns <- kc
+
+	if _, err := New(cfgstructs.WebServer{}).newGracefulListener(&connsTestListener{conns: conns}).Accept(); err != nil {
+		t.Fatalf("Accept error: %v", err)
+	}
+
+	if kc.keepAlive || kc.period != 0 {
+		t.Errorf("keep-alive = %v, period = %s, want it untouched", kc.keepAlive, kc.period)
+	}
+}
+
+func TestGracefulListener_keepAliveTCP(t *testing.T) {
+	t.Parallel()
+
+	s := New(cfgstructs.WebServer{}, WithTCPKeepalive(true), WithTCPKeepalivePeriod(time.Minute))
+
+	tcp, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen error: %v", err)
+	}
+
+	ln := s.newGracefulListener(tcp)
+	defer ln.Close()
+
+	go func() {
+		if c, err := net.Dial("tcp4", tcp.Addr().String()); err == nil {
+			defer c.Close()
+		}
+	}()
+
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("Accept error: %v", err)
+	}
+
+	defer c.Close()
+
+	rc, err := c.(*gracefulConn).Conn.(*net.TCPConn).SyscallConn()
+	if err != nil {
+		t.Fatalf("SyscallConn error: %v", err)
+	}
+
+	var idle int
+
+	_ = rc.Control(func(fd uintptr) {
+		idle, err = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE)
+	})
+
+	if err != nil || idle != int(time.Minute/time.Second) {
+		t.Errorf("TCP_KEEPIDLE = %d, error: %v, want %d", idle, err, int(time.Minute/time.Second))
+	}
+}
@@ -0,0 +1,37 @@
+package fhserver
+
+import (
+	"os"
+	"syscall"
+)
+
+// HandleSignal registers fn to be called when the running server receives sig.
+// The server keeps serving after fn returns. Registering SIGINT or SIGTERM
+// overrides the default graceful shutdown on that signal.
+func (s *Server) HandleSignal(sig os.Signal, fn func()) *Server {
+	if s.signalHandlers == nil {
+		s.signalHandlers = make(map[os.Signal]func())
+	}
+
+	s.signalHandlers[sig] = fn
+
+	return s
+}
+
+// signals returns the list of signals the server listens to.
+func (s *Server) signals() []os.Signal {
+	sigs := []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+
+	// prefork parent only forwards signals to children
+	if s.upgrade && !s.prefork && upgradeSignal != nil {
+		sigs = append(sigs, upgradeSignal)
+	}
+
+	for sig := range s.signalHandlers {
+		if sig != syscall.SIGINT && sig != syscall.SIGTERM {
+			sigs = append(sigs, sig)
+		}
+	}
+
+	return sigs
+}
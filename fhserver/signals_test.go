@@ -0,0 +1,53 @@
+package fhserver
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+)
+
+func TestServer_HandleSignal(t *testing.T) {
+	t.Parallel()
+
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Port: 0}
+	cfg.Timeouts.Shutdown = time.Second
+
+	called := make(chan struct{}, 2)
+
+	s := testServer(t, cfg).HandleSignal(syscall.SIGUSR1, func() { called <- struct{}{} })
+	s.SetRouter(testRouter())
+
+	done := make(chan error, 1)
+
+	go func() { done <- s.Run(nil) }()
+
+	<-s.Ready()
+
+	for i := 0; i < 2; i++ {
+		if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatalf("syscall.Kill error: %v", err)
+		}
+
+		select {
+		case <-called:
+		case <-time.After(time.Second):
+			t.Fatal("signal handler was not called")
+		}
+	}
+
+	select {
+	case err := <-done:
+		t.Fatalf("server must keep serving after custom signal, Run returned %v", err)
+	default:
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop error: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("Run error: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package fhserver
+
+import (
+	"bufio"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const defaultSSEHeartbeat = 15 * time.Second
+
+// SSEOption configures SSE stream.
+type SSEOption func(o *sseOptions)
+
+type sseOptions struct {
+	heartbeat time.Duration
+}
+
+// SSEHeartbeat sets interval of heartbeat comments keeping idle connection open, 15s by default.
+// Zero interval turns heartbeat off.
+func SSEHeartbeat(d time.Duration) SSEOption {
+	return func(o *sseOptions) {
+		o.heartbeat = d
+	}
+}
+
+// SSEWriter writes Server-Sent Events to the client.
+type SSEWriter struct {
+	mu sync.Mutex
+	w  *bufio.Writer
+
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+// Event writes event with JSON encoded data and flushes it to the client. Empty name means
+// default "message" event. It returns error once the client is disconnected.
+func (w *SSEWriter) Event(name string, data interface{}) error {
+	b, err := jsonEngine.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if name != "" {
+		_, _ = w.w.WriteString("event: " + name + "\n")
+	}
+
+	_, _ = w.w.WriteString("data: ")
+	_, _ = w.w.Write(b)
+	_, _ = w.w.WriteString("\n\n")
+
+	return w.flush()
+}
+
+// Done returns a channel which is closed when the client is disconnected.
+func (w *SSEWriter) Done() <-chan struct{} {
+	return w.done
+}
+
+func (w *SSEWriter) comment(text string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, _ = w.w.WriteString(": " + text + "\n\n")
+
+	return w.flush()
+}
+
+// flush sends buffered data, write error means the client is disconnected.
+func (w *SSEWriter) flush() error {
+	if err := w.w.Flush(); err != nil {
+		w.doneOnce.Do(func() { close(w.done) })
+
+		return err
+	}
+
+	return nil
+}
+
+// SSE responds with Server-Sent Events stream written by stream. Response isn't compressed or
+// buffered. Client disconnect is detected on writing, stream should return once SSEWriter.Done
+// is closed. stream error is logged with server logger. Server WriteTimeout limits stream duration.
+func SSE(ctx *fasthttp.RequestCtx, stream func(w *SSEWriter) error, opts ...SSEOption) {
+	o := sseOptions{heartbeat: defaultSSEHeartbeat}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	skipCompression(ctx)
+
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "no-cache")
+	// disables nginx response buffering
+	ctx.Response.Header.Set("X-Accel-Buffering", "no")
+
+	// request context isn't available while streaming
+	logger := requestLogger(ctx)
+	path := string(ctx.Path())
+
+	ctx.SetBodyStreamWriter(func(bw *bufio.Writer) {
+		w := &SSEWriter{w: bw, done: make(chan struct{})}
+
+		stop := make(chan struct{})
+		wg := &sync.WaitGroup{}
+
+		if o.heartbeat > 0 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				ticker := time.NewTicker(o.heartbeat)
+				defer ticker.Stop()
+
+				for {
+					select {
+					case <-stop:
+						return
+					case <-ticker.C:
+						if err := w.comment("heartbeat"); err != nil {
+							return
+						}
+					}
+				}
+			}()
+		}
+
+		err := stream(w)
+
+		close(stop)
+		wg.Wait()
+
+		if err != nil && logger != nil {
+			logger.Warn().Err(err).Str("path", path).Msg("sse stream error")
+		}
+	})
+}
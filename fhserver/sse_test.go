@@ -0,0 +1,103 @@
+package fhserver
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestSSE(t *testing.T) {
+	t.Parallel()
+
+	disconnected := make(chan struct{})
+
+	r := router.New()
+	r.GET("/events", func(ctx *fasthttp.RequestCtx) {
+		SSE(ctx, func(w *SSEWriter) error {
+			for i := 1; i <= 3; i++ {
+				if err := w.Event("progress", map[string]int{"done": i}); err != nil {
+					return err
+				}
+			}
+
+			if err := w.Event("", "finished"); err != nil {
+				return err
+			}
+
+			// heartbeats detect disconnect
+			<-w.Done()
+			close(disconnected)
+
+			return nil
+		}, SSEHeartbeat(20*time.Millisecond))
+	})
+
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Compress: true}
+	cfg.Timeouts.Shutdown = time.Second
+
+	s := New(cfg, WithCompressMinSize(0)).SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	go func() { _ = s.Run(nil) }()
+
+	t.Cleanup(func() { _ = s.Stop() })
+
+	<-s.Ready()
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+s.Addr().String()+"/events", nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest error: %v", err)
+	}
+
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+		t.Errorf("Content-Encoding = %q, want none", enc)
+	}
+
+	lines := make([]string, 0)
+	sc := bufio.NewScanner(resp.Body)
+
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+
+		if sc.Text() == ": heartbeat" {
+			break
+		}
+	}
+
+	want := []string{
+		"event: progress", `data: {"done":1}`, "",
+		"event: progress", `data: {"done":2}`, "",
+		"event: progress", `data: {"done":3}`, "",
+		`data: "finished"`, "",
+		": heartbeat",
+	}
+
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("stream = %q, want %q", lines, want)
+	}
+
+	_ = resp.Body.Close()
+
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second):
+		t.Error("stream isn't terminated on client disconnect")
+	}
+}
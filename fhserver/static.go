@@ -0,0 +1,152 @@
+package fhserver
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fasthttp/router"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+const defaultStaticIndex = "index.html"
+
+// StaticOptions configures Static files serving.
+type StaticOptions struct {
+	// directory index file name, "index.html" by default
+	Index string
+	// serve index file for unknown paths without extension, for single page applications
+	SPA bool
+	// Cache-Control max-age of files, index file is always revalidated
+	MaxAge time.Duration
+}
+
+// Static serves files from dir under urlPrefix. Files have weak ETag and Last-Modified headers,
+// conditional and range requests are supported. Paths with ".." segments are answered with 404.
+// Files aren't compressed on disk, responses are compressed by the server if compression is enabled.
+func Static(r *router.Router, urlPrefix, dir string, opts StaticOptions) {
+	if opts.Index == "" {
+		opts.Index = defaultStaticIndex
+	}
+
+	prefix := strings.TrimSuffix(urlPrefix, "/")
+
+	notFound := func(ctx *fasthttp.RequestCtx) {
+		JSON(ctx, pkgErr.ErrNotFound)
+	}
+
+	// serves index file for any path
+	index := (&fasthttp.FS{
+		Root:         dir,
+		PathRewrite:  func(*fasthttp.RequestCtx) []byte { return []byte("/" + opts.Index) },
+		PathNotFound: notFound,
+	}).NewRequestHandler()
+
+	files := (&fasthttp.FS{
+		Root:            dir,
+		IndexNames:      []string{opts.Index},
+		AcceptByteRange: true,
+		PathRewrite:     fasthttp.NewPathSlashesStripper(strings.Count(prefix, "/")),
+		PathNotFound: func(ctx *fasthttp.RequestCtx) {
+			if opts.SPA && path.Ext(string(ctx.Path())) == "" {
+				index(ctx)
+
+				return
+			}
+
+			notFound(ctx)
+		},
+	}).NewRequestHandler()
+
+	h := func(ctx *fasthttp.RequestCtx) {
+		if hasDotDotSegment(ctx.Request.URI().PathOriginal()) || hasDotDotSegment(ctx.Path()) {
+			notFound(ctx)
+
+			return
+		}
+
+		files(ctx)
+		setStaticCacheHeaders(ctx, opts)
+	}
+
+	r.GET(prefix+"/{filepath:*}", h)
+	r.HEAD(prefix+"/{filepath:*}", h)
+}
+
+// hasDotDotSegment reports whether p has ".." segment, including percent-encoded one.
+func hasDotDotSegment(p []byte) bool {
+	p = bytes.ToLower(p)
+	p = bytes.ReplaceAll(p, []byte("%2e"), []byte("."))
+	p = bytes.ReplaceAll(p, []byte("%2f"), []byte("/"))
+	p = bytes.ReplaceAll(p, []byte("%5c"), []byte("/"))
+	p = bytes.ReplaceAll(p, []byte(`\`), []byte("/"))
+
+	for _, segment := range bytes.Split(p, []byte("/")) {
+		if bytes.Equal(segment, []byte("..")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setStaticCacheHeaders sets Cache-Control and ETag headers of file response and answers 304
+// if If-None-Match header matches ETag.
+func setStaticCacheHeaders(ctx *fasthttp.RequestCtx, opts StaticOptions) {
+	status := ctx.Response.StatusCode()
+	if status != fasthttp.StatusOK && status != fasthttp.StatusPartialContent && status != fasthttp.StatusNotModified {
+		return
+	}
+
+	cacheControl := "no-cache"
+	if p := string(ctx.Path()); opts.MaxAge > 0 && !strings.HasSuffix(p, "/") && path.Base(p) != opts.Index &&
+		path.Ext(p) != "" {
+		cacheControl = fmt.Sprintf("public, max-age=%d", int(opts.MaxAge.Seconds()))
+	}
+
+	ctx.Response.Header.Set(fasthttp.HeaderCacheControl, cacheControl)
+
+	etag, ok := staticETag(&ctx.Response)
+	if !ok {
+		return
+	}
+
+	ctx.Response.Header.SetBytesV(fasthttp.HeaderETag, etag)
+
+	if status == fasthttp.StatusOK && etagMatch(ctx.Request.Header.Peek(fasthttp.HeaderIfNoneMatch), etag) {
+		ctx.Response.ResetBody()
+		ctx.Response.SetStatusCode(fasthttp.StatusNotModified)
+	}
+}
+
+// staticETag returns weak ETag built from file modification time and size.
+func staticETag(resp *fasthttp.Response) ([]byte, bool) {
+	lastModified, err := fasthttp.ParseHTTPDate(resp.Header.Peek(fasthttp.HeaderLastModified))
+	if err != nil {
+		return nil, false
+	}
+
+	size := resp.Header.ContentLength()
+
+	// partial content has the file size after slash, e.g. "bytes 0-3/42"
+	if contentRange := resp.Header.Peek(fasthttp.HeaderContentRange); len(contentRange) > 0 {
+		i := bytes.LastIndexByte(contentRange, '/')
+		if i < 0 {
+			return nil, false
+		}
+
+		if size, err = strconv.Atoi(string(contentRange[i+1:])); err != nil {
+			return nil, false
+		}
+	}
+
+	if size < 0 {
+		return nil, false
+	}
+
+	return []byte(fmt.Sprintf(`W/"%x-%x"`, lastModified.Unix(), size)), true
+}
@@ -0,0 +1,175 @@
+package fhserver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestStatic(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	root := filepath.Join(base, "public")
+
+	files := map[string]string{
+		filepath.Join(base, "secret.txt"):  "secret",
+		filepath.Join(root, "index.html"):  "<html>app</html>",
+		filepath.Join(root, "app.js"):      strings.Repeat("console.log('app');\n", 100),
+		filepath.Join(root, "img/logo.js"): "logo",
+	}
+
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatalf("MkdirAll error: %v", err)
+		}
+
+		if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile error: %v", err)
+		}
+	}
+
+	r := router.New()
+	Static(r, "/static/", root, StaticOptions{SPA: true, MaxAge: time.Hour})
+
+	s := New(cfgstructs.WebServer{Compress: true}).SetLogger(testLogger(t, nil))
+	s.SetRouter(r)
+
+	client := serveInmemory(t, s)
+
+	get := func(t *testing.T, uri string, headers ...string) *fasthttp.Response {
+		t.Helper()
+
+		req := newRequest("GET", "http://test"+uri)
+		req.URI().DisablePathNormalizing = true
+
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+
+		resp := &fasthttp.Response{}
+		if err := client.Do(req, resp); err != nil {
+			t.Fatalf("GET %s error: %v", uri, err)
+		}
+
+		return resp
+	}
+
+	t.Run("files", func(t *testing.T) {
+		t.Parallel()
+
+		tcs := []struct {
+			uri          string
+			want         string
+			cacheControl string
+		}{
+			{uri: "/static/app.js", want: files[filepath.Join(root, "app.js")], cacheControl: "public, max-age=3600"},
+			{uri: "/static/img/logo.js", want: "logo", cacheControl: "public, max-age=3600"},
+			{uri: "/static/", want: "<html>app</html>", cacheControl: "no-cache"},
+			{uri: "/static/users/42", want: "<html>app</html>", cacheControl: "no-cache"},
+		}
+
+		for _, tc := range tcs {
+			resp := get(t, tc.uri)
+
+			if resp.StatusCode() != fasthttp.StatusOK {
+				t.Errorf("%s: status code = %d, want %d", tc.uri, resp.StatusCode(), fasthttp.StatusOK)
+
+				continue
+			}
+
+			if string(resp.Body()) != tc.want {
+				t.Errorf("%s: body = %q, want %q", tc.uri, resp.Body(), tc.want)
+			}
+
+			if got := string(resp.Header.Peek(fasthttp.HeaderCacheControl)); got != tc.cacheControl {
+				t.Errorf("%s: Cache-Control = %q, want %q", tc.uri, got, tc.cacheControl)
+			}
+
+			if etag := resp.Header.Peek(fasthttp.HeaderETag); !strings.HasPrefix(string(etag), `W/"`) {
+				t.Errorf("%s: ETag = %q, want weak ETag", tc.uri, etag)
+			}
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		for _, uri := range []string{
+			"/static/missing.js",
+			"/static/../secret.txt",
+			"/static/..%2fsecret.txt",
+			"/static/img/%2e%2e/%2e%2e/secret.txt",
+			"/secret.txt",
+		} {
+			resp := get(t, uri)
+
+			if resp.StatusCode() != fasthttp.StatusNotFound {
+				t.Errorf("%s: status code = %d, want %d", uri, resp.StatusCode(), fasthttp.StatusNotFound)
+			}
+
+			if string(resp.Body()) == "secret" {
+				t.Errorf("%s: file outside the root is served", uri)
+			}
+		}
+	})
+
+	t.Run("conditional", func(t *testing.T) {
+		t.Parallel()
+
+		resp := get(t, "/static/app.js")
+		etag := string(resp.Header.Peek(fasthttp.HeaderETag))
+		lastModified := string(resp.Header.Peek(fasthttp.HeaderLastModified))
+
+		if resp := get(t, "/static/app.js", fasthttp.HeaderIfNoneMatch, etag); resp.StatusCode() != fasthttp.StatusNotModified {
+			t.Errorf("If-None-Match: status code = %d, want %d", resp.StatusCode(), fasthttp.StatusNotModified)
+		}
+
+		if resp := get(t, "/static/app.js", fasthttp.HeaderIfModifiedSince, lastModified); resp.StatusCode() != fasthttp.StatusNotModified {
+			t.Errorf("If-Modified-Since: status code = %d, want %d", resp.StatusCode(), fasthttp.StatusNotModified)
+		}
+
+		if resp := get(t, "/static/app.js", fasthttp.HeaderIfNoneMatch, `W/"stale"`); resp.StatusCode() != fasthttp.StatusOK {
+			t.Errorf("stale If-None-Match: status code = %d, want %d", resp.StatusCode(), fasthttp.StatusOK)
+		}
+	})
+
+	t.Run("range", func(t *testing.T) {
+		t.Parallel()
+
+		resp := get(t, "/static/app.js", fasthttp.HeaderRange, "bytes=0-6", fasthttp.HeaderAcceptEncoding, "gzip")
+
+		if resp.StatusCode() != fasthttp.StatusPartialContent {
+			t.Fatalf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusPartialContent)
+		}
+
+		if string(resp.Body()) != "console" {
+			t.Errorf("body = %q, want %q", resp.Body(), "console")
+		}
+	})
+
+	t.Run("compressed", func(t *testing.T) {
+		t.Parallel()
+
+		resp := get(t, "/static/app.js", fasthttp.HeaderAcceptEncoding, "gzip")
+
+		if enc := string(resp.Header.Peek(fasthttp.HeaderContentEncoding)); enc != "gzip" {
+			t.Fatalf("Content-Encoding = %q, want gzip", enc)
+		}
+
+		body, err := resp.BodyGunzip()
+		if err != nil {
+			t.Fatalf("BodyGunzip error: %v", err)
+		}
+
+		if string(body) != files[filepath.Join(root, "app.js")] {
+			t.Error("decompressed body differs from the file")
+		}
+	})
+}
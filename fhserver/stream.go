@@ -0,0 +1,112 @@
+package fhserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/valyala/fasthttp"
+)
+
+// UserValueRequestBodyReader is a user value key holding decompressed streamed request body reader.
+const UserValueRequestBodyReader = "fhserver.requestBodyReader"
+
+// RequestBodyReader returns request body as io.Reader. In streaming mode body isn't
+// loaded into memory and is decompressed on the fly by DecompressRequestHandler.
+func RequestBodyReader(ctx *fasthttp.RequestCtx) io.Reader {
+	if r, ok := ctx.UserValue(UserValueRequestBodyReader).(io.Reader); ok {
+		return r
+	}
+
+	if ctx.Request.IsBodyStream() {
+		return ctx.RequestBodyStream()
+	}
+
+	return bytes.NewReader(ctx.PostBody())
+}
+
+// decompressBodyStream wraps streamed request body with a decompressing reader returning
+// fasthttp.ErrBodyTooLarge after limit bytes, zero limit means no limit. Content-Length header
+// is kept, fasthttp reads the stream according to it. Returned decompressing reader must be
+// released with closeDecoder once the body is read.
+func decompressBodyStream(ctx *fasthttp.RequestCtx, limit int) (io.Reader, error) {
+	r, err := decompressReader(&ctx.Request.Header, RequestBodyReader(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("decompressBodyStream error: %w", err)
+	}
+
+	if r == nil {
+		return nil, nil
+	}
+
+	body := r
+	if limit > 0 {
+		body = newLimitedReader(r, limit)
+	}
+
+	ctx.SetUserValue(UserValueRequestBodyReader, body)
+	ctx.Request.Header.Del(fasthttp.HeaderContentEncoding)
+
+	return r, nil
+}
+
+// decompressReader wraps r with decompressing readers according to Content-Encoding header.
+// Codings are listed in the order they were applied, so decoders are chained in reverse order.
+// It returns nil reader if the body isn't compressed. Reader must be released with closeDecoder.
+func decompressReader(h *fasthttp.RequestHeader, r io.Reader) (io.Reader, error) {
+	encodings := contentEncodings(h)
+	chain := &decoderChain{Reader: r}
+
+	for i := len(encodings) - 1; i >= 0; i-- {
+		d, err := newDecoder(encodings[i], chain.Reader)
+		if err != nil {
+			_ = chain.Close()
+
+			return nil, fmt.Errorf("%s decoder error: %w", encodings[i], err)
+		}
+
+		if d != nil {
+			chain.Reader = d
+			chain.stages = append(chain.stages, d)
+		}
+	}
+
+	if len(chain.stages) == 0 {
+		return nil, nil
+	}
+
+	return chain, nil
+}
+
+// newDecoder returns reader decoding r encoded with encoding or nil reader for identity.
+func newDecoder(encoding string, r io.Reader) (io.Reader, error) {
+	switch encoding {
+	case "gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		return zlib.NewReader(r)
+	case "br":
+		return brotli.NewReader(r), nil
+	case "zstd":
+		return newZstdReader(r)
+	default:
+		return nil, nil
+	}
+}
+
+// decoderChain reads the last decoding stage and releases all of them on Close.
+type decoderChain struct {
+	io.Reader
+	stages []io.Reader
+}
+
+func (c *decoderChain) Close() error {
+	for _, stage := range c.stages {
+		closeDecoder(stage)
+	}
+
+	return nil
+}
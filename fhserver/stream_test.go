@@ -0,0 +1,73 @@
+package fhserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRequestBodyReader_stream(t *testing.T) {
+	t.Parallel()
+
+	s := New(cfgstructs.WebServer{Compress: true}, WithMaxRequestBodySize(1024), WithStreamRequestBody(true),
+		WithDecompressionFactor(0)).SetLogger(testLogger(t, nil))
+
+	r := router.New()
+	r.POST("/upload", func(ctx *fasthttp.RequestCtx) {
+		n, err := io.Copy(io.Discard, RequestBodyReader(ctx))
+		if err != nil {
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		}
+
+		ctx.SetBodyString(strconv.FormatInt(n, 10))
+	})
+	s.SetRouter(r)
+
+	client := serveInmemory(t, s)
+	payload := bytes.Repeat([]byte("0123456789"), 10*1024)
+
+	var gz bytes.Buffer
+
+	w := gzip.NewWriter(&gz)
+	_, _ = w.Write(payload)
+	_ = w.Close()
+
+	type testCase struct {
+		name     string
+		body     []byte
+		encoding string
+	}
+
+	tcs := []testCase{
+		{name: "plain", body: payload},
+		{name: "gzip", body: gz.Bytes(), encoding: "gzip"},
+	}
+
+	for _, tc := range tcs {
+		req := newRequest("POST", "http://test/upload")
+		req.SetBody(tc.body)
+
+		if tc.encoding != "" {
+			req.Header.Set(fasthttp.HeaderContentEncoding, tc.encoding)
+		}
+
+		resp := &fasthttp.Response{}
+		if err := client.Do(req, resp); err != nil {
+			t.Fatalf("%s: request error: %v", tc.name, err)
+		}
+
+		if resp.StatusCode() != fasthttp.StatusOK {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), fasthttp.StatusOK)
+		}
+
+		if got := string(resp.Body()); got != strconv.Itoa(len(payload)) {
+			t.Errorf("%s: read %s bytes, want %d", tc.name, got, len(payload))
+		}
+	}
+}
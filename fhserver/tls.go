@@ -0,0 +1,37 @@
+package fhserver
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// SetTLS loads certificate/key pair from files and turns on HTTPS serving.
+func (s *Server) SetTLS(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("SetTLS tls.LoadX509KeyPair error: %w", err)
+	}
+
+	s.SetTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+
+	return nil
+}
+
+// SetTLSFromPEM loads certificate/key pair from PEM blocks and turns on HTTPS serving.
+func (s *Server) SetTLSFromPEM(certPEM, keyPEM []byte) error {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("SetTLSFromPEM tls.X509KeyPair error: %w", err)
+	}
+
+	s.SetTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+
+	return nil
+}
+
+// SetTLSConfig sets TLS config used to serve HTTPS. Nil config turns TLS off.
+func (s *Server) SetTLSConfig(cfg *tls.Config) *Server {
+	s.tlsConfig = cfg
+
+	return s
+}
@@ -0,0 +1,146 @@
+package fhserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert issues certificate signed by parent (self-signed if parent is nil).
+func newTestCert(t *testing.T, cn string, parent *testCert, isCA bool) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey error: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+
+	signerCert, signerKey := tmpl, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate error: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate error: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("x509.MarshalECPrivateKey error: %v", err)
+	}
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func TestServer_SetTLS(t *testing.T) {
+	t.Parallel()
+
+	s := New(cfgstructs.WebServer{})
+
+	if err := s.SetTLS("not-exists.crt", "not-exists.key"); err == nil {
+		t.Error("SetTLS with missing files must return error")
+	}
+
+	if err := s.SetTLSFromPEM([]byte("bad"), []byte("bad")); err == nil {
+		t.Error("SetTLSFromPEM with bad PEM must return error")
+	}
+
+	if s.tlsConfig != nil {
+		t.Error("tls config must stay nil after errors")
+	}
+
+	c := newTestCert(t, "server", nil, false)
+	if err := s.SetTLSFromPEM(c.certPEM, c.keyPEM); err != nil {
+		t.Fatalf("SetTLSFromPEM error: %v", err)
+	}
+
+	if s.tlsConfig == nil || len(s.tlsConfig.Certificates) != 1 {
+		t.Error("tls config must contain loaded certificate")
+	}
+}
+
+func TestServer_TLSOverGracefulListener(t *testing.T) {
+	t.Parallel()
+
+	c := newTestCert(t, "server", nil, false)
+
+	s := New(cfgstructs.WebServer{})
+	if err := s.SetTLSFromPEM(c.certPEM, c.keyPEM); err != nil {
+		t.Fatalf("SetTLSFromPEM error: %v", err)
+	}
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen error: %v", err)
+	}
+
+	graceful := newGracefulListener(ln, time.Second, nil)
+	srv := &fasthttp.Server{Handler: func(ctx *fasthttp.RequestCtx) {
+		if !ctx.IsTLS() {
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		}
+	}}
+
+	go func() { _ = srv.Serve(tls.NewListener(graceful, s.tlsConfig)) }()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(c.cert)
+
+	client := &fasthttp.Client{TLSConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}}
+
+	code, _, err := client.Get(nil, "https://"+ln.Addr().String()+"/")
+	if err != nil {
+		t.Fatalf("https request error: %v", err)
+	}
+
+	if code != fasthttp.StatusOK {
+		t.Errorf("status code = %d, want %d", code, fasthttp.StatusOK)
+	}
+
+	client.CloseIdleConnections()
+
+	if err := graceful.Close(); err != nil {
+		t.Errorf("graceful close error: %v", err)
+	}
+}
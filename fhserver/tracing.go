@@ -0,0 +1,92 @@
+package fhserver
+
+import (
+	"context"
+
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// UserValueTraceContext is a user value key holding context.Context with the request span.
+	UserValueTraceContext = "fhserver.traceContext"
+
+	tracerName = "github.com/spacetab-io/http-go/fhserver"
+)
+
+// WithTracerProvider turns on tracing: every request gets a server span, W3C trace context
+// is taken from traceparent/tracestate headers. Span is named by the matched route
+// when router.SaveMatchedRoutePath is set before routes registration.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *Server) {
+		s.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// TraceContext returns context with the request span to be passed to the downstream calls.
+// It returns context.Background() if tracing is off.
+func TraceContext(ctx *fasthttp.RequestCtx) context.Context {
+	if c, ok := ctx.UserValue(UserValueTraceContext).(context.Context); ok {
+		return c
+	}
+
+	return context.Background()
+}
+
+// fasthttpHeaderCarrier adapts fasthttp request header to propagation.TextMapCarrier.
+type fasthttpHeaderCarrier struct {
+	h *fasthttp.RequestHeader
+}
+
+func (c fasthttpHeaderCarrier) Get(key string) string {
+	return string(c.h.Peek(key))
+}
+
+func (c fasthttpHeaderCarrier) Set(key, value string) {
+	c.h.Set(key, value)
+}
+
+func (c fasthttpHeaderCarrier) Keys() []string {
+	keys := make([]string, 0)
+
+	c.h.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+
+	return keys
+}
+
+func tracingMiddleware(next fasthttp.RequestHandler, tracer trace.Tracer) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		parent := propagation.TraceContext{}.Extract(context.Background(), fasthttpHeaderCarrier{h: &ctx.Request.Header})
+
+		method := string(ctx.Method())
+
+		spanCtx, span := tracer.Start(parent, "HTTP "+method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(method),
+				semconv.HTTPTargetKey.String(string(ctx.RequestURI())),
+				semconv.HTTPUserAgentKey.String(string(ctx.UserAgent())),
+				semconv.NetPeerIPKey.String(ctx.RemoteIP().String()),
+			),
+		)
+		defer span.End()
+
+		ctx.SetUserValue(UserValueTraceContext, spanCtx)
+
+		next(ctx)
+
+		if route, ok := ctx.UserValue(router.MatchedRoutePathParam).(string); ok {
+			span.SetName(route)
+			span.SetAttributes(semconv.HTTPRouteKey.String(route))
+		}
+
+		code := ctx.Response.StatusCode()
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(code))
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(code, trace.SpanKindServer))
+	}
+}
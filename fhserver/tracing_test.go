@@ -0,0 +1,99 @@
+package fhserver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWithTracerProvider(t *testing.T) {
+	t.Parallel()
+
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	var handlerSpan trace.SpanContext
+
+	r := router.New()
+	r.SaveMatchedRoutePath = true
+	r.GET("/users/{id}", func(ctx *fasthttp.RequestCtx) {
+		handlerSpan = trace.SpanContextFromContext(TraceContext(ctx))
+
+		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+	})
+
+	buf := &syncBuffer{}
+
+	s := New(cfgstructs.WebServer{}, WithTracerProvider(tp)).SetLogger(testLogger(t, buf))
+	s.SetRouter(r)
+
+	req := newRequest("GET", "http://test/users/42")
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+
+	doRequest(s.httpServer.Handler, req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("spans len = %d, want 1", len(spans))
+	}
+
+	span := spans[0]
+
+	if span.Name != "/users/{id}" {
+		t.Errorf("span name = %q, want %q", span.Name, "/users/{id}")
+	}
+
+	if span.SpanKind != trace.SpanKindServer {
+		t.Errorf("span kind = %v, want %v", span.SpanKind, trace.SpanKindServer)
+	}
+
+	if got := span.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("trace id = %s, want %s", got, traceID)
+	}
+
+	if got := span.Parent.SpanID().String(); got != parentSpanID {
+		t.Errorf("parent span id = %s, want %s", got, parentSpanID)
+	}
+
+	if handlerSpan.SpanID() != span.SpanContext.SpanID() {
+		t.Error("handler must get the request span in TraceContext")
+	}
+
+	if span.Status.Code != codes.Error {
+		t.Errorf("span status = %v, want %v", span.Status.Code, codes.Error)
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+
+	if got := attrs["http.status_code"].AsInt64(); got != fasthttp.StatusInternalServerError {
+		t.Errorf("http.status_code = %d, want %d", got, fasthttp.StatusInternalServerError)
+	}
+
+	if got := attrs["http.method"].AsString(); got != "GET" {
+		t.Errorf("http.method = %q, want %q", got, "GET")
+	}
+
+	if got := attrs["http.route"].AsString(); got != "/users/{id}" {
+		t.Errorf("http.route = %q, want %q", got, "/users/{id}")
+	}
+
+	if !strings.Contains(buf.String(), traceID) {
+		t.Errorf("log must contain trace_id %s, got:\n%s", traceID, buf.String())
+	}
+}
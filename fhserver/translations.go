@@ -0,0 +1,118 @@
+package fhserver
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/locales"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	esTranslations "github.com/go-playground/validator/v10/translations/es"
+	faTranslations "github.com/go-playground/validator/v10/translations/fa"
+	frTranslations "github.com/go-playground/validator/v10/translations/fr"
+	idTranslations "github.com/go-playground/validator/v10/translations/id"
+	jaTranslations "github.com/go-playground/validator/v10/translations/ja"
+	nlTranslations "github.com/go-playground/validator/v10/translations/nl"
+	ptTranslations "github.com/go-playground/validator/v10/translations/pt"
+	ptBRTranslations "github.com/go-playground/validator/v10/translations/pt_BR"
+	ruTranslations "github.com/go-playground/validator/v10/translations/ru"
+	trTranslations "github.com/go-playground/validator/v10/translations/tr"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+	zhTwTranslations "github.com/go-playground/validator/v10/translations/zh_tw"
+)
+
+// validatorDefaultTranslations registers standard validator translations by locale.
+var validatorDefaultTranslations = map[string]func(v *validator.Validate, trans ut.Translator) error{
+	"en":         enTranslations.RegisterDefaultTranslations,
+	"es":         esTranslations.RegisterDefaultTranslations,
+	"fa":         faTranslations.RegisterDefaultTranslations,
+	"fr":         frTranslations.RegisterDefaultTranslations,
+	"id":         idTranslations.RegisterDefaultTranslations,
+	"ja":         jaTranslations.RegisterDefaultTranslations,
+	"nl":         nlTranslations.RegisterDefaultTranslations,
+	"pt":         ptTranslations.RegisterDefaultTranslations,
+	"pt_BR":      ptBRTranslations.RegisterDefaultTranslations,
+	"ru":         ruTranslations.RegisterDefaultTranslations,
+	"tr":         trTranslations.RegisterDefaultTranslations,
+	"zh":         zhTranslations.RegisterDefaultTranslations,
+	"zh_Hant_TW": zhTwTranslations.RegisterDefaultTranslations,
+}
+
+// validatorTranslators holds translators registered with UseValidatorTranslations by language tag.
+var validatorTranslators struct {
+	sync.RWMutex
+	byLang map[string]ut.Translator
+}
+
+// UseValidatorTranslations registers standard validator translations of translators' locales
+// in v, e.g. en.New() and ru.New() of github.com/go-playground/locales. Validation errors of v
+// are then rendered by the translator of ResolvedLang, validation messages (see
+// RegisterValidationMessages) are used for languages without translator and tags without
+// translation. Locales without standard translations, e.g. de, are reported with error.
+func UseValidatorTranslations(v *validator.Validate, translators ...locales.Translator) error {
+	if len(translators) == 0 {
+		return nil
+	}
+
+	uni := ut.New(translators[0], translators...)
+	byLang := make(map[string]ut.Translator, len(translators))
+
+	for _, l := range translators {
+		register, ok := validatorDefaultTranslations[l.Locale()]
+		if !ok {
+			return fmt.Errorf("UseValidatorTranslations error: no translations of locale %s", l.Locale())
+		}
+
+		trans, _ := uni.GetTranslator(l.Locale())
+
+		if err := register(v, trans); err != nil {
+			return fmt.Errorf("UseValidatorTranslations error: %w", err)
+		}
+
+		byLang[localeLang(l.Locale())] = trans
+	}
+
+	validatorTranslators.Lock()
+	defer validatorTranslators.Unlock()
+
+	if validatorTranslators.byLang == nil {
+		validatorTranslators.byLang = make(map[string]ut.Translator, len(byLang))
+	}
+
+	for lang, trans := range byLang {
+		validatorTranslators.byLang[lang] = trans
+	}
+
+	return nil
+}
+
+// validatorTranslator returns translator of lang registered with UseValidatorTranslations.
+func validatorTranslator(lang string) (ut.Translator, bool) {
+	validatorTranslators.RLock()
+	defer validatorTranslators.RUnlock()
+
+	trans, ok := validatorTranslators.byLang[lang]
+
+	return trans, ok
+}
+
+// translateValidationError returns message of e translated to lang, false if there is no
+// translator of lang or translation of the tag.
+func translateValidationError(e validator.FieldError, lang string) (string, bool) {
+	trans, ok := validatorTranslator(lang)
+	if !ok {
+		return "", false
+	}
+
+	// FieldError returns its Error() if there is no translation
+	msg := e.Translate(trans)
+
+	return msg, msg != e.Error()
+}
+
+// localeLang returns lower case language tag of locale, e.g. "pt-br" for "pt_BR".
+func localeLang(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+}
@@ -0,0 +1,114 @@
+package fhserver
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/locales/de"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	"github.com/go-playground/validator/v10"
+	errs "github.com/spacetab-io/errors-go"
+	"github.com/valyala/fasthttp"
+)
+
+// TestUseValidatorTranslations isn't parallel as translators are global.
+func TestUseValidatorTranslations(t *testing.T) {
+	t.Cleanup(func() {
+		validatorTranslators.Lock()
+		validatorTranslators.byLang = nil
+		validatorTranslators.Unlock()
+	})
+
+	v := validator.New()
+
+	if err := UseValidatorTranslations(v, en.New(), ru.New()); err != nil {
+		t.Fatalf("UseValidatorTranslations error: %v", err)
+	}
+
+	if err := UseValidatorTranslations(validator.New(), de.New()); err == nil {
+		t.Error("UseValidatorTranslations error for locale without translations = nil")
+	}
+
+	if err := v.RegisterValidation("even", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int()%2 == 0
+	}); err != nil {
+		t.Fatalf("RegisterValidation error: %v", err)
+	}
+
+	type form struct {
+		Name  string `validate:"required"`
+		Email string `validate:"email"`
+		Code  string `validate:"max=3"`
+		Count int    `validate:"even"`
+	}
+
+	validationErr := v.Struct(form{Email: "mail", Code: "abcd", Count: 1})
+
+	type testCase struct {
+		lang     string
+		wantLang string
+		want     map[errs.FieldName][]errs.ValidationError
+	}
+
+	tcs := []testCase{
+		{
+			lang:     "en-US",
+			wantLang: "en",
+			want: map[errs.FieldName][]errs.ValidationError{
+				"Name":  {"Name is a required field"},
+				"Email": {"Email must be a valid email address"},
+				"Code":  {"Code must be a maximum of 3 characters in length"},
+				// no translation of the tag
+				"Count": {"Validation error of property `Count` with rule `even`"},
+			},
+		},
+		{
+			lang:     "ru",
+			wantLang: "ru",
+			want: map[errs.FieldName][]errs.ValidationError{
+				"Name":  {"Name обязательное поле"},
+				"Email": {"Email должен быть email адресом"},
+				"Code":  {"Code должен содержать максимум 3 символа"},
+				"Count": {"Ошибка валидации для свойства `Count` с правилом `even`"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.Set(fasthttp.HeaderAcceptLanguage, tc.lang)
+
+		JSON(ctx, validationErr)
+
+		var body struct {
+			Error *errs.ErrorObject `json:"error"`
+		}
+
+		if err := json.Unmarshal(ctx.Response.Body(), &body); err != nil {
+			t.Fatalf("%s: body isn't JSON: %v", tc.lang, err)
+		}
+
+		if body.Error == nil || !reflect.DeepEqual(body.Error.Validation, tc.want) {
+			t.Errorf("%s: validation = %+v, want %+v", tc.lang, body.Error, tc.want)
+		}
+
+		if got := string(ctx.Response.Header.Peek(fasthttp.HeaderContentLanguage)); got != tc.wantLang {
+			t.Errorf("%s: Content-Language = %q, want %q", tc.lang, got, tc.wantLang)
+		}
+	}
+
+	// errors of other validators aren't translated
+	other := validator.New().Struct(struct {
+		Name string `validate:"required"`
+	}{})
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set(fasthttp.HeaderAcceptLanguage, "en")
+
+	JSON(ctx, other)
+
+	if body := string(ctx.Response.Body()); !strings.Contains(body, "Property `Name` is required") {
+		t.Errorf("body of other validator error = %s, want pattern message", body)
+	}
+}
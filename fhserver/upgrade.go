@@ -0,0 +1,78 @@
+package fhserver
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+const (
+	// number of listeners passed to the upgraded process, readiness pipe follows them
+	upgradeFDsEnv = "FHSERVER_UPGRADE_FDS"
+	// time given to the upgraded process to start serving
+	upgradeReadyTimeout = 30 * time.Second
+)
+
+// newUpgradeCmd returns command starting the upgraded process. Binary is looked up by os.Args[0]
+// to start the replaced one, os.Executable may point to the old deleted file.
+var newUpgradeCmd = func() *exec.Cmd {
+	return exec.Command(os.Args[0], os.Args[1:]...) //nolint:gosec
+}
+
+// EnableUpgrade turns on zero-downtime restart on SIGUSR2: the running server starts the current
+// binary again passing it listening sockets, waits until the new process is serving and then
+// gracefully stops, so Run returns nil. The old process keeps serving if the new one fails to start.
+// Upgrade isn't available in prefork mode and on Windows.
+func (s *Server) EnableUpgrade() *Server {
+	s.upgrade = true
+
+	return s
+}
+
+// upgradeListeners returns listeners passed by the upgraded process and keeps readiness pipe
+// to be notified by notifyUpgraded. Environment variable is removed, so processes started later
+// don't inherit it.
+func (s *Server) upgradeListeners() ([]net.Listener, error) {
+	v, ok := os.LookupEnv(upgradeFDsEnv)
+	if !ok {
+		return nil, nil
+	}
+
+	_ = os.Unsetenv(upgradeFDsEnv)
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("bad %s value %q", upgradeFDsEnv, v)
+	}
+
+	lns, err := fileListeners(listenFDsStart, n, "upgrade")
+	if err != nil {
+		return nil, err
+	}
+
+	s.upgradeReady = os.NewFile(uintptr(listenFDsStart+n), "UPGRADE_READY")
+
+	if s.log != nil {
+		s.log.Debug().Int("listeners", n).Msg("listeners inherited from upgraded process")
+	}
+
+	return lns, nil
+}
+
+// notifyUpgraded tells the upgraded process that this one is serving.
+func (s *Server) notifyUpgraded() {
+	if s.upgradeReady == nil {
+		return
+	}
+
+	_, err := s.upgradeReady.Write([]byte{1})
+	_ = s.upgradeReady.Close()
+	s.upgradeReady = nil
+
+	if err != nil && s.log != nil {
+		s.log.Error().Err(err).Msg("upgrade readiness notification error")
+	}
+}
@@ -0,0 +1,198 @@
+//go:build !windows
+// +build !windows
+
+package fhserver
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func upgradeTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	cfg := cfgstructs.WebServer{Host: "127.0.0.1", Port: 0}
+	cfg.Timeouts.Shutdown = 5 * time.Second
+
+	r := router.New()
+	r.GET("/pid", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBodyString(strconv.Itoa(os.Getpid()))
+	})
+	r.GET("/slow", func(ctx *fasthttp.RequestCtx) {
+		time.Sleep(300 * time.Millisecond)
+		ctx.SetBodyString(strconv.Itoa(os.Getpid()))
+	})
+
+	s := testServer(t, cfg).EnableUpgrade()
+	s.SetRouter(r)
+
+	return s
+}
+
+// getPID requests uri and returns pid of the process which handled it.
+func getPID(uri string) (int, error) {
+	req := newRequest("GET", uri)
+	req.SetConnectionClose()
+
+	resp := &fasthttp.Response{}
+	if err := fasthttp.DoTimeout(req, resp, 5*time.Second); err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(string(resp.Body()))
+}
+
+func TestServer_upgrade(t *testing.T) {
+	if _, ok := os.LookupEnv(upgradeFDsEnv); ok {
+		// upgraded process started by the test below, serves until SIGTERM
+		if err := upgradeTestServer(t).Run(nil); err != nil {
+			t.Fatalf("upgraded Run error: %v", err)
+		}
+
+		return
+	}
+
+	if testing.Short() {
+		t.Skip("upgrade test starts child process")
+	}
+
+	newCmd := newUpgradeCmd
+	newUpgradeCmd = func() *exec.Cmd {
+		return exec.Command(os.Args[0], "-test.run=^TestServer_upgrade$") //nolint:gosec
+	}
+
+	t.Cleanup(func() { newUpgradeCmd = newCmd })
+
+	s := upgradeTestServer(t)
+
+	done := make(chan error, 1)
+
+	go func() { done <- s.Run(nil) }()
+
+	<-s.Ready()
+
+	base := "http://" + s.Addr().String()
+
+	// in-flight request is completed by the old process
+	slow := make(chan error, 1)
+
+	go func() {
+		pid, err := getPID(base + "/slow")
+		if err == nil && pid != os.Getpid() {
+			err = errors.New("slow request isn't handled by the old process")
+		}
+
+		slow <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatalf("syscall.Kill error: %v", err)
+	}
+
+	// request stream keeps flowing until the new process answers
+	var (
+		child    int
+		requests int
+	)
+
+	for deadline := time.Now().Add(15 * time.Second); time.Now().Before(deadline); requests++ {
+		pid, err := getPID(base + "/pid")
+		if err != nil {
+			t.Fatalf("request %d during upgrade error: %v", requests, err)
+		}
+
+		if pid != os.Getpid() {
+			child = pid
+
+			break
+		}
+	}
+
+	if child == 0 {
+		t.Fatal("upgraded process didn't handle requests")
+	}
+
+	t.Cleanup(func() { _ = syscall.Kill(child, syscall.SIGKILL) })
+
+	if err := <-slow; err != nil {
+		t.Errorf("in-flight request error: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("old process Run error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("old process Run didn't return after upgrade")
+	}
+
+	// old process listener is closed, the new one still serves
+	for i := 0; i < 10; i++ {
+		if pid, err := getPID(base + "/pid"); err != nil || pid != child {
+			t.Fatalf("request after upgrade pid = %d, error: %v, want pid %d", pid, err, child)
+		}
+	}
+
+	if err := syscall.Kill(child, syscall.SIGTERM); err != nil {
+		t.Errorf("upgraded process stop error: %v", err)
+	}
+}
+
+func TestServer_upgrade_failed(t *testing.T) {
+	newCmd := newUpgradeCmd
+	newUpgradeCmd = func() *exec.Cmd {
+		// exits at once without serving
+		return exec.Command(os.Args[0], "-test.list=^$") //nolint:gosec
+	}
+
+	t.Cleanup(func() { newUpgradeCmd = newCmd })
+
+	buf := &syncBuffer{}
+
+	s := upgradeTestServer(t).SetLogger(testLogger(t, buf))
+
+	done := make(chan error, 1)
+
+	go func() { done <- s.Run(nil) }()
+
+	<-s.Ready()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatalf("syscall.Kill error: %v", err)
+	}
+
+	// new process exits without serving, the old one keeps going
+	deadline := time.Now().Add(10 * time.Second)
+	for !strings.Contains(buf.String(), "upgrade error") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !strings.Contains(buf.String(), "upgraded process is not ready") {
+		t.Errorf("upgrade error isn't logged, got:\n%s", buf.String())
+	}
+
+	if pid, err := getPID("http://" + s.Addr().String() + "/pid"); err != nil || pid != os.Getpid() {
+		t.Errorf("request after failed upgrade pid = %d, error: %v, want pid %d", pid, err, os.Getpid())
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop error: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("Run error: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+//go:build !windows
+// +build !windows
+
+package fhserver
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	pkgErr "github.com/spacetab-io/http-go/errors"
+)
+
+// upgradeSignal makes the server with enabled upgrade start the new process.
+var upgradeSignal os.Signal = syscall.SIGUSR2
+
+// upgradeProcess starts the new process serving listeners and waits until it's ready.
+// The new process is killed if it isn't ready in time.
+func (s *Server) upgradeProcess(listeners []*gracefulListener) error {
+	files := make([]*os.File, 0, len(listeners)+1)
+
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+
+	for _, graceful := range listeners {
+		f, err := listenerFile(graceful.ln)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, f)
+	}
+
+	ready, notify, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("os.Pipe error: %w", err)
+	}
+
+	defer ready.Close()
+
+	files = append(files, notify)
+
+	cmd := newUpgradeCmd()
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%d", upgradeFDsEnv, len(listeners)))
+	cmd.ExtraFiles = files
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("upgraded process start error: %w", err)
+	}
+
+	if s.log != nil {
+		s.log.Debug().Int("pid", cmd.Process.Pid).Msg("upgraded process started")
+	}
+
+	// the new process holds its own copy, so reading fails once it exits
+	_ = notify.Close()
+
+	readErr := make(chan error, 1)
+
+	go func() {
+		_, err := ready.Read(make([]byte, 1))
+		readErr <- err
+	}()
+
+	select {
+	case err = <-readErr:
+	case <-time.After(upgradeReadyTimeout):
+		err = fmt.Errorf("timeout %s", upgradeReadyTimeout)
+	}
+
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+
+		return fmt.Errorf("%w: %v", pkgErr.ErrUpgradeNotReady, err)
+	}
+
+	_ = cmd.Process.Release()
+
+	// socket file is served by the new process now
+	for _, graceful := range listeners {
+		if ul, ok := graceful.ln.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+
+	if s.log != nil {
+		s.log.Debug().Int("pid", cmd.Process.Pid).Msg("upgraded process is ready")
+	}
+
+	return nil
+}
+
+// listenerFile returns duplicated file descriptor of the listening socket. net.TCPListener.File
+// isn't used, it switches the shared socket to blocking mode and Close hangs in pending accept.
+func listenerFile(ln net.Listener) (*os.File, error) {
+	sc, ok := ln.(syscall.Conn)
+	if !ok {
+		return nil, fmt.Errorf("listener %T doesn't support file descriptor passing", ln)
+	}
+
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return nil, fmt.Errorf("listener SyscallConn error: %w", err)
+	}
+
+	var (
+		fd     int
+		dupErr error
+	)
+
+	err = rc.Control(func(s uintptr) {
+		// hold fork lock, so the descriptor doesn't leak to processes started concurrently
+		syscall.ForkLock.RLock()
+		defer syscall.ForkLock.RUnlock()
+
+		if fd, dupErr = syscall.Dup(int(s)); dupErr == nil {
+			syscall.CloseOnExec(fd)
+		}
+	})
+
+	if err == nil {
+		err = dupErr
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("listener dup error: %w", err)
+	}
+
+	return os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd)), nil
+}
@@ -0,0 +1,16 @@
+package fhserver
+
+import (
+	"os"
+
+	pkgErr "github.com/spacetab-io/http-go/errors"
+)
+
+// upgradeSignal is nil, there is no signal to start the upgrade on Windows.
+var upgradeSignal os.Signal
+
+// upgradeProcess returns error, passing listening sockets to the new process isn't supported
+// on Windows.
+func (s *Server) upgradeProcess(listeners []*gracefulListener) error {
+	return pkgErr.ErrUpgradeNotSupported
+}
@@ -0,0 +1,107 @@
+package fhserver
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	errs "github.com/spacetab-io/errors-go"
+)
+
+// validationRuleDefault is the rule of the message used for rules without own messages.
+const validationRuleDefault = "ek"
+
+// validationMessages holds messages registered with RegisterValidationMessages by language and rule.
+var validationMessages struct {
+	sync.RWMutex
+	langs map[string]map[string]string
+}
+
+// RegisterValidationMessages adds validation messages of lang by validator tag (rule), overriding
+// CommonValidationErrors and previously registered ones. Patterns get field name and rule param
+// for "%s" placeholders, e.g. "`%s` must be longer than %s", the "ek" message of rules without own
+// messages gets field name and rule. New languages are chosen by ResolvedLang.
+// It's safe to call concurrently with request handling.
+func RegisterValidationMessages(lang string, msgs map[string]string) {
+	lang = strings.ToLower(lang)
+
+	validationMessages.Lock()
+	defer validationMessages.Unlock()
+
+	if validationMessages.langs == nil {
+		validationMessages.langs = make(map[string]map[string]string)
+	}
+
+	if validationMessages.langs[lang] == nil {
+		validationMessages.langs[lang] = make(map[string]string, len(msgs))
+	}
+
+	for rule, pattern := range msgs {
+		validationMessages.langs[lang][rule] = pattern
+	}
+}
+
+// RegisterValidationMessage adds validation message of lang for rule, see RegisterValidationMessages.
+func RegisterValidationMessage(lang, rule, pattern string) {
+	RegisterValidationMessages(lang, map[string]string{rule: pattern})
+}
+
+// validationMessage returns message pattern of lang for rule, registered ones take precedence
+// over CommonValidationErrors.
+func validationMessage(lang, rule string) (string, bool) {
+	validationMessages.RLock()
+	pattern, ok := validationMessages.langs[lang][rule]
+	validationMessages.RUnlock()
+
+	if ok {
+		return pattern, true
+	}
+
+	p, ok := CommonValidationErrors[lang][validationRule(rule)]
+
+	return p.string(), ok
+}
+
+// hasValidationLang reports whether there are validation messages or translator of lang.
+func hasValidationLang(lang string) bool {
+	if _, ok := validatorTranslator(lang); ok {
+		return true
+	}
+
+	validationMessages.RLock()
+	_, ok := validationMessages.langs[lang]
+	validationMessages.RUnlock()
+
+	if ok {
+		return true
+	}
+
+	_, ok = CommonValidationErrors[lang]
+
+	return ok
+}
+
+// getErrMessage returns validation message of rule for field: message of the rule or the default
+// one of lang, then ones of the default language.
+func getErrMessage(rule validationRule, field errs.FieldName, param, lang string) errs.ValidationError {
+	for _, l := range []string{lang, defaultLang} {
+		if pattern, ok := validationMessage(l, string(rule)); ok {
+			return formatValidationMessage(pattern, field, param)
+		}
+
+		if pattern, ok := validationMessage(l, validationRuleDefault); ok {
+			return formatValidationMessage(pattern, field, rule)
+		}
+	}
+
+	return errs.ValidationError(fmt.Sprintf("%s: %s", field, rule))
+}
+
+// formatValidationMessage formats pattern with as many args as it has "%s" placeholders.
+func formatValidationMessage(pattern string, args ...interface{}) errs.ValidationError {
+	if n := strings.Count(pattern, "%s"); n < len(args) {
+		args = args[:n]
+	}
+
+	return errs.ValidationError(fmt.Sprintf(pattern, args...))
+}
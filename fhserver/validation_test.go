@@ -0,0 +1,201 @@
+package fhserver
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	errs "github.com/spacetab-io/errors-go"
+	"github.com/valyala/fasthttp"
+)
+
+// TestRegisterValidationMessages isn't parallel as the registry is global.
+func TestRegisterValidationMessages(t *testing.T) {
+	t.Cleanup(func() {
+		validationMessages.Lock()
+		validationMessages.langs = nil
+		validationMessages.Unlock()
+	})
+
+	RegisterValidationMessages("DE", map[string]string{
+		"ek":       "Validierungsfehler der Eigenschaft `%s` mit Regel `%s`",
+		"required": "Eigenschaft `%s` ist erforderlich",
+	})
+	RegisterValidationMessage("ru", "phone_ru", "Свойство `%s` должно быть российским номером телефона")
+	RegisterValidationMessage("en", "gt", "`%s` needs more than %s items")
+
+	type form struct {
+		Name  string   `validate:"required"`
+		Phone string   `validate:"phone_ru"`
+		Tags  []string `validate:"gt=1"`
+		Email string   `validate:"email"`
+	}
+
+	v := validator.New()
+	if err := v.RegisterValidation("phone_ru", func(fl validator.FieldLevel) bool {
+		return strings.HasPrefix(fl.Field().String(), "+7")
+	}); err != nil {
+		t.Fatalf("RegisterValidation error: %v", err)
+	}
+
+	validationErr := v.Struct(form{Phone: "+1", Email: "mail"})
+
+	type testCase struct {
+		lang string
+		want map[errs.FieldName][]errs.ValidationError
+	}
+
+	tcs := []testCase{
+		{
+			lang: "de-AT",
+			want: map[errs.FieldName][]errs.ValidationError{
+				"Name":  {"Eigenschaft `Name` ist erforderlich"},
+				"Phone": {"Validierungsfehler der Eigenschaft `Phone` mit Regel `phone_ru`"},
+				"Tags":  {"Validierungsfehler der Eigenschaft `Tags` mit Regel `gt`"},
+				"Email": {"Validierungsfehler der Eigenschaft `Email` mit Regel `email`"},
+			},
+		},
+		{
+			lang: "ru",
+			want: map[errs.FieldName][]errs.ValidationError{
+				"Name":  {"Свойство `Name` обязательно для заполнения"},
+				"Phone": {"Свойство `Phone` должно быть российским номером телефона"},
+				"Tags":  {"Свойство `Tags` должно содержать более `1` элементов"},
+				"Email": {"Свойство `Email` должно быть email адресом"},
+			},
+		},
+		{
+			lang: "en",
+			want: map[errs.FieldName][]errs.ValidationError{
+				"Name":  {"Property `Name` is required"},
+				"Phone": {"Validation error of property `Phone` with rule `phone_ru`"},
+				"Tags":  {"`Tags` needs more than 1 items"},
+				"Email": {"Property `Email` must be an email address"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.Set(fasthttp.HeaderAcceptLanguage, tc.lang)
+
+		JSON(ctx, validationErr)
+
+		var body struct {
+			Error *errs.ErrorObject `json:"error"`
+		}
+
+		if err := json.Unmarshal(ctx.Response.Body(), &body); err != nil {
+			t.Fatalf("%s: body isn't JSON: %v", tc.lang, err)
+		}
+
+		if body.Error == nil || !reflect.DeepEqual(body.Error.Validation, tc.want) {
+			t.Errorf("%s: validation = %+v, want %+v", tc.lang, body.Error, tc.want)
+		}
+
+		wantLang := strings.SplitN(tc.lang, "-", 2)[0]
+		if got := string(ctx.Response.Header.Peek(fasthttp.HeaderContentLanguage)); got != wantLang {
+			t.Errorf("%s: Content-Language = %q, want %q", tc.lang, got, wantLang)
+		}
+	}
+}
+
+func TestCommonValidationErrors(t *testing.T) {
+	t.Parallel()
+
+	type form struct {
+		Min      string `validate:"min=3"`
+		Max      int    `validate:"max=10"`
+		Len      []int  `validate:"len=2"`
+		Email    string `validate:"email"`
+		OneOf    string `validate:"oneof=red green"`
+		UUID     string `validate:"uuid"`
+		URL      string `validate:"url"`
+		Gte      int    `validate:"gte=18"`
+		Lte      int    `validate:"lte=5"`
+		Numeric  string `validate:"numeric"`
+		AlphaNum string `validate:"alphanum"`
+		DateTime string `validate:"datetime=2006-01-02"`
+	}
+
+	err := validator.New().Struct(form{
+		Min:      "ab",
+		Max:      11,
+		Len:      []int{1},
+		Email:    "mail",
+		OneOf:    "blue",
+		UUID:     "id",
+		URL:      "path",
+		Gte:      17,
+		Lte:      6,
+		Numeric:  "one",
+		AlphaNum: "a-b",
+		DateTime: "02.01.2006",
+	})
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("Struct error = %v, want validation errors", err)
+	}
+
+	type testCase struct {
+		field errs.FieldName
+		ru    errs.ValidationError
+		en    errs.ValidationError
+	}
+
+	tcs := []testCase{
+		{field: "Min", ru: "Свойство `Min` должно быть не меньше `3`", en: "Property `Min` must be at least `3`"},
+		{field: "Max", ru: "Свойство `Max` должно быть не больше `10`", en: "Property `Max` must be at most `10`"},
+		{field: "Len", ru: "Длина свойства `Len` должна быть равна `2`", en: "Length of property `Len` must be `2`"},
+		{field: "Email", ru: "Свойство `Email` должно быть email адресом", en: "Property `Email` must be an email address"},
+		{field: "OneOf", ru: "Свойство `OneOf` должно быть одним из `red green`", en: "Property `OneOf` must be one of `red green`"},
+		{field: "UUID", ru: "Свойство `UUID` должно быть UUID", en: "Property `UUID` must be a UUID"},
+		{field: "URL", ru: "Свойство `URL` должно быть URL", en: "Property `URL` must be a URL"},
+		{field: "Gte", ru: "Свойство `Gte` должно быть не меньше `18`", en: "Property `Gte` must be at least `18`"},
+		{field: "Lte", ru: "Свойство `Lte` должно быть не больше `5`", en: "Property `Lte` must be at most `5`"},
+		{field: "Numeric", ru: "Свойство `Numeric` должно быть числом", en: "Property `Numeric` must be a number"},
+		{field: "AlphaNum", ru: "Свойство `AlphaNum` должно содержать только буквы и цифры", en: "Property `AlphaNum` must contain only letters and digits"},
+		{field: "DateTime", ru: "Свойство `DateTime` должно быть датой в формате `2006-01-02`", en: "Property `DateTime` must be a date in `2006-01-02` format"},
+	}
+
+	if len(validationErrs) != len(tcs) {
+		t.Fatalf("got %d validation errors, want %d: %v", len(validationErrs), len(tcs), validationErrs)
+	}
+
+	ru := makeErrorsSlice(validationErrs, "ru")
+	en := makeErrorsSlice(validationErrs, "en")
+
+	for _, tc := range tcs {
+		if got := ru[tc.field]; !reflect.DeepEqual(got, []errs.ValidationError{tc.ru}) {
+			t.Errorf("ru %s = %q, want %q", tc.field, got, tc.ru)
+		}
+
+		if got := en[tc.field]; !reflect.DeepEqual(got, []errs.ValidationError{tc.en}) {
+			t.Errorf("en %s = %q, want %q", tc.field, got, tc.en)
+		}
+	}
+}
+
+func TestFormatValidationMessage(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		pattern string
+		want    errs.ValidationError
+	}
+
+	tcs := []testCase{
+		{pattern: "`%s` is invalid", want: "`Name` is invalid"},
+		{pattern: "`%s` must be at least `%s`", want: "`Name` must be at least `3`"},
+		{pattern: "invalid", want: "invalid"},
+	}
+
+	for _, tc := range tcs {
+		if got := formatValidationMessage(tc.pattern, "Name", "3"); got != tc.want {
+			t.Errorf("formatValidationMessage(%q) = %q, want %q", tc.pattern, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package fhserver
+
+import (
+	"runtime"
+
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	defaultVersionPath = "/version"
+	versionHeader      = "X-Service-Version"
+)
+
+// VersionInfo is the service build information.
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+// RegisterVersion adds GET /version endpoint responding with info. GoVersion defaults to runtime.Version().
+func RegisterVersion(r *router.Router, info VersionInfo) {
+	RegisterVersionAt(r, defaultVersionPath, info)
+}
+
+// RegisterVersionAt is the same as RegisterVersion with custom endpoint path.
+func RegisterVersionAt(r *router.Router, path string, info VersionInfo) {
+	if info.GoVersion == "" {
+		info.GoVersion = runtime.Version()
+	}
+
+	r.GET(path, func(ctx *fasthttp.RequestCtx) {
+		JSON(ctx, info)
+	})
+}
+
+// SetVersionHeader makes server add X-Service-Version header with version to every response.
+// Must be called before SetRouter.
+func (s *Server) SetVersionHeader(version string) *Server {
+	s.version = version
+
+	return s
+}
+
+func versionHeaderMiddleware(next fasthttp.RequestHandler, version string) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		next(ctx)
+
+		ctx.Response.Header.Set(versionHeader, version)
+	}
+}
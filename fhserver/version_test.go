@@ -0,0 +1,75 @@
+package fhserver
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRegisterVersion(t *testing.T) {
+	t.Parallel()
+
+	info := VersionInfo{Version: "1.2.3", Commit: "abcdef", BuildDate: "2022-05-01"}
+
+	r := router.New()
+	RegisterVersion(r, info)
+	RegisterVersionAt(r, "/info/version", info)
+
+	for _, path := range []string{"/version", "/info/version"} {
+		resp := doRequest(r.Handler, newRequest("GET", "http://test"+path))
+		if resp.StatusCode() != fasthttp.StatusOK {
+			t.Fatalf("%s: status code = %d, want %d", path, resp.StatusCode(), fasthttp.StatusOK)
+		}
+
+		var body map[string]map[string]string
+		if err := json.Unmarshal(resp.Body(), &body); err != nil {
+			t.Fatalf("%s: json.Unmarshal error: %v, body: %s", path, err, resp.Body())
+		}
+
+		want := map[string]string{
+			"version":    "1.2.3",
+			"commit":     "abcdef",
+			"build_date": "2022-05-01",
+			"go_version": runtime.Version(),
+		}
+
+		for k, v := range want {
+			if body["data"][k] != v {
+				t.Errorf("%s: data.%s = %q, want %q", path, k, body["data"][k], v)
+			}
+		}
+	}
+}
+
+func TestServer_SetVersionHeader(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name    string
+		version string
+	}
+
+	tcs := []testCase{
+		{name: "enabled", version: "1.2.3"},
+		{name: "disabled", version: ""},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := testServer(t, cfgstructs.WebServer{}).SetVersionHeader(tc.version)
+			s.SetRouter(testRouter())
+
+			resp := doRequest(s.httpServer.Handler, newRequest("GET", "http://test/ping"))
+
+			if got := string(resp.Header.Peek("X-Service-Version")); got != tc.version {
+				t.Errorf("X-Service-Version = %q, want %q", got, tc.version)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package fhserver
+
+import (
+	"net"
+	"strings"
+
+	"github.com/fasthttp/router"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// SetHostRouter makes requests with Host header matching host served by r instead of the router
+// passed to SetRouter, which becomes the default one for unknown hosts. Host is matched
+// case-insensitively without port. The middlewares chain is shared by all routers.
+// Must be called before SetRouter.
+func (s *Server) SetHostRouter(host string, r *router.Router) *Server {
+	if s.hostRouters == nil {
+		s.hostRouters = make(map[string]*router.Router)
+	}
+
+	s.hostRouters[normalizeHost(host)] = r
+
+	return s
+}
+
+// SetUnknownHostStatus makes requests to hosts not registered with SetHostRouter answered with
+// statusCode, e.g. 421 Misdirected Request or 404, instead of serving them by the default router.
+// Must be called before SetRouter.
+func (s *Server) SetUnknownHostStatus(statusCode int) *Server {
+	s.unknownHostStatus = statusCode
+
+	return s
+}
+
+// hostHandler returns handler selecting router by request Host header, r serves unknown hosts.
+func (s *Server) hostHandler(r *router.Router) fasthttp.RequestHandler {
+	if len(s.hostRouters) == 0 && s.unknownHostStatus == 0 {
+		return r.Handler
+	}
+
+	routerFor, unknownHostStatus := s.hostRouter(r), s.unknownHostStatus
+
+	return func(ctx *fasthttp.RequestCtx) {
+		if hr := routerFor(ctx); hr != nil {
+			hr.Handler(ctx)
+
+			return
+		}
+
+		ctx.SetStatusCode(unknownHostStatus)
+		JSON(ctx, pkgErr.ErrUnknownHost.Error())
+	}
+}
+
+// hostRouter returns function selecting router serving the request by its Host header.
+// The function returns nil if unknown hosts are answered with SetUnknownHostStatus.
+func (s *Server) hostRouter(r *router.Router) func(ctx *fasthttp.RequestCtx) *router.Router {
+	routers := make(map[string]*router.Router, len(s.hostRouters))
+	for host, hr := range s.hostRouters {
+		routers[host] = hr
+	}
+
+	unknownHostStatus := s.unknownHostStatus
+
+	return func(ctx *fasthttp.RequestCtx) *router.Router {
+		if hr, ok := routers[normalizeHost(string(ctx.Host()))]; ok {
+			return hr
+		}
+
+		if unknownHostStatus != 0 {
+			return nil
+		}
+
+		return r
+	}
+}
+
+// normalizeHost returns lower case host without port and trailing dot.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	host = strings.Trim(host, "[]")
+
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -0,0 +1,70 @@
+package fhserver
+
+import (
+	"testing"
+
+	"github.com/fasthttp/router"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestServer_SetHostRouter(t *testing.T) {
+	t.Parallel()
+
+	newRouter := func(name string) *router.Router {
+		r := router.New()
+		r.GET("/users", func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString(name + " users") })
+
+		return r
+	}
+
+	marker := func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			next(ctx)
+			ctx.Response.Header.Set("X-Global", "1")
+		}
+	}
+
+	newServer := func(unknownHostStatus int) *Server {
+		s := testServer(t, cfgstructs.WebServer{}).Use(marker).
+			SetHostRouter("api.example.com", newRouter("api")).
+			SetHostRouter("Admin.Example.com", newRouter("admin")).
+			SetUnknownHostStatus(unknownHostStatus)
+		s.SetRouter(newRouter("default"))
+
+		return s
+	}
+
+	tcs := []struct {
+		name              string
+		unknownHostStatus int
+		host              string
+		want              int
+		wantBody          string
+	}{
+		{name: "api host", host: "api.example.com", want: 200, wantBody: "api users"},
+		{name: "admin host with port and case", host: "ADMIN.example.com:8080", want: 200, wantBody: "admin users"},
+		{name: "unknown host default router", host: "other.example.com", want: 200, wantBody: "default users"},
+		{name: "unknown host misdirected", unknownHostStatus: 421, host: "other.example.com", want: 421},
+		{name: "unknown host not found", unknownHostStatus: 404, host: "other.example.com", want: 404},
+		{name: "known host with unknown host status", unknownHostStatus: 421, host: "api.example.com", want: 200, wantBody: "api users"},
+	}
+
+	for _, tc := range tcs {
+		req := newRequest("GET", "http://"+tc.host+"/users")
+
+		resp := doRequest(newServer(tc.unknownHostStatus).httpServer.Handler, req)
+
+		if resp.StatusCode() != tc.want {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.want)
+		}
+
+		if tc.wantBody != "" && string(resp.Body()) != tc.wantBody {
+			t.Errorf("%s: body = %q, want %q", tc.name, resp.Body(), tc.wantBody)
+		}
+
+		if string(resp.Header.Peek("X-Global")) != "1" {
+			t.Errorf("%s: global middleware isn't applied", tc.name)
+		}
+	}
+}
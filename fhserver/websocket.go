@@ -0,0 +1,77 @@
+package fhserver
+
+import (
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+// WebsocketOption configures websocket upgrader used by Websocket.
+type WebsocketOption func(u *websocket.FastHTTPUpgrader)
+
+// WebsocketCheckOrigin sets function validating request Origin header. By default cross-origin
+// requests are rejected.
+func WebsocketCheckOrigin(check func(ctx *fasthttp.RequestCtx) bool) WebsocketOption {
+	return func(u *websocket.FastHTTPUpgrader) {
+		u.CheckOrigin = check
+	}
+}
+
+// WebsocketSubprotocols sets supported subprotocols in order of preference.
+func WebsocketSubprotocols(protocols ...string) WebsocketOption {
+	return func(u *websocket.FastHTTPUpgrader) {
+		u.Subprotocols = protocols
+	}
+}
+
+// Websocket returns route handler upgrading request to websocket connection served by handler.
+// Upgrade response isn't compressed. Connect and disconnect are logged with server logger.
+// Open connections are waited for on graceful shutdown up to the shutdown timeout and force closed after it.
+func Websocket(handler func(conn *websocket.Conn), opts ...WebsocketOption) fasthttp.RequestHandler {
+	upgrader := websocket.FastHTTPUpgrader{
+		Error: func(ctx *fasthttp.RequestCtx, status int, reason error) {
+			ctx.SetStatusCode(status)
+			JSON(ctx, reason.Error())
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&upgrader)
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		skipCompression(ctx)
+
+		// request context isn't available after upgrade
+		logger := requestLogger(ctx)
+		path := string(ctx.Path())
+		ip := ClientIP(ctx).String()
+		id := RequestID(ctx)
+
+		_ = upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
+			begin := time.Now()
+
+			if logger != nil {
+				event := logger.Debug().Str("path", path).Str("ip", ip)
+				if id != uuid.Nil {
+					event.Str("req_id", id.String())
+				}
+
+				event.Msg("websocket connected")
+
+				defer func() {
+					event := logger.Debug().Str("path", path).Str("ip", ip).Dur("duration", time.Since(begin))
+					if id != uuid.Nil {
+						event.Str("req_id", id.String())
+					}
+
+					event.Msg("websocket disconnected")
+				}()
+			}
+
+			handler(conn)
+		})
+	}
+}
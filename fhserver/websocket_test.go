@@ -0,0 +1,159 @@
+package fhserver
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/fasthttp/websocket"
+	cfgstructs "github.com/spacetab-io/configuration-structs-go/v2"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+func TestWebsocket(t *testing.T) {
+	t.Parallel()
+
+	echo := Websocket(func(conn *websocket.Conn) {
+		for {
+			mt, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			if err := conn.WriteMessage(mt, msg); err != nil {
+				return
+			}
+		}
+	})
+
+	r := router.New()
+	r.GET("/ws", echo)
+
+	start := func(t *testing.T, shutdown time.Duration, buf *syncBuffer) (*Server, chan error) {
+		t.Helper()
+
+		cfg := cfgstructs.WebServer{Host: "127.0.0.1", Compress: true}
+		cfg.Timeouts.Shutdown = shutdown
+
+		s := New(cfg, WithCompressMinSize(0)).SetLogger(testLogger(t, buf))
+		s.SetRouter(r)
+
+		done := make(chan error, 1)
+
+		go func() { done <- s.Run(nil) }()
+
+		<-s.Ready()
+
+		return s, done
+	}
+
+	dial := func(t *testing.T, s *Server) *websocket.Conn {
+		t.Helper()
+
+		header := http.Header{}
+		header.Set("Accept-Encoding", "gzip, br")
+
+		conn, resp, err := websocket.DefaultDialer.Dial("ws://"+s.Addr().String()+"/ws", header)
+		if err != nil {
+			t.Fatalf("Dial error: %v", err)
+		}
+
+		defer resp.Body.Close()
+
+		// compression is skipped entirely, so Vary isn't set either
+		if enc, vary := resp.Header.Get("Content-Encoding"), resp.Header.Get("Vary"); enc != "" || vary != "" {
+			t.Errorf("upgrade response Content-Encoding = %q, Vary = %q, want none", enc, vary)
+		}
+
+		return conn
+	}
+
+	t.Run("echo", func(t *testing.T) {
+		t.Parallel()
+
+		buf := &syncBuffer{}
+		s, done := start(t, time.Second, buf)
+		conn := dial(t, s)
+
+		for _, msg := range []string{"hello", "world"} {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+				t.Fatalf("WriteMessage error: %v", err)
+			}
+
+			_, got, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("ReadMessage error: %v", err)
+			}
+
+			if string(got) != msg {
+				t.Errorf("echo = %q, want %q", got, msg)
+			}
+		}
+
+		stopped := make(chan error, 1)
+
+		go func() { stopped <- s.Stop() }()
+
+		// shutdown waits for the open socket
+		select {
+		case err := <-stopped:
+			t.Fatalf("Stop returned before socket is closed: %v", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		_ = conn.Close()
+
+		if err := <-stopped; err != nil {
+			t.Errorf("Stop error: %v", err)
+		}
+
+		if err := <-done; err != nil {
+			t.Errorf("Run error: %v", err)
+		}
+
+		for _, msg := range []string{"websocket connected", "websocket disconnected"} {
+			if !strings.Contains(buf.String(), msg) {
+				t.Errorf("%q isn't logged, got:\n%s", msg, buf.String())
+			}
+		}
+	})
+
+	t.Run("force close", func(t *testing.T) {
+		t.Parallel()
+
+		s, done := start(t, 100*time.Millisecond, &syncBuffer{})
+		conn := dial(t, s)
+
+		defer conn.Close()
+
+		if err := s.Stop(); !errors.Is(err, pkgErr.ErrFHServerShutdown) {
+			t.Errorf("Stop error = %v, want %v", err, pkgErr.ErrFHServerShutdown)
+		}
+
+		<-done
+
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+		if _, _, err := conn.ReadMessage(); err == nil {
+			t.Error("socket must be closed")
+		}
+	})
+
+	t.Run("not upgrade", func(t *testing.T) {
+		t.Parallel()
+
+		resp := doRequest(echo, newRequest("GET", "http://test/ws"))
+
+		if resp.StatusCode() != fasthttp.StatusBadRequest {
+			t.Errorf("status code = %d, want %d", resp.StatusCode(), fasthttp.StatusBadRequest)
+		}
+
+		if !strings.Contains(string(resp.Body()), `"error"`) {
+			t.Errorf("body = %s, want JSON error envelope", resp.Body())
+		}
+	})
+}
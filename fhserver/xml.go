@@ -0,0 +1,179 @@
+package fhserver
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"sort"
+
+	"github.com/google/uuid"
+	errs "github.com/spacetab-io/errors-go"
+	"github.com/valyala/fasthttp"
+)
+
+// ContentTypeXML is Content-Type of XML responses.
+const ContentTypeXML = "application/xml; charset=utf-8"
+
+var xmlEncoding = responseEncoding{
+	contentType: ContentTypeXML,
+	marshal: func(obj *Response) ([]byte, error) {
+		res, err := xml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]byte(xml.Header), res...), nil
+	},
+	errorBody: xmlErrorBody,
+}
+
+// XML makes common response in xml the same way JSON does. The envelope is <response> element
+// with error, data, meta and request_id children. Maps with string keys (e.g. validation errors)
+// are encoded as elements named by the keys, slices as repeated elements.
+func XML(ctx *fasthttp.RequestCtx, response interface{}) {
+	respond(ctx, response, xmlEncoding)
+}
+
+// MarshalXML encodes response as <response> element with children named like JSON fields.
+func (r Response) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xmlStart("response")
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if r.Error != nil {
+		if err := encodeXMLError(e, r.Error); err != nil {
+			return err
+		}
+	}
+
+	if err := encodeXMLValue(e, "data", r.Data); err != nil {
+		return err
+	}
+
+	if err := encodeXMLValue(e, "meta", r.Meta); err != nil {
+		return err
+	}
+
+	if r.RequestID != "" {
+		if err := e.EncodeElement(r.RequestID, xmlStart("request_id")); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// encodeXMLError encodes error object as <error> element.
+func encodeXMLError(e *xml.Encoder, obj *errs.ErrorObject) error {
+	start := xmlStart("error")
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	fields := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "message", value: obj.Message},
+		{name: "type", value: obj.Type},
+		{name: "code", value: obj.Code},
+		{name: "validation", value: obj.Validation},
+		{name: "debug", value: obj.Debug},
+	}
+
+	for _, f := range fields {
+		if err := encodeXMLValue(e, f.name, f.value); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// encodeXMLValue encodes v as element name, nil values are skipped. Unlike encoding/xml,
+// maps with string keys are supported: they're encoded as elements named by sorted keys.
+func encodeXMLValue(e *xml.Encoder, name string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+
+		return encodeXMLMap(e, name, rv)
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+
+		for i := 0; i < rv.Len(); i++ {
+			if err := encodeXMLValue(e, name, rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return e.EncodeElement(rv.Interface(), xmlStart(name))
+}
+
+// encodeXMLMap encodes map with string keys as element name with children named by the keys.
+func encodeXMLMap(e *xml.Encoder, name string, rv reflect.Value) error {
+	start := xmlStart(name)
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	for _, k := range keys {
+		if err := encodeXMLValue(e, k.String(), rv.MapIndex(k).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+func xmlStart(name string) xml.StartElement {
+	return xml.StartElement{Name: xml.Name{Local: name}}
+}
+
+// xmlErrorBody returns body of the XML error response, unlike xml.Marshal of Response it can't fail.
+func xmlErrorBody(msg string, id uuid.UUID) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString(xml.Header)
+	buf.WriteString("<response><error><message>")
+	_ = xml.EscapeText(&buf, []byte(msg))
+	buf.WriteString("</message></error>")
+
+	if id != uuid.Nil {
+		buf.WriteString("<request_id>" + id.String() + "</request_id>")
+	}
+
+	buf.WriteString("</response>")
+
+	return buf.Bytes()
+}
@@ -0,0 +1,119 @@
+package fhserver
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	pkgErr "github.com/spacetab-io/http-go/errors"
+	"github.com/valyala/fasthttp"
+)
+
+func TestXML(t *testing.T) {
+	t.Parallel()
+
+	type order struct {
+		ID    int    `xml:"id"`
+		Title string `xml:"title"`
+	}
+
+	type item struct {
+		Name string `validate:"required"`
+	}
+
+	type testCase struct {
+		name     string
+		response interface{}
+		wantCode int
+		wantBody string
+	}
+
+	tcs := []testCase{
+		{
+			name:     "string",
+			response: "ok",
+			wantCode: fasthttp.StatusOK,
+			wantBody: `<response><data>ok</data></response>`,
+		},
+		{
+			name:     "struct",
+			response: order{ID: 1, Title: "<tea> & cake"},
+			wantCode: fasthttp.StatusOK,
+			wantBody: `<response><data><id>1</id><title>&lt;tea&gt; &amp; cake</title></data></response>`,
+		},
+		{
+			name:     "map and slice",
+			response: map[string]interface{}{"total": 2, "ids": []int{1, 2}},
+			wantCode: fasthttp.StatusOK,
+			wantBody: `<response><data><ids>1</ids><ids>2</ids><total>2</total></data></response>`,
+		},
+		{
+			name:     "error",
+			response: pkgErr.WithCode(pkgErr.ErrConflict, "ORDER_EXISTS"),
+			wantCode: fasthttp.StatusConflict,
+			wantBody: `<response><error><message>conflict</message><code>ORDER_EXISTS</code></error></response>`,
+		},
+		{
+			name:     "errors map",
+			response: map[string]error{"name": errors.New("name is empty"), "age": errors.New("age is negative")},
+			wantCode: fasthttp.StatusBadRequest,
+			wantBody: `<response><error><message><age>age is negative</age><name>name is empty</name></message></error></response>`,
+		},
+		{
+			name:     "validation",
+			response: validator.New().Struct(item{}),
+			wantCode: fasthttp.StatusUnprocessableEntity,
+			wantBody: `<response><error><message>validation error</message><code>VALIDATION_FAILED</code>` +
+				"<validation><Name>Свойство `Name` обязательно для заполнения</Name></validation></error></response>",
+		},
+		{
+			name:     "marshal error",
+			response: map[string]interface{}{"ch": make(chan int)},
+			wantCode: fasthttp.StatusInternalServerError,
+			wantBody: `<response><error><message>xml: unsupported type: chan int</message></error></response>`,
+		},
+	}
+
+	for _, tc := range tcs {
+		resp := doRequest(func(ctx *fasthttp.RequestCtx) { XML(ctx, tc.response) }, newRequest("GET", "/"))
+
+		if resp.StatusCode() != tc.wantCode {
+			t.Errorf("%s: status code = %d, want %d", tc.name, resp.StatusCode(), tc.wantCode)
+		}
+
+		if got := string(resp.Header.ContentType()); got != ContentTypeXML {
+			t.Errorf("%s: Content-Type = %q, want %q", tc.name, got, ContentTypeXML)
+		}
+
+		body := string(resp.Body())
+		if !strings.HasPrefix(body, xml.Header) || strings.TrimPrefix(body, xml.Header) != tc.wantBody {
+			t.Errorf("%s: body = %s, want %s", tc.name, body, xml.Header+tc.wantBody)
+		}
+
+		if err := xml.Unmarshal(resp.Body(), new(interface{})); err != nil {
+			t.Errorf("%s: body isn't valid XML: %v", tc.name, err)
+		}
+	}
+}
+
+func TestXMLErrorBody(t *testing.T) {
+	t.Parallel()
+
+	id := uuid.New()
+
+	var body struct {
+		Message   string `xml:"error>message"`
+		RequestID string `xml:"request_id"`
+	}
+
+	if err := xml.Unmarshal(xmlErrorBody(`bad <value> & "quotes"`, id), &body); err != nil {
+		t.Fatalf("xmlErrorBody isn't XML: %v", err)
+	}
+
+	if body.Message != `bad <value> & "quotes"` || body.RequestID != id.String() {
+		t.Errorf("message = %q, request_id = %q, want escaped message and %s", body.Message, body.RequestID, id)
+	}
+}
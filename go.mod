@@ -4,25 +4,32 @@ go 1.17
 
 require (
 	github.com/AdhityaRamadhanus/fasthttpcors v0.0.0-20170121111917-d4c07198763a
+	github.com/andybalholm/brotli v1.0.4
 	github.com/fasthttp/router v1.4.7
+	github.com/fasthttp/websocket v1.4.3-rc.6
+	github.com/go-playground/locales v0.14.0
+	github.com/go-playground/universal-translator v0.18.0
 	github.com/go-playground/validator/v10 v10.10.1
 	github.com/google/uuid v1.3.0
 	github.com/json-iterator/go v1.1.12
+	github.com/klauspost/compress v1.15.0
 	github.com/savsgio/gotils v0.0.0-20220401102855-e56b59f40436
 	github.com/spacetab-io/configuration-structs-go/v2 v2.0.0-alpha2
 	github.com/spacetab-io/errors-go v1.3.0
 	github.com/spacetab-io/logs-go/v3 v3.0.0-alpha2
 	github.com/valyala/fasthttp v1.37.0
+	github.com/vmihailenco/msgpack v4.0.4+incompatible
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/sdk v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
 	go.uber.org/zap v1.21.0
 )
 
 require (
-	github.com/andybalholm/brotli v1.0.4 // indirect
 	github.com/asaskevich/govalidator v0.0.0-20210307081110-f21760c49a8d // indirect
 	github.com/getsentry/sentry-go v0.13.0 // indirect
-	github.com/go-playground/locales v0.14.0 // indirect
-	github.com/go-playground/universal-translator v0.18.0 // indirect
-	github.com/klauspost/compress v1.15.0 // indirect
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/leodido/go-urn v1.2.1 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect